@@ -61,13 +61,11 @@ func mainLoop() {
 	b := createView()
 	b.SetData([]float64{1, 2, 3, 4, 5, 6, 6, 7, 5, 8, 9})
 
-	ticker := time.NewTicker(time.Millisecond * 200).C
-	go func() {
-		for range ticker {
-			b.AddData(float64(rand.Int31n(20))) //nolint
-			ui.PutEvent(ui.Event{Type: ui.EventRedraw})
-		}
-	}()
+	ui.Every(time.Millisecond*200, func() {
+		b.AddData(float64(rand.Int31n(20))) //nolint
+		// repaint only the chart window
+		ui.Invalidate(b)
+	})
 
 	// start event processing loop - the main core of the library
 	ui.MainLoop()
@@ -5,5 +5,6 @@ go 1.16
 require (
 	github.com/atotto/clipboard v0.1.4
 	github.com/huandu/xstrings v1.3.2
+	github.com/mattn/go-runewidth v0.0.9
 	github.com/nsf/termbox-go v1.1.0
 )
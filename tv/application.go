@@ -0,0 +1,267 @@
+package tv
+
+import (
+	"log"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prospero78/goTV/tv/types"
+)
+
+/*
+Application is an independent goTV UI: it owns its composer with the window
+list, canvas with the screen backend, theme manager, and main loop. A few
+applications can work in one process, e.g. one per SSH session, and every
+test can create its own application without leaking state to others.
+
+Controls and package functions (AddWindow, CreateButton, RefreshScreen,
+SetCurrentTheme, etc) always work with the active application. An
+application is active while its event loop processes an event or while
+a function passed to Do runs. Applications are never active at the same
+time, so their event handlers do not need any extra locking. It also
+means that applications process events one at a time: a slow event
+handler of one application delays all others. Long work must run in a
+separate goroutine that reports the result with Application.Post.
+
+InitLibrary creates the default application. Package functions work with it
+when no other application is active, so a program with one UI does not need
+to use Application directly. Functions that can be called from any
+goroutine (Post, Invoke, PutEvent, Stop, AfterFunc and Every) work with the
+active application when they are called by its event handlers or inside
+Do. Other goroutines always reach the default application with them: use
+the Application methods with the same names for other applications. The
+functions do nothing if there is no default application.
+*/
+type Application struct {
+	composer *Composer
+	canvas   *Canvas
+	themes   *ThemeManager
+	loop     *mainLoop
+	logger   *log.Logger
+	closer   sync.Once
+	// screen events read after the event loop stopped, they are processed
+	// by the next RunContext
+	pending []Event
+}
+
+var (
+	// appMtx makes sure that only one application is active at a time
+	appMtx sync.Mutex
+	// defaultApp is created by InitLibrary and it is active by default
+	defaultApp *Application
+	// curApp is the application package functions work with
+	curApp *Application
+	// defaultMainLoop keeps the loop of defaultApp. Functions that can be
+	// called from any goroutine, like Post and PutEvent, use it instead of
+	// loop that is switched while other applications process events
+	defaultMainLoop atomic.Value
+	// activeGoroutine is the id of the goroutine that runs an application
+	// other than the default one, or 0
+	activeGoroutine int64
+)
+
+// defaultLoop returns the main loop of the default application or nil if
+// InitLibrary has not been called
+func defaultLoop() *mainLoop {
+	l, _ := defaultMainLoop.Load().(*mainLoop)
+	return l
+}
+
+// callLoop returns the loop for goroutine safe package functions: the
+// loop of the active application if the caller is its event handler, or
+// the loop of the default application for all other callers
+func callLoop() *mainLoop {
+	if id := atomic.LoadInt64(&activeGoroutine); id != 0 && id == goroutineID() {
+		// only the active goroutine changes loop, so it reads it safely
+		return loop
+	}
+	return defaultLoop()
+}
+
+// goroutineID returns the id of the calling goroutine. The first line of
+// its stack trace is "goroutine 42 [running]:"
+func goroutineID() int64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	fields := strings.Fields(string(buf[:n]))
+	if len(fields) < 2 {
+		return 0
+	}
+	id, _ := strconv.ParseInt(fields[1], 10, 64)
+	return id
+}
+
+// NewApplication creates a new application that draws to the screen and
+// reads events from it. The screen is initialized immediately
+func NewApplication(screen Screen) (*Application, error) {
+	cnv, err := newCanvas(screen)
+	if err != nil {
+		return nil, err
+	}
+
+	app := &Application{
+		composer: newComposer(),
+		canvas:   cnv,
+		themes:   newThemeManager(),
+		loop:     newMainLoop(),
+	}
+
+	return app, nil
+}
+
+// DefaultApplication returns the application created by InitLibrary
+func DefaultApplication() *Application {
+	return defaultApp
+}
+
+// makeCurrent points package state to the application parts. Values are
+// assigned only if they change to avoid writes while other goroutines
+// read them, e.g. when they call PutEvent
+func (a *Application) makeCurrent() {
+	if curApp == a {
+		return
+	}
+
+	curApp = a
+	comp = a.composer
+	canvas = a.canvas
+	themeManager = a.themes
+	loop = a.loop
+}
+
+func (a *Application) enter() {
+	appMtx.Lock()
+	a.makeCurrent()
+	if a != defaultApp {
+		atomic.StoreInt64(&activeGoroutine, goroutineID())
+	}
+}
+
+func (a *Application) leave() {
+	atomic.StoreInt64(&activeGoroutine, 0)
+	if defaultApp != nil {
+		defaultApp.makeCurrent()
+	}
+	appMtx.Unlock()
+}
+
+// Do makes the application active and calls fn. All package functions
+// called inside fn work with the application. Do must not be called from
+// event handlers of any application: handlers already run inside Do
+func (a *Application) Do(fn func()) {
+	a.enter()
+	defer a.leave()
+
+	fn()
+}
+
+// Close finalizes the application screen and restores the terminal. It
+// is safe to call Close a few times
+func (a *Application) Close() {
+	a.closer.Do(a.canvas.screen.Close)
+}
+
+// Screen returns the application screen backend
+func (a *Application) Screen() Screen {
+	return a.canvas.screen
+}
+
+// Composer returns the application window manager
+func (a *Application) Composer() *Composer {
+	return a.composer
+}
+
+// Logger returns the application logger. The package function Logger
+// returns it inside event handlers of the application. Applications write
+// to the shared debug log until SetLogger is called. The library itself
+// does not log anything, the logger is for the application code
+func (a *Application) Logger() *log.Logger {
+	if a.logger == nil {
+		if logger == nil {
+			InitLogger()
+		}
+		a.logger = logger
+	}
+
+	return a.logger
+}
+
+// SetLogger changes the application logger
+func (a *Application) SetLogger(l *log.Logger) {
+	a.logger = l
+}
+
+// AddWindow constructs a new Window inside the application. See AddWindow
+// for details
+func (a *Application) AddWindow(posX types.ACoordX, posY types.ACoordY,
+	width, height int,
+	title string,
+	autoWidth types.AAutoWidth,
+	autoHeight types.AAutoHeight) *TWindow {
+	var wnd *TWindow
+	a.Do(func() {
+		wnd = AddWindow(posX, posY, width, height, title, autoWidth, autoHeight)
+	})
+
+	return wnd
+}
+
+// RefreshScreen repaints all application windows
+func (a *Application) RefreshScreen() {
+	a.Do(RefreshScreen)
+}
+
+// ProcessEvent processes the event inside the application
+func (a *Application) ProcessEvent(ev Event) {
+	a.Do(func() {
+		ProcessEvent(ev)
+	})
+}
+
+// PutEvent sends the event to the application main loop. It is safe to
+// call it from any goroutine
+func (a *Application) PutEvent(ev Event) {
+	a.loop.put(ev)
+}
+
+// Post queues fn to run in the application main loop. See Post for details
+func (a *Application) Post(fn func()) {
+	a.loop.post(fn)
+}
+
+// Invoke runs fn in the application main loop and waits until fn
+// finishes. See Invoke for details
+func (a *Application) Invoke(fn func()) {
+	<-a.loop.post(fn)
+}
+
+// AfterFunc calls fn in the application main loop once after d passes
+func (a *Application) AfterFunc(d time.Duration, fn func()) *Timer {
+	return a.loop.startTimer(d, 0, fn)
+}
+
+// Every calls fn in the application main loop every d until the timer is
+// stopped
+func (a *Application) Every(d time.Duration, fn func()) *Timer {
+	return a.loop.startTimer(d, d, fn)
+}
+
+// Stop asks the application main loop to quit
+func (a *Application) Stop() {
+	a.PutEvent(Event{Type: EventQuit})
+}
+
+// SetCurrentTheme changes the application theme. Returns false if the
+// theme does not exist
+func (a *Application) SetCurrentTheme(name string) bool {
+	res := false
+	a.Do(func() {
+		res = SetCurrentTheme(name)
+	})
+
+	return res
+}
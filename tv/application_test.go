@@ -0,0 +1,155 @@
+package tv
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplicationsAreIndependent(t *testing.T) {
+	scrDef := NewHeadlessScreen(30, 6)
+	InitLibrary(scrDef)
+	defer DeinitLibrary()
+
+	scr1, scr2 := NewHeadlessScreen(30, 6), NewHeadlessScreen(30, 6)
+	app1, err := NewApplication(scr1)
+	if err != nil {
+		t.Fatalf("Failed to create application: %v", err)
+	}
+	app2, _ := NewApplication(scr2)
+
+	app1.AddWindow(0, 0, 20, 4, "First", false, false)
+	app2.AddWindow(0, 0, 20, 4, "Second", false, false)
+	app2.AddWindow(2, 1, 20, 4, "Third", false, false)
+	app1.RefreshScreen()
+	app2.RefreshScreen()
+
+	if !strings.Contains(scr1.String(), "First") || strings.Contains(scr1.String(), "Second") {
+		t.Errorf("First application screen is invalid:\n%v", scr1.String())
+	}
+	if !strings.Contains(scr2.String(), "Third") || strings.Contains(scr2.String(), "First") {
+		t.Errorf("Second application screen is invalid:\n%v", scr2.String())
+	}
+	if len(app1.Composer().windows) != 1 || len(app2.Composer().windows) != 2 {
+		t.Errorf("Applications must not share windows")
+	}
+	if len(WindowManager().windows) != 0 {
+		t.Errorf("Default application must not have windows after other applications added theirs")
+	}
+	if DefaultApplication().Screen() != scrDef {
+		t.Errorf("Default application must use the screen passed to InitLibrary")
+	}
+}
+
+func TestApplicationMainLoops(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(30, 6))
+	defer DeinitLibrary()
+
+	var apps []*Application
+	var edits []*TEditField
+	done := make(chan struct{}, 2)
+	for i := 0; i < 2; i++ {
+		app, _ := NewApplication(NewHeadlessScreen(30, 6))
+		app.Do(func() {
+			wnd := AddWindow(0, 0, 20, 3, "Edit", false, false)
+			edit := CreateEditField(wnd, 10, "", 1)
+			ActivateControl(wnd, edit)
+			edit.OnChange(func(ev Event) {
+				if strings.HasSuffix(ev.Msg, "ok") {
+					app.Stop()
+				}
+			})
+			edits = append(edits, edit)
+		})
+		apps = append(apps, app)
+
+		go func() {
+			app.MainLoop()
+			done <- struct{}{}
+		}()
+	}
+
+	for i, app := range apps {
+		scr := app.Screen().(*HeadlessScreen)
+		scr.InjectRune(rune('a' + i))
+		scr.InjectRune('o')
+		scr.InjectRune('k')
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Application main loop did not finish")
+		}
+	}
+
+	if edits[0].Title() != "aok" || edits[1].Title() != "bok" {
+		t.Errorf("Applications must process only own events: %v, %v", edits[0].Title(), edits[1].Title())
+	}
+}
+
+func TestPostToDefaultApplication(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(30, 6))
+	defer DeinitLibrary()
+
+	done := make(chan struct{})
+	go func() {
+		MainLoop()
+		close(done)
+	}()
+
+	// other goroutines reach the default application even while another
+	// application is active, its event handlers reach their own one
+	app, _ := NewApplication(NewHeadlessScreen(30, 6))
+	posted := make(chan struct{})
+	app.Do(func() {
+		sent := make(chan struct{})
+		go func() {
+			Post(func() {
+				close(posted)
+			})
+			close(sent)
+		}()
+		<-sent
+
+		Post(func() {})
+		Stop()
+	})
+	select {
+	case <-posted:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Post must queue the function to the default application")
+	}
+	if app.loop.nextCall() == nil {
+		t.Errorf("Post inside Do must queue the function to the active application")
+	}
+	if ev, ok := app.loop.nextEvent(); !ok || ev.Type != EventCall {
+		t.Errorf("Post inside Do must wake the active application up")
+	}
+	if ev, ok := app.loop.nextEvent(); !ok || ev.Type != EventQuit {
+		t.Errorf("Stop inside Do must stop the active application")
+	}
+
+	Stop()
+	<-done
+}
+
+func TestPostWithoutDefaultApplication(t *testing.T) {
+	saved := defaultLoop()
+	defaultMainLoop.Store((*mainLoop)(nil))
+	defer defaultMainLoop.Store(saved)
+
+	// without InitLibrary the functions do nothing instead of panicking
+	PutEvent(Event{Type: EventRedraw})
+	Post(func() {
+		t.Errorf("Function must not run without an application")
+	})
+	Invoke(func() {
+		t.Errorf("Function must not run without an application")
+	})
+	if !AfterFunc(time.Millisecond, func() {}).Stopped() {
+		t.Errorf("Timer must not start without an application")
+	}
+	Stop()
+}
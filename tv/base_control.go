@@ -217,11 +217,12 @@ func (sf *TBaseControl) SetVisible(visible bool) {
 		p = p.Parent()
 	}
 
+	l := loop
 	go func() {
 		if FindFirstActiveControl(sf) != nil && !sf.inactive {
-			PutEvent(Event{Type: EventKey, Key: term.KeyTab})
+			l.put(Event{Type: EventKey, Key: term.KeyTab})
 		}
-		PutEvent(Event{Type: EventLayout, Target: p})
+		l.put(Event{Type: EventLayout, Target: p})
 	}()
 }
 
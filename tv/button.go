@@ -16,6 +16,9 @@ import (
 Button is a simpe push button control. Every time a user clicks a Button, it
 emits OnClick event. Event has only one valid field Sender.
 Button can be clicked with mouse or using space on keyboard while the Button is active.
+Title can contain an accelerator: "~O~K" is clicked with Alt+O.
+A Button can run a command (see Composer.RegisterCommand): the button is
+disabled while the command is disabled.
 */
 type Button struct {
 	TBaseControl
@@ -23,8 +26,12 @@ type Button struct {
 	pressed     int32
 	shadowType  ButtonShadow
 	onClick     func(Event)
-	autoWidth   types.IAutoWidth
-	autoHeight  types.IAutoHeight
+	command     string
+	// the bound command is disabled: the button is disabled regardless
+	// of its own state
+	commandDisabled bool
+	autoWidth       types.IAutoWidth
+	autoHeight      types.IAutoHeight
 }
 
 /*
@@ -56,7 +63,7 @@ func CreateButton(parent IControl,
 		height = 4
 	}
 	if b.autoWidth.Is() {
-		width = xs.Len(title) + 2 + 1
+		width = xs.Len(stripHotkey(title)) + 2 + 1
 	}
 
 	if height < 4 {
@@ -95,7 +102,7 @@ func (b *Button) Draw() {
 	fg, bg := b.fg, b.bg
 	shadow := RealColor(b.shadowColor, b.Style(), ColorButtonShadow)
 	switch {
-	case b.disabled:
+	case !b.Enabled():
 		fg, bg = RealColor(fg, b.Style(), ColorButtonDisabledText), RealColor(bg, b.Style(), ColorButtonDisabledBack)
 	case b.Active():
 		fg, bg = RealColor(b.fgActive, b.Style(), ColorButtonActiveText), RealColor(b.bgActive, b.Style(), ColorButtonActiveBack)
@@ -103,9 +110,14 @@ func (b *Button) Draw() {
 		fg, bg = RealColor(fg, b.Style(), ColorButtonText), RealColor(bg, b.Style(), ColorButtonBack)
 	}
 
+	title := stripHotkey(b.title)
+	if b.Enabled() {
+		title = hotkeyTitle(b.title, RealColor(ColorDefault, b.Style(), ColorButtonHotkeyText))
+	}
+
 	dy := (h - 1) / 2
 	SetTextColor(fg)
-	shift, text := AlignColorizedText(b.title, w-1, b.align)
+	shift, text := AlignColorizedText(title, w-1, b.align)
 	if b.isPressed() == 0 {
 		switch b.shadowType {
 		case ShadowFull:
@@ -131,7 +143,7 @@ func (b *Button) Draw() {
 	} else {
 		SetBackColor(bg)
 		FillRect(x+1, y+1, w-1, h-1, ' ')
-		DrawText(x+types.ACoordX(1+shift), y+types.ACoordY(1+dy), b.title)
+		DrawText(x+types.ACoordX(1+shift), y+types.ACoordY(1+dy), text)
 	}
 }
 
@@ -157,18 +169,17 @@ func (b *Button) ProcessEvent(event Event) bool {
 	if event.Type == EventKey {
 		if event.Key == term.KeySpace && b.isPressed() == 0 {
 			b.setPressed(1)
-			ev := Event{Type: EventRedraw}
+			ev := Event{Type: EventRedraw, Target: b}
 
+			l := loop
 			go func() {
-				PutEvent(ev)
+				l.put(ev)
 				time.Sleep(100 * time.Millisecond)
 				b.setPressed(0)
-				PutEvent(ev)
+				l.put(ev)
 			}()
 
-			if b.onClick != nil {
-				b.onClick(event)
-			}
+			b.click(event)
 			return true
 		} else if event.Key == term.KeyEsc && b.isPressed() != 0 {
 			b.setPressed(0)
@@ -186,9 +197,7 @@ func (b *Button) ProcessEvent(event Event) bool {
 				event.Y >= b.pos.GetY() &&
 				event.X < b.pos.GetX()+types.ACoordX(b.width.Get()) &&
 				event.Y < b.pos.GetY()+types.ACoordY(b.height.Get()) {
-				if b.onClick != nil {
-					b.onClick(event)
-				}
+				b.click(event)
 			}
 			b.setPressed(0)
 			return true
@@ -198,12 +207,51 @@ func (b *Button) ProcessEvent(event Event) bool {
 	return false
 }
 
+// click calls OnClick callback and runs the button command
+func (b *Button) click(event Event) {
+	if b.onClick != nil {
+		b.onClick(event)
+	}
+	if b.command != "" {
+		comp.runCommand(b.command, Event{Type: EventClick, Msg: b.command, Target: b})
+	}
+}
+
 // OnClick sets the callback that is called when one clicks button
 // with mouse or pressing space on keyboard while the button is active
 func (b *Button) OnClick(fn func(Event)) {
 	b.onClick = fn
 }
 
+// Command returns the command the button runs
+func (b *Button) Command() string {
+	return b.command
+}
+
+// SetCommand sets the command the button runs after OnClick callback. The
+// button is disabled while the command is disabled, its own state set with
+// SetEnabled is kept. Empty command unbinds the button
+func (b *Button) SetCommand(command string) {
+	b.command = command
+	b.setCommandEnabled(true)
+	comp.bindControl(b, command)
+}
+
+// Enabled returns false if the button or its command is disabled
+func (b *Button) Enabled() bool {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+	return !b.disabled && !b.commandDisabled
+}
+
+// setCommandEnabled is called when the bound command is enabled or
+// disabled
+func (b *Button) setCommandEnabled(enabled bool) {
+	b.mtx.Lock()
+	b.commandDisabled = !enabled
+	b.mtx.Unlock()
+}
+
 // ShadowType returns type of a show the button drops
 func (b *Button) ShadowType() ButtonShadow {
 	return b.shadowType
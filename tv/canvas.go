@@ -35,23 +35,24 @@ type Canvas struct {
 	clipH     int
 	attrStack []attr
 	clipStack []rect
+	screen    Screen
 }
 
 var (
 	canvas *Canvas
 )
 
-func initCanvas() bool {
-	err := term.Init()
+func newCanvas(screen Screen) (*Canvas, error) {
+	err := screen.Init()
 	if err != nil {
-		return false
+		return nil, err
 	}
-	term.SetInputMode(term.InputEsc | term.InputMouse)
 
-	canvas = new(Canvas)
-	Reset()
+	c := new(Canvas)
+	c.screen = screen
+	c.reset()
 
-	return true
+	return c, nil
 }
 
 // PushAttributes saves the current back and fore colors. Useful when used with
@@ -98,14 +99,18 @@ func PopClip() {
 // terminal window, clears clip and color saved data, sets colors
 // to default ones
 func Reset() {
-	canvas.width, canvas.height = term.Size()
-	canvas.clipX, canvas.clipY = 0, 0
-	canvas.clipW, canvas.clipH = canvas.width, canvas.height
-	canvas.textColor = ColorWhite
-	canvas.backColor = ColorBlack
+	canvas.reset()
+}
 
-	canvas.attrStack = make([]attr, 0)
-	canvas.clipStack = make([]rect, 0)
+func (c *Canvas) reset() {
+	c.width, c.height = c.screen.Size()
+	c.clipX, c.clipY = 0, 0
+	c.clipW, c.clipH = c.width, c.height
+	c.textColor = ColorWhite
+	c.backColor = ColorBlack
+
+	c.attrStack = make([]attr, 0)
+	c.clipStack = make([]rect, 0)
 }
 
 // InClipRect returns true if x and y position is inside current clipping
@@ -142,9 +147,14 @@ func clip(x types.ACoordX, y types.ACoordY, w, h int) (cx types.ACoordX, cy type
 	return x, y, w, h
 }
 
-// Flush makes termbox to draw everything to screen
+// Flush makes the screen backend to draw everything to screen
 func Flush() {
-	term.Flush()
+	_ = canvas.screen.Flush()
+}
+
+// CurrentScreen returns the screen backend the library draws to
+func CurrentScreen() Screen {
+	return canvas.screen
 }
 
 // SetSize sets the new Canvas size. If new size does not
@@ -170,7 +180,13 @@ func ScreenSize() (width int, height int) {
 
 // SetCursorPos sets text caret position. Used by controls like EditField
 func SetCursorPos(x types.ACoordX, y types.ACoordY) {
-	term.SetCursor(int(x), int(y))
+	canvas.screen.SetCursor(int(x), int(y))
+}
+
+// HideCursor makes text caret invisible. Used by controls like EditField
+// when they lose focus
+func HideCursor() {
+	canvas.screen.HideCursor()
 }
 
 // PutChar sets value for the Canvas cell: rune and its colors. Returns result of
@@ -178,7 +194,7 @@ func SetCursorPos(x types.ACoordX, y types.ACoordY) {
 // and the function returns false
 func PutChar(x types.ACoordX, y types.ACoordY, r rune) bool {
 	if InClipRect(x, y) {
-		term.SetCell(int(x), int(y), r, canvas.textColor, canvas.backColor)
+		canvas.screen.SetCell(int(x), int(y), r, canvas.textColor, canvas.backColor)
 		return true
 	}
 
@@ -186,14 +202,13 @@ func PutChar(x types.ACoordX, y types.ACoordY, r rune) bool {
 }
 
 func putCharUnsafe(x types.ACoordX, y types.ACoordY, r rune) {
-	term.SetCell(int(x), int(y), r, canvas.textColor, canvas.backColor)
+	canvas.screen.SetCell(int(x), int(y), r, canvas.textColor, canvas.backColor)
 }
 
 // Symbol returns the character and its attributes by its coordinates
 func Symbol(x, y int) (term.Cell, bool) {
 	if x >= 0 && x < canvas.width && y >= 0 && y < canvas.height {
-		cells := term.CellBuffer()
-		return cells[y*canvas.width+x], true
+		return canvas.screen.Cell(x, y), true
 	}
 	return term.Cell{Ch: ' '}, false
 }
@@ -30,7 +30,8 @@ width - is minimal width of the control.
 title - button title.
 scale - the way of scaling the control when the parent is resized. Use DoNotScale constant if the
 control should keep its original size.
-CheckBox state can be changed using mouse or pressing space on keyboard while the control is active
+CheckBox state can be changed using mouse or pressing space on keyboard while the control is active.
+Title can contain an accelerator: "~B~old" changes the state with Alt+B
 */
 func CreateCheckBox(parent IControl,
 	width int,
@@ -47,7 +48,7 @@ func CreateCheckBox(parent IControl,
 	c.parent = parent
 
 	if c.autoWidth.Is() {
-		width = xs.Len(title) + 4
+		width = xs.Len(stripHotkey(title)) + 4
 	}
 
 	c.SetSize(width, 1) // TODO: only one line checkboxes are supported at that moment
@@ -108,7 +109,11 @@ func (c *CheckBox) Draw() {
 		return
 	}
 
-	shift, text := AlignColorizedText(c.title, w-4, c.align)
+	title := stripHotkey(c.title)
+	if c.Enabled() {
+		title = hotkeyTitle(c.title, RealColor(ColorDefault, c.Style(), ColorControlHotkeyText))
+	}
+	shift, text := AlignColorizedText(title, w-4, c.align)
 	DrawText(x+types.ACoordX(4+shift), y, text)
 }
 
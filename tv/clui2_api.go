@@ -1,18 +1,35 @@
 package tv
 
-import (
-	term "github.com/nsf/termbox-go"
-)
+// InitLibrary initializes the library: creates the default Application with
+// its theme manager, composer, main loop, and the screen backend. The library draws to the terminal with termbox by
+// default. Pass a Screen to use another backend, e.g, InitLibrary(myScreen).
+// Only the first screen is used. Returns false if the backend failed to
+// initialize
+func InitLibrary(screen ...Screen) bool {
+	var scr Screen = NewTermboxScreen()
+	if len(screen) > 0 && screen[0] != nil {
+		scr = screen[0]
+	}
 
-func InitLibrary() bool {
-	initThemeManager()
-	initComposer()
-	initMainLoop()
-	return initCanvas()
+	app, err := NewApplication(scr)
+	if err != nil {
+		return false
+	}
+
+	appMtx.Lock()
+	defaultApp = app
+	defaultMainLoop.Store(app.loop)
+	app.makeCurrent()
+	appMtx.Unlock()
+
+	return true
 }
 
-// Close closes console management and makes a console cursor visible
+// DeinitLibrary closes console management and makes a console cursor
+// visible. Call it with defer right after InitLibrary: then the terminal is
+// restored even if an event handler panics
 func DeinitLibrary() {
-	term.SetCursor(3, 3)
-	term.Close()
+	if defaultApp != nil {
+		defaultApp.Close()
+	}
 }
@@ -0,0 +1,369 @@
+package tv
+
+import (
+	"strings"
+	"unicode"
+
+	xs "github.com/huandu/xstrings"
+	term "github.com/nsf/termbox-go"
+
+	"github.com/prospero78/goTV/tv/types"
+)
+
+/*
+ComboBox is a one line control with a drop-down list of items. In the
+default mode a user can only select one of the items. An editable ComboBox
+contains an edit field: a user can type any text or select an item.
+The drop-down list is shown above all windows. Alt+Down, F4 or a click on
+the arrow opens the list (in the default mode a click anywhere opens it),
+Up, Down, PgUp and PgDn select an item, Enter, Tab or a click accepts it,
+Esc closes the list. The list is closed when the ComboBox loses focus.
+While the list is closed Up and Down select the
+previous and the next item, in the default mode typing a letter selects
+the next item that starts with the letter.
+ComboBox calls onChange when its text or the selected item is changed.
+Event type is EventChanged, Msg is the new text, Y is the selected item or
+-1 if the text of an editable ComboBox does not match any item. X is 1 if
+the item was selected with the mouse and 0 otherwise
+*/
+type ComboBox struct {
+	TBaseControl
+	items    []string
+	selected int
+	editable bool
+	edit     *TEditField
+	list     *dropDown
+	// how the item is being selected: it is the Event X value of the
+	// change emitted by the edit field
+	how int
+
+	onChange func(Event)
+}
+
+/*
+CreateComboBox creates a new ComboBox control.
+parent - is container that keeps the control.
+width - is minimal width of the control.
+editable - whether a user can type any text or only select an item.
+scale - the way of scaling the control when the parent is resized. Use DoNotScale constant if the
+control should keep its original size.
+*/
+func CreateComboBox(parent IControl, width int, editable bool, scale int) *ComboBox {
+	cb := &ComboBox{
+		TBaseControl: NewBaseControl(),
+		selected:     -1,
+		editable:     editable,
+	}
+	cb.parent = parent
+	cb.list = newDropDown(cb, func(string) {
+		cb.selectItem(cb.list.SelectedItem(), 1)
+	})
+
+	if width < 3 {
+		width = 3
+	}
+	if editable {
+		cb.edit = CreateEditField(nil, width-1, "", Fixed)
+		cb.edit.OnChange(func(ev Event) {
+			text := cb.edit.Title()
+			if cb.selected < 0 || cb.selected >= len(cb.items) || cb.items[cb.selected] != text {
+				cb.selected = cb.indexOf(text)
+			}
+			cb.emitChange(cb.how)
+		})
+	}
+	cb.SetSize(width, 1)
+	cb.SetConstraints(width, 1)
+	cb.SetTabStop(true)
+	cb.SetScale(scale)
+
+	if parent != nil {
+		parent.AddChild(cb)
+	}
+
+	return cb
+}
+
+// Draw repaints the control on its View surface
+func (cb *ComboBox) Draw() {
+	if cb.hidden {
+		return
+	}
+
+	PushAttributes()
+	defer PopAttributes()
+
+	x, y := cb.Pos().Get()
+	w, _ := cb.Size()
+
+	fg, bg := RealColor(cb.fg, cb.Style(), ColorEditText), RealColor(cb.bg, cb.Style(), ColorEditBack)
+	if !cb.Enabled() {
+		fg, bg = RealColor(cb.fg, cb.Style(), ColorDisabledText), RealColor(cb.bg, cb.Style(), ColorDisabledBack)
+	} else if cb.Active() {
+		fg, bg = RealColor(cb.fg, cb.Style(), ColorEditActiveText), RealColor(cb.bg, cb.Style(), ColorEditActiveBack)
+	}
+
+	if cb.editable {
+		cb.syncEdit()
+		cb.edit.Draw()
+	} else {
+		SetTextColor(fg)
+		SetBackColor(bg)
+		FillRect(x, y, w-1, 1, ' ')
+		text := cb.Text()
+		if xs.Len(text) > w-1 {
+			text = xs.Slice(text, 0, w-1)
+		}
+		DrawRawText(x, y, text)
+	}
+
+	parts := []rune(SysObject(ObjEdit))
+	SetTextColor(fg)
+	SetBackColor(bg)
+	PutChar(x+types.ACoordX(w-1), y, parts[2])
+}
+
+// syncEdit moves the edit field of an editable ComboBox to the control
+// position: the edit field is not a child control
+func (cb *ComboBox) syncEdit() {
+	x, y := cb.Pos().Get()
+	w, _ := cb.Size()
+	cb.edit.SetPos(x, y)
+	cb.edit.SetSize(w-1, 1)
+	cb.edit.SetEnabled(cb.Enabled())
+	cb.edit.SetActive(cb.Active())
+	cb.edit.SetStyle(cb.Style())
+}
+
+/*
+ProcessEvent processes all events come from the control parent. If a control
+processes an event it should return true. If the method returns false it means
+that the control do not want or cannot process the event and the caller sends
+the event to the control parent
+*/
+func (cb *ComboBox) ProcessEvent(event Event) bool {
+	if cb.editable {
+		cb.syncEdit()
+	}
+	if event.Type == EventActivate && event.X == 0 {
+		cb.list.hide()
+		if cb.editable {
+			cb.edit.ProcessEvent(event)
+		}
+	}
+
+	if !cb.Active() || !cb.Enabled() {
+		return false
+	}
+
+	switch event.Type {
+	case EventKey:
+		if cb.list.processKey(event) {
+			return true
+		}
+
+		switch {
+		case (event.Key == term.KeyArrowDown && event.Alt()) || event.Key == term.KeyF4:
+			cb.OpenList()
+			return true
+		case event.Key == term.KeyArrowUp && !event.Alt():
+			return cb.SetSelected(cb.selected - 1)
+		case event.Key == term.KeyArrowDown:
+			return cb.SetSelected(cb.selected + 1)
+		}
+
+		if cb.editable {
+			return cb.edit.ProcessEvent(event)
+		}
+		if event.Ch != 0 && !event.Alt() {
+			cb.selectByLetter(event.Ch)
+			return true
+		}
+		return false
+	case EventMouse:
+		if event.Key != term.MouseLeft || event.Mod&term.ModMotion != 0 {
+			return false
+		}
+		w, _ := cb.Size()
+		if cb.editable && event.X < cb.pos.GetX()+types.ACoordX(w-1) {
+			return cb.edit.ProcessEvent(event)
+		}
+		if cb.list.isOpen() {
+			cb.list.hide()
+		} else {
+			cb.OpenList()
+		}
+		return true
+	case EventPaste, EventMouseDrag, EventDoubleClick, EventActivate:
+		if cb.editable {
+			return cb.edit.ProcessEvent(event)
+		}
+	}
+
+	return false
+}
+
+// selectItem selects the item and emits the change. how is the Event X
+// value: 1 for the mouse, 0 for the keyboard
+func (cb *ComboBox) selectItem(idx, how int) {
+	if idx < 0 || idx >= len(cb.items) {
+		return
+	}
+	if cb.editable {
+		// the edit field emits the change
+		cb.selected, cb.how = idx, how
+		cb.edit.SetTitle(cb.items[idx])
+		cb.how = 0
+		return
+	}
+	if cb.selected != idx {
+		cb.selected = idx
+		cb.emitChange(how)
+	}
+}
+
+// selectByLetter selects the next item that starts with the letter
+func (cb *ComboBox) selectByLetter(ch rune) {
+	ch = unicode.ToLower(ch)
+	for i := 1; i <= len(cb.items); i++ {
+		idx := (cb.selected + i) % len(cb.items)
+		if idx < 0 {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(cb.items[idx]), string(ch)) {
+			cb.selectItem(idx, 0)
+			return
+		}
+	}
+}
+
+// indexOf returns the index of the first item equal to the text or -1
+func (cb *ComboBox) indexOf(text string) int {
+	for i, item := range cb.items {
+		if item == text {
+			return i
+		}
+	}
+	return -1
+}
+
+func (cb *ComboBox) emitChange(how int) {
+	if cb.onChange != nil {
+		ev := Event{Type: EventChanged, Msg: cb.Text(), X: types.ACoordX(how), Y: types.ACoordY(cb.selected), Target: cb}
+		cb.onChange(ev)
+	}
+}
+
+// OpenList shows the drop-down list of items. The list is not shown if
+// the ComboBox does not have items
+func (cb *ComboBox) OpenList() {
+	if len(cb.items) == 0 {
+		return
+	}
+	cb.list.show(cb.items, cb.selected)
+}
+
+// CloseList hides the drop-down list
+func (cb *ComboBox) CloseList() {
+	cb.list.hide()
+}
+
+// ListOpen returns true while the drop-down list is shown
+func (cb *ComboBox) ListOpen() bool {
+	return cb.list.isOpen()
+}
+
+// SetItems replaces the items. The selection is reset: the default
+// ComboBox selects the first item, an editable one keeps its text
+func (cb *ComboBox) SetItems(items []string) {
+	cb.items = append([]string(nil), items...)
+	cb.list.hide()
+	if cb.editable {
+		cb.selected = cb.indexOf(cb.edit.Title())
+		return
+	}
+	cb.selected = -1
+	cb.selectItem(0, 0)
+}
+
+// Items returns the items of the ComboBox
+func (cb *ComboBox) Items() []string {
+	return append([]string(nil), cb.items...)
+}
+
+// Selected returns the index of the selected item or -1
+func (cb *ComboBox) Selected() int {
+	return cb.selected
+}
+
+// SetSelected selects the item. Returns false if the index is out of range
+func (cb *ComboBox) SetSelected(idx int) bool {
+	if idx < 0 || idx >= len(cb.items) {
+		return false
+	}
+	cb.selectItem(idx, 0)
+	return true
+}
+
+// Text returns the selected item or the text of an editable ComboBox
+func (cb *ComboBox) Text() string {
+	if cb.editable {
+		return cb.edit.Title()
+	}
+	if cb.selected == -1 {
+		return ""
+	}
+	return cb.items[cb.selected]
+}
+
+// SetText changes the text of an editable ComboBox. The default ComboBox
+// selects the item equal to the text. Returns false if the text is not
+// set
+func (cb *ComboBox) SetText(text string) bool {
+	if !cb.editable {
+		return cb.SetSelected(cb.indexOf(text))
+	}
+	cb.edit.SetTitle(text)
+	return true
+}
+
+// Editable returns true if a user can type any text
+func (cb *ComboBox) Editable() bool {
+	return cb.editable
+}
+
+// EditField returns the edit field of an editable ComboBox, e.g. to set
+// a validator, or nil
+func (cb *ComboBox) EditField() *TEditField {
+	return cb.edit
+}
+
+// Validate checks the text of an editable ComboBox with the validator of
+// its edit field, see TEditField.Validate
+func (cb *ComboBox) Validate() error {
+	if cb.edit == nil {
+		return nil
+	}
+	return cb.edit.Validate()
+}
+
+// MaxDropDownHeight returns the maximum number of visible items of the
+// drop-down list
+func (cb *ComboBox) MaxDropDownHeight() int {
+	return cb.list.maxHeight
+}
+
+// SetMaxDropDownHeight sets the maximum number of visible items of the
+// drop-down list. The list is scrolled if there are more items
+func (cb *ComboBox) SetMaxDropDownHeight(h int) {
+	if h < 1 {
+		h = 1
+	}
+	cb.list.maxHeight = h
+}
+
+// OnChange sets the callback that is called when the text or the selected
+// item is changed
+func (cb *ComboBox) OnChange(fn func(Event)) {
+	cb.onChange = fn
+}
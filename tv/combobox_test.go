@@ -0,0 +1,126 @@
+package tv
+
+import (
+	"strings"
+	"testing"
+
+	term "github.com/nsf/termbox-go"
+)
+
+func newTestCombo(editable bool) (*ComboBox, *TEditField, *HeadlessScreen) {
+	scr := NewHeadlessScreen(40, 12)
+	InitLibrary(scr)
+
+	wnd := AddWindow(0, 0, 20, 6, "Combo", false, false)
+	wnd.SetPack(Vertical)
+	cb := CreateComboBox(wnd, 10, editable, Fixed)
+	cb.SetItems([]string{"red", "green", "blue", "gray", "black"})
+	edit := CreateEditField(wnd, 10, "", Fixed)
+	wnd.ResizeChildren()
+	wnd.PlaceChildren()
+	ActivateControl(wnd, cb)
+	return cb, edit, scr
+}
+
+func TestComboBoxList(t *testing.T) {
+	cb, edit, scr := newTestCombo(false)
+	defer DeinitLibrary()
+
+	var changes []Event
+	cb.OnChange(func(ev Event) {
+		changes = append(changes, ev)
+	})
+
+	if cb.Selected() != 0 || cb.Text() != "red" {
+		t.Errorf("First item must be selected: %v", cb.Selected())
+	}
+	ProcessEvent(keyEvent(term.KeyArrowDown))
+	typeText("b")
+	typeText("b")
+	typeText("x")
+	if cb.Text() != "black" || len(changes) != 3 || changes[2].Y != 4 || changes[2].X != 0 {
+		t.Errorf("Wrong keyboard selection: %q, %v changes", cb.Text(), len(changes))
+	}
+
+	cb.SetMaxDropDownHeight(3)
+	ProcessEvent(Event{Type: EventKey, Key: term.KeyArrowDown, Mod: ModAlt})
+	RefreshScreen()
+	if !cb.ListOpen() || cb.list.topLine != 2 || !strings.HasPrefix(scr.Lines()[4], "║black") {
+		t.Fatalf("List must show the selected item: %v <%v>", cb.list.topLine, scr.Lines()[4])
+	}
+	ProcessEvent(keyEvent(term.KeyArrowUp))
+	ProcessEvent(keyEvent(term.KeyEsc))
+	if cb.ListOpen() || cb.Text() != "black" {
+		t.Errorf("Esc must close the list without selection: %q", cb.Text())
+	}
+
+	// the list is drawn over the edit field below the ComboBox
+	ProcessEvent(mouseAt(3, 1, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(3, 1, term.MouseRelease, 0))
+	if !cb.ListOpen() {
+		t.Fatalf("Click must open the list")
+	}
+	ProcessEvent(mouseAt(3, 3, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(3, 3, term.MouseRelease, 0))
+	if cb.Text() != "gray" || cb.ListOpen() || edit.Active() || changes[len(changes)-1].X != 1 {
+		t.Errorf("Click must select the item: %q", cb.Text())
+	}
+
+	cb.OpenList()
+	ProcessEvent(keyEvent(term.KeyTab))
+	if cb.ListOpen() || cb.Text() != "gray" {
+		t.Errorf("Tab must accept the item: %q", cb.Text())
+	}
+	ProcessEvent(keyEvent(term.KeyF4))
+	ProcessEvent(keyEvent(term.KeyTab))
+	ProcessEvent(keyEvent(term.KeyTab))
+	if cb.ListOpen() || !edit.Active() {
+		t.Errorf("List must be closed when focus leaves")
+	}
+
+	if cb.SetText("pink") || !cb.SetText("green") || cb.Selected() != 1 {
+		t.Errorf("Only items can be set: %v", cb.Selected())
+	}
+}
+
+func TestComboBoxEditable(t *testing.T) {
+	cb, _, _ := newTestCombo(true)
+	defer DeinitLibrary()
+
+	var last Event
+	cb.OnChange(func(ev Event) {
+		last = ev
+	})
+
+	if cb.Selected() != -1 || cb.Text() != "" {
+		t.Errorf("Editable ComboBox must keep its text: %v", cb.Selected())
+	}
+	typeText("blue")
+	if cb.Text() != "blue" || last.Y != 2 || last.Msg != "blue" {
+		t.Errorf("Typed item must be selected: %q %v", cb.Text(), last.Y)
+	}
+	typeText("s")
+	if cb.Selected() != -1 || last.Y != -1 {
+		t.Errorf("Any text can be typed: %q %v", cb.Text(), cb.Selected())
+	}
+
+	ProcessEvent(keyEvent(term.KeyArrowDown))
+	if cb.Text() != "red" || cb.Selected() != 0 {
+		t.Errorf("Down must select the next item: %q", cb.Text())
+	}
+	ProcessEvent(Event{Type: EventKey, Key: term.KeyArrowDown, Mod: ModAlt})
+	ProcessEvent(keyEvent(term.KeyArrowDown))
+	ProcessEvent(keyEvent(term.KeyEnter))
+	if cb.Text() != "green" || last.Y != 1 {
+		t.Errorf("Enter must accept the item: %q", cb.Text())
+	}
+
+	ProcessEvent(mouseAt(3, 1, term.MouseLeft, 0))
+	if cb.ListOpen() || cb.EditField().CursorPos() != 2 {
+		t.Errorf("Click on the text must move the cursor: %v", cb.EditField().CursorPos())
+	}
+	ProcessEvent(mouseAt(18, 1, term.MouseLeft, 0))
+	if !cb.ListOpen() {
+		t.Errorf("Click on the arrow must open the list")
+	}
+}
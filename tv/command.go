@@ -0,0 +1,112 @@
+package tv
+
+/*
+Commands are named actions of the application, like Turbo Vision cmXXX
+commands. A command is registered once with its handler and default keys:
+
+	tv.WindowManager().RegisterCommand("Save", "f2", func(ev tv.Event) {
+		// save the document
+	})
+
+Menu items (MenuItem.SetCommand), status bar items (StatusItem.Command),
+buttons (Button.SetCommand) and key bindings refer to the command by its
+name. A disabled command is not executed and all items and buttons bound
+to it are greyed out:
+
+	tv.WindowManager().EnableCommand("Save", false)
+*/
+
+// RegisterCommand sets the command handler and binds the default global
+// key sequence to the command. keys can be empty if the command does not
+// have default keys
+func (c *Composer) RegisterCommand(command, keys string, fn func(Event)) error {
+	if keys != "" {
+		if err := c.keymap.Bind(keys, command); err != nil {
+			return err
+		}
+	}
+	c.HandleCommand(command, fn)
+	return nil
+}
+
+// EnableCommand enables or disables the command. Controls bound to the
+// command are enabled or disabled as well, menu and status bar items
+// bound to the command are drawn disabled
+func (c *Composer) EnableCommand(command string, enabled bool) {
+	if enabled {
+		delete(c.disabledCommands, command)
+	} else {
+		c.disabledCommands[command] = true
+	}
+
+	for _, ctrl := range c.commandControls[command] {
+		setCommandState(ctrl, enabled)
+	}
+	RefreshScreen()
+}
+
+// CommandEnabled returns false if the command is disabled. Commands are
+// enabled by default
+func (c *Composer) CommandEnabled(command string) bool {
+	return !c.disabledCommands[command]
+}
+
+// bindControl makes the enabled state of the control follow the command.
+// Empty command unbinds the control
+func (c *Composer) bindControl(ctrl IControl, command string) {
+	c.unbindControl(ctrl)
+	if command == "" {
+		return
+	}
+
+	c.commandControls[command] = append(c.commandControls[command], ctrl)
+	setCommandState(ctrl, c.CommandEnabled(command))
+}
+
+// commandControl is a control that keeps its own enabled state apart from
+// the state of the bound command, e.g. Button
+type commandControl interface {
+	setCommandEnabled(enabled bool)
+}
+
+// setCommandState passes the command state to the control. Other controls
+// are enabled and disabled directly
+func setCommandState(ctrl IControl, enabled bool) {
+	if cc, ok := ctrl.(commandControl); ok {
+		cc.setCommandEnabled(enabled)
+		return
+	}
+	ctrl.SetEnabled(enabled)
+}
+
+// unbindControl removes the control from all commands
+func (c *Composer) unbindControl(ctrl IControl) {
+	c.removeCommandControls(func(bound IControl) bool {
+		return bound == ctrl
+	})
+}
+
+// removeCommandScope removes controls of the window and all its children
+// from commands
+func (c *Composer) removeCommandScope(wnd IControl) {
+	c.removeCommandControls(func(bound IControl) bool {
+		return ownedBy(bound, wnd)
+	})
+}
+
+// removeCommandControls removes the matching controls from commands
+func (c *Composer) removeCommandControls(match func(IControl) bool) {
+	for command, ctrls := range c.commandControls {
+		var rest []IControl
+		for _, ctrl := range ctrls {
+			if !match(ctrl) {
+				rest = append(rest, ctrl)
+			}
+		}
+		if len(rest) == 0 {
+			delete(c.commandControls, command)
+		} else {
+			c.commandControls[command] = rest
+		}
+	}
+}
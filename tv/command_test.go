@@ -0,0 +1,85 @@
+package tv
+
+import (
+	"testing"
+
+	term "github.com/nsf/termbox-go"
+)
+
+func TestCommands(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(60, 20))
+	defer DeinitLibrary()
+
+	saved := 0
+	if err := comp.RegisterCommand("Save", "f2", func(ev Event) {
+		saved++
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := comp.RegisterCommand("Bad", "ctrl+f2+", nil); err == nil {
+		t.Errorf("Invalid keys must fail")
+	}
+
+	wnd := AddWindow(0, 1, 30, 10, "Main", false, false)
+	btn := CreateButton(wnd, AutoSize, AutoSize, "Save", Fixed, true, true)
+	btn.SetCommand("Save")
+	wnd.ResizeChildren()
+	wnd.PlaceChildren()
+	ActivateControl(wnd, btn)
+
+	item := NewMenu("").AddCommand("~S~ave", "Save")
+	if item.Shortcut() != "F2" {
+		t.Errorf("Item must show the default keys: %v", item.Shortcut())
+	}
+	sb := CreateStatusBar()
+	sb.SetItems([]StatusItem{{Title: "Save", Command: "Save"}})
+
+	ProcessEvent(keyEvent(term.KeyF2))
+	ProcessEvent(keyEvent(term.KeySpace))
+	item.trigger()
+	ProcessEvent(mouseAt(1, 19, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(1, 19, term.MouseRelease, 0))
+	if saved != 4 {
+		t.Errorf("Every control must run the command: %v", saved)
+	}
+
+	comp.EnableCommand("Save", false)
+	if btn.Enabled() || item.Enabled() || sb.Items()[0].enabled() || comp.CommandEnabled("Save") {
+		t.Errorf("Disabled command must disable its controls")
+	}
+	ProcessEvent(keyEvent(term.KeyF2))
+	ProcessEvent(mouseAt(1, 19, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(1, 19, term.MouseRelease, 0))
+	if saved != 4 {
+		t.Errorf("Disabled command must not run: %v", saved)
+	}
+
+	btn.SetEnabled(false)
+	comp.EnableCommand("Save", true)
+	if btn.Enabled() || !item.Enabled() {
+		t.Errorf("Enabled command must keep the disabled button")
+	}
+	btn.SetEnabled(true)
+	if !btn.Enabled() {
+		t.Errorf("Enabled command must enable its controls")
+	}
+	ProcessEvent(keyEvent(term.KeyF2))
+	if saved != 5 {
+		t.Errorf("Enabled command must run: %v", saved)
+	}
+
+	btn.SetCommand("")
+	comp.EnableCommand("Save", false)
+	if !btn.Enabled() {
+		t.Errorf("Unbound button must not follow the command")
+	}
+	btn.SetCommand("Save")
+	if btn.Enabled() {
+		t.Errorf("Bound button must get the command state")
+	}
+
+	comp.DestroyWindow(wnd)
+	if len(comp.commandControls) != 0 {
+		t.Errorf("Controls of the destroyed window must be unbound")
+	}
+}
@@ -0,0 +1,108 @@
+package tv
+
+import (
+	xs "github.com/huandu/xstrings"
+)
+
+/*
+Completion of TEditField text. The provider gets the text before the
+cursor and returns the matching values, e.g. host names or file paths:
+
+	edit.SetCompleter(func(prefix string) []string {
+		return lookupHosts(prefix)
+	}, true)
+
+The provider is called after every change a user makes. Ctrl+Space asks for
+completions explicitly, even if the field is empty. The matches are shown
+in a drop-down list under the field: Up, Down, PgUp and PgDn select a match,
+Enter, Tab or a click replace the text before the cursor with the selected
+match. Matches shown while typing are not selected until a user presses
+Down, so Enter and Tab are passed to the field.
+Esc closes the list, it is closed as well when the field loses focus.
+An async provider is called in a separate goroutine and its results are
+shown only if the text has not been changed since the call. Fields in
+password mode never show completions
+*/
+
+// SetCompleter sets the provider of completions. If async is true the
+// provider is called in a separate goroutine. nil removes the provider
+func (e *TEditField) SetCompleter(fn func(prefix string) []string, async bool) {
+	e.CloseCompletions()
+	e.completer = fn
+	e.asyncComplete = async
+	if fn != nil && e.completions == nil {
+		e.completions = newDropDown(e, e.acceptCompletion)
+	}
+}
+
+// Complete asks the provider for completions of the text before the
+// cursor and shows the matches
+func (e *TEditField) Complete() {
+	e.requestCompletions(true)
+}
+
+// CompletionsOpen returns true while the list of completions is shown
+func (e *TEditField) CompletionsOpen() bool {
+	return e.completions != nil && e.completions.isOpen()
+}
+
+// CloseCompletions hides the list of completions. Results of running
+// async requests are dropped
+func (e *TEditField) CloseCompletions() {
+	e.completeSeq++
+	if e.completions != nil {
+		e.completions.hide()
+	}
+}
+
+// requestCompletions calls the provider for the text before the cursor.
+// Empty text is completed only if explicit is true
+func (e *TEditField) requestCompletions(explicit bool) {
+	e.CloseCompletions()
+	if e.completer == nil || e.showStars || e.readonly || e.accepting {
+		return
+	}
+	prefix := xs.Slice(e.title, 0, int(e.cursorPos))
+	if prefix == "" && !explicit {
+		return
+	}
+
+	// matches shown while typing are not selected: Down selects the
+	// first one, Enter and Tab keep their usual meaning
+	selected := -1
+	if explicit {
+		selected = 0
+	}
+	seq, fn := e.completeSeq, e.completer
+	if !e.asyncComplete {
+		e.showCompletions(seq, prefix, fn(prefix), selected)
+		return
+	}
+	// the results go to the loop of the field application
+	l := loop
+	go func() {
+		items := fn(prefix)
+		l.post(func() {
+			e.showCompletions(seq, prefix, items, selected)
+		})
+	}()
+}
+
+// showCompletions shows the matches of the request seq with the item
+// selected highlighted. Matches of outdated requests are dropped
+func (e *TEditField) showCompletions(seq int, prefix string, items []string, selected int) {
+	if seq != e.completeSeq || !e.Active() || e.showStars {
+		return
+	}
+	if len(items) == 0 || (len(items) == 1 && items[0] == prefix) {
+		return
+	}
+	e.completions.show(items, selected)
+}
+
+// acceptCompletion replaces the text before the cursor with the match
+func (e *TEditField) acceptCompletion(item string) {
+	e.accepting = true
+	e.replace(0, int(e.cursorPos), item, false)
+	e.accepting = false
+}
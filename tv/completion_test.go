@@ -0,0 +1,198 @@
+package tv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	term "github.com/nsf/termbox-go"
+)
+
+var testHosts = []string{"alpha", "beta", "bravo", "build"}
+
+func completeHost(prefix string) []string {
+	var res []string
+	for _, h := range testHosts {
+		if strings.HasPrefix(h, prefix) {
+			res = append(res, h)
+		}
+	}
+	return res
+}
+
+func TestEditFieldCompletion(t *testing.T) {
+	scr := NewHeadlessScreen(40, 12)
+	InitLibrary(scr)
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 0, 30, 6, "Hosts", false, false)
+	wnd.SetPack(Vertical)
+	host := CreateEditField(wnd, 10, "", Fixed)
+	host.SetCompleter(completeHost, false)
+	other := CreateEditField(wnd, 10, "", Fixed)
+	wnd.ResizeChildren()
+	wnd.PlaceChildren()
+	ActivateControl(wnd, host)
+
+	typeText("b")
+	if !host.CompletionsOpen() || host.completions.ItemCount() != 3 {
+		t.Fatalf("Matches must be shown")
+	}
+	RefreshScreen()
+	if x, y := host.completions.Pos().Get(); y != 2 || x != 1 || !strings.HasPrefix(scr.Lines()[2], "║beta") {
+		t.Errorf("List must be under the field: %v:%v <%v>", x, y, scr.Lines()[2])
+	}
+
+	typeText("r")
+	if host.completions.ItemCount() != 1 {
+		t.Errorf("Matches must follow the text: %v", host.completions.ItemCount())
+	}
+	ProcessEvent(keyEvent(term.KeyBackspace2))
+	ProcessEvent(keyEvent(term.KeyArrowDown))
+	ProcessEvent(keyEvent(term.KeyArrowDown))
+	ProcessEvent(keyEvent(term.KeyEnter))
+	if host.Title() != "bravo" || host.CompletionsOpen() {
+		t.Errorf("Enter must accept the match: %q", host.Title())
+	}
+	host.Undo()
+	if host.Title() != "b" {
+		t.Errorf("Accepted match must be one change: %q", host.Title())
+	}
+
+	ProcessEvent(keyEvent(term.KeyCtrlR))
+	ProcessEvent(keyEvent(term.KeyCtrlSpace))
+	if host.completions.ItemCount() != 4 {
+		t.Errorf("Ctrl+Space must complete empty text")
+	}
+	ProcessEvent(keyEvent(term.KeyEsc))
+	if host.CompletionsOpen() || !host.Active() {
+		t.Errorf("Esc must close the list")
+	}
+
+	// click on the match
+	typeText("b")
+	ProcessEvent(mouseAt(2, 4, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(2, 4, term.MouseRelease, 0))
+	if host.Title() != "build" || !host.Active() {
+		t.Errorf("Click must accept the match: %q", host.Title())
+	}
+
+	typeText("x")
+	host.Home()
+	typeText("a")
+	if !host.CompletionsOpen() || host.completions.SelectedItem() != -1 {
+		t.Errorf("Matches shown while typing must not be selected")
+	}
+	ProcessEvent(keyEvent(term.KeyArrowDown))
+	ProcessEvent(keyEvent(term.KeyTab))
+	if host.Title() != "alphabuildx" {
+		t.Errorf("Tab must replace the text before the cursor: %q", host.Title())
+	}
+	typeText("b")
+	ProcessEvent(keyEvent(term.KeyTab))
+	if host.CompletionsOpen() || !other.Active() {
+		t.Errorf("List must be closed when focus leaves")
+	}
+
+	ActivateControl(wnd, host)
+	host.SetPasswordMode(true)
+	host.Complete()
+	typeText("b")
+	if host.CompletionsOpen() {
+		t.Errorf("Password field must not show completions")
+	}
+
+	host.SetPasswordMode(false)
+	host.Complete()
+	comp.DestroyWindow(wnd)
+	if len(comp.overlays) != 0 {
+		t.Errorf("List must be closed with the window")
+	}
+}
+
+func TestDropDownWithoutRoom(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(40, 1))
+	defer DeinitLibrary()
+
+	owner := CreateEditField(nil, 10, "", Fixed)
+	list := newDropDown(owner, nil)
+	list.show(testHosts, 0)
+	if list.isOpen() || len(comp.overlays) != 0 {
+		t.Errorf("List must not be shown without room for an item")
+	}
+	if list.processKey(keyEvent(term.KeyEnter)) {
+		t.Errorf("Closed list must not take keys")
+	}
+}
+
+func TestEditFieldAsyncCompletion(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(40, 12))
+	defer DeinitLibrary()
+
+	done := make(chan struct{})
+	go func() {
+		MainLoop()
+		close(done)
+	}()
+	defer func() {
+		Stop()
+		<-done
+	}()
+
+	var host *TEditField
+	requests := make(chan string, 10)
+	Invoke(func() {
+		wnd := AddWindow(0, 0, 30, 6, "Hosts", false, false)
+		host = CreateEditField(wnd, 10, "", Fixed)
+		host.SetCompleter(func(prefix string) []string {
+			requests <- prefix
+			return completeHost(prefix)
+		}, true)
+		ActivateControl(wnd, host)
+		typeText("b")
+	})
+
+	<-requests
+	deadline := time.Now().Add(5 * time.Second)
+	var open bool
+	for !open && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		Invoke(func() {
+			open = host.CompletionsOpen()
+		})
+	}
+	if !open {
+		t.Fatalf("Async matches must be shown")
+	}
+}
+
+func TestFileSelectDialogCompletion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fselect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"notes.txt", "news.md", "other.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "new"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	InitLibrary(NewHeadlessScreen(80, 25))
+	defer DeinitLibrary()
+
+	dlg := CreateFileSelectDialog("Open", "*.txt", dir, false, false)
+	want := []string{"new" + string(os.PathSeparator), "notes.txt"}
+	if got := dlg.completePath("n"); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Wrong completions: %v", got)
+	}
+	if got := dlg.completePath(filepath.Join(dir, "OT")); len(got) != 1 || got[0] != filepath.Join(dir, "other.txt") {
+		t.Errorf("Wrong absolute completions: %v", got)
+	}
+}
@@ -2,6 +2,7 @@ package tv
 
 import (
 	"sync"
+	"time"
 
 	term "github.com/nsf/termbox-go"
 
@@ -16,10 +17,22 @@ type Composer struct {
 	windows      []IControl
 	windowBorder BorderStyle
 	consumer     IControl
-	// last pressed key - to make repeatable actions simpler, e.g, at first
-	// one presses Ctrl+S and then just repeatedly presses arrow lest to
-	// resize Window
-	lastKey term.Key
+	// the main menu at the top of the screen, nil if the application
+	// does not have it
+	menuBar *MenuBar
+	// the status line at the bottom of the screen, nil if the
+	// application does not have it
+	statusBar *StatusBar
+	// transient views drawn above all windows, e.g. popup menus
+	overlays []IControl
+	// keymap translates key sequences to commands, commands holds the
+	// command handlers
+	keymap   *Keymap
+	commands map[string]func(Event)
+	// disabled commands and controls which enabled state follows
+	// their commands
+	disabledCommands map[string]bool
+	commandControls  map[string][]IControl
 	// coordinates when the mouse button was down, e.g to detect
 	// mouse click
 	mdownX types.ACoordX
@@ -29,6 +42,14 @@ type Composer struct {
 	lastY types.ACoordY
 	// Type of dragging
 	dragType DragType
+	// the mouse button that is pressed now (term.MouseRelease if none)
+	downButton term.Key
+	// time and coordinates of the last left click to detect double click
+	clickTime time.Time
+	clickX    types.ACoordX
+	clickY    types.ACoordY
+	// hover is true if EventMouseMove is sent to controls
+	hover bool
 	// For safe Window manipulations
 	mtx sync.RWMutex
 }
@@ -37,12 +58,18 @@ var (
 	comp *Composer
 )
 
-func initComposer() {
-	comp = new(Composer)
-	comp.windows = make([]IControl, 0)
-	comp.windowBorder = BorderAuto
-	comp.consumer = nil
-	comp.lastKey = term.KeyEsc
+func newComposer() *Composer {
+	c := new(Composer)
+	c.windows = make([]IControl, 0)
+	c.windowBorder = BorderAuto
+	c.consumer = nil
+	c.downButton = term.MouseRelease
+	c.keymap = DefaultKeymap()
+	c.commands = make(map[string]func(Event))
+	c.disabledCommands = make(map[string]bool)
+	c.commandControls = make(map[string][]IControl)
+	c.registerDefaultCommands()
+	return c
 }
 
 // WindowManager returns main Window manager (that is Composer). Use it at
@@ -67,37 +94,35 @@ func ReleaseEvents() {
 	comp.consumer = nil
 }
 
-func termboxEventToLocal(ev term.Event) Event {
-	e := Event{Type: EventType(ev.Type), Ch: ev.Ch,
-		Key: ev.Key, Err: ev.Err, X: types.ACoordX(ev.MouseX), Y: types.ACoordY(ev.MouseY),
-		Mod: ev.Mod, Width: ev.Width, Height: ev.Height}
-	return e
+// doubleClickTime is the longest time between two clicks of a double click
+const doubleClickTime = 400 * time.Millisecond
+
+// SetMouseHover turns on or off sending EventMouseMove when the mouse moves
+// without pressed buttons. It is off by default because the terminal
+// sends an event for every mouse move. The screen must support it, see
+// HoverScreen
+func SetMouseHover(on bool) {
+	comp.hover = on
+	if scr, ok := canvas.screen.(HoverScreen); ok {
+		scr.SetMouseHover(on)
+	}
 }
 
-// Repaints everything on the screen
-func RefreshScreen() {
-	comp.BeginUpdate()
-	_ = term.Clear(ColorWhite, ColorBlack)
-	comp.EndUpdate()
-
-	windows := comp.getWindowList()
-	for _, wnd := range windows {
-		v := wnd.(*TWindow)
-		if v.Visible() {
-			wnd.Draw()
-
-			WindowManager().BeginUpdate()
-			PushAttributes()
-			term.Flush()
-			PopAttributes()
-			WindowManager().EndUpdate()
+// MouseHover returns true if hover tracking is on
+func MouseHover() bool {
+	return comp.hover
+}
 
-		}
+// RefreshScreen repaints everything on the screen. Inside the main loop
+// the repaint is postponed till the end of the current frame, so any
+// number of calls made while an event is processed cost one repaint
+func RefreshScreen() {
+	if loop.inFrame {
+		loop.invalidateAll()
+		return
 	}
 
-	comp.BeginUpdate()
-	term.Flush()
-	comp.EndUpdate()
+	repaintAll()
 }
 
 // AddWindow constucts a new Window, adds it to the composer automatically,
@@ -116,8 +141,6 @@ func AddWindow(posX types.ACoordX, posY types.ACoordY,
 	comp.BeginUpdate()
 	comp.windows = append(comp.windows, window)
 	comp.EndUpdate()
-	window.Draw()
-	term.Flush()
 
 	comp.activateWindow(window)
 
@@ -126,6 +149,120 @@ func AddWindow(posX types.ACoordX, posY types.ACoordY,
 	return window
 }
 
+// SetMenuBar attaches the menu bar to the top line of the screen. nil
+// removes the current menu bar. Maximized windows are resized to fit
+// the rest of the screen
+func (c *Composer) SetMenuBar(mb *MenuBar) {
+	if c.menuBar != nil {
+		c.menuBar.Close()
+	}
+	c.menuBar = mb
+	if mb != nil {
+		w, _ := ScreenSize()
+		mb.SetPos(0, 0)
+		mb.SetSize(w, 1)
+	}
+	c.fitMaximized()
+	RefreshScreen()
+}
+
+// MenuBar returns the menu bar of the application or nil
+func (c *Composer) MenuBar() *MenuBar {
+	return c.menuBar
+}
+
+// SetStatusBar attaches the status bar to the bottom line of the screen.
+// nil removes the current status bar. Maximized windows are resized to
+// fit the rest of the screen
+func (c *Composer) SetStatusBar(sb *StatusBar) {
+	c.statusBar = sb
+	if sb != nil {
+		w, h := ScreenSize()
+		sb.SetPos(0, types.ACoordY(h-1))
+		sb.SetSize(w, 1)
+	}
+	c.fitMaximized()
+	RefreshScreen()
+}
+
+// StatusBar returns the status bar of the application or nil
+func (c *Composer) StatusBar() *StatusBar {
+	return c.statusBar
+}
+
+// showOverlay adds the transient view that is drawn above all windows
+// and the menu bar
+func (c *Composer) showOverlay(view IControl) {
+	c.hideOverlay(view)
+	c.overlays = append(c.overlays, view)
+	RefreshScreen()
+}
+
+// hideOverlay removes the transient view from the screen
+func (c *Composer) hideOverlay(view IControl) {
+	for i, v := range c.overlays {
+		if v == view {
+			c.overlays = append(c.overlays[:i], c.overlays[i+1:]...)
+			RefreshScreen()
+			return
+		}
+	}
+}
+
+// processOverlayMouse sends the mouse event to the topmost overlay under
+// the mouse. Returns true if the overlay processed the event
+func (c *Composer) processOverlayMouse(ev Event) bool {
+	for i := len(c.overlays) - 1; i >= 0; i-- {
+		view := c.overlays[i]
+		x, y := view.Pos().Get()
+		w, h := view.Size()
+		if ev.X >= x && ev.Y >= y && ev.X < x+types.ACoordX(w) && ev.Y < y+types.ACoordY(h) {
+			return view.ProcessEvent(ev)
+		}
+	}
+	return false
+}
+
+// hideOwnedOverlays removes overlays shown by the Window and its
+// controls, e.g. drop-down lists
+func (c *Composer) hideOwnedOverlays(wnd IControl) {
+	for i := len(c.overlays) - 1; i >= 0; i-- {
+		if o, ok := c.overlays[i].(interface{ overlayOwner() IControl }); ok && ownedBy(o.overlayOwner(), wnd) {
+			c.hideOverlay(c.overlays[i])
+		}
+	}
+}
+
+// Desktop returns the screen area for windows: the whole screen except
+// the menu bar and the status bar lines
+func (c *Composer) Desktop() (x types.ACoordX, y types.ACoordY, w, h int) {
+	w, h = ScreenSize()
+	if c.menuBar != nil && c.menuBar.Visible() {
+		y++
+		h--
+	}
+	if c.statusBar != nil && c.statusBar.Visible() {
+		h--
+	}
+	return x, y, w, h
+}
+
+// fitMaximized resizes maximized windows to the desktop after the screen
+// or the desktop changes
+func (c *Composer) fitMaximized() {
+	x, y, w, h := c.Desktop()
+	for _, ctrl := range c.getWindowList() {
+		wnd, ok := ctrl.(*TWindow)
+		if !ok || !wnd.Maximized() {
+			continue
+		}
+		wnd.SetPos(x, y)
+		wnd.SetSize(w, h)
+		wnd.ResizeChildren()
+		wnd.PlaceChildren()
+	}
+}
+
 // Border returns the default window border
 func (c *Composer) BorderStyle() BorderStyle {
 	return c.windowBorder
@@ -329,9 +466,10 @@ func (c *Composer) moveTopWindow(ev Event) bool {
 		x, y := view.Pos().Get()
 		w, h := view.Size()
 		x1, y1 := x, y
-		cx, cy := term.Size()
+		_, top, cx, dh := c.Desktop()
+		cy := int(top) + dh
 		switch {
-		case ev.Key == term.KeyArrowUp && y > 0:
+		case ev.Key == term.KeyArrowUp && y > top:
 			y--
 		case ev.Key == term.KeyArrowDown && int(y)+h < cy:
 			y++
@@ -368,12 +506,12 @@ func (c *Composer) closeTopWindow() {
 
 		RefreshScreen()
 	} else {
-		go Stop()
+		quit()
 	}
 }
 
 func (c *Composer) processWindowDrag(ev Event) {
-	if ev.Mod != term.ModMotion || c.dragType == DragNone {
+	if ev.Mod&term.ModMotion == 0 || c.dragType == DragNone {
 		return
 	}
 	dx := ev.X - c.lastX
@@ -385,13 +523,14 @@ func (c *Composer) processWindowDrag(ev Event) {
 	w := c.topWindow()
 	newX, newY := w.Pos().Get()
 	newW, newH := w.Size()
-	cw, ch := ScreenSize()
+	_, top, cw, dh := c.Desktop()
+	ch := int(top) + dh
 
 	switch c.dragType {
 	case DragMove:
 		newX += dx
 		newY += dy
-		if newX >= 0 && newY >= 0 && newX+types.ACoordX(newW) < types.ACoordX(cw) && int(newY)+newH < ch {
+		if newX >= 0 && newY >= top && newX+types.ACoordX(newW) < types.ACoordX(cw) && int(newY)+newH < ch {
 			c.lastX = ev.X
 			c.lastY = ev.Y
 
@@ -403,7 +542,7 @@ func (c *Composer) processWindowDrag(ev Event) {
 	case DragResizeLeft:
 		newX += dx
 		newW -= int(dx)
-		if newX >= 0 && newY >= 0 && newX+types.ACoordX(newW) < types.ACoordX(cw) && int(newY)+newH < ch {
+		if newX >= 0 && newY >= top && newX+types.ACoordX(newW) < types.ACoordX(cw) && int(newY)+newH < ch {
 			c.lastX = ev.X
 			c.lastY = ev.Y
 
@@ -417,7 +556,7 @@ func (c *Composer) processWindowDrag(ev Event) {
 		}
 	case DragResizeRight:
 		newW += int(dx)
-		if newX >= 0 && newY >= 0 && int(newX)+newW < cw && int(newY)+newH < ch {
+		if newX >= 0 && newY >= top && int(newX)+newW < cw && int(newY)+newH < ch {
 			c.lastX = ev.X
 			c.lastY = ev.Y
 
@@ -428,7 +567,7 @@ func (c *Composer) processWindowDrag(ev Event) {
 		}
 	case DragResizeBottom:
 		newH += int(dy)
-		if newX >= 0 && newY >= 0 && int(newX)+newW < cw && int(newY)+newH < ch {
+		if newX >= 0 && newY >= top && int(newX)+newW < cw && int(newY)+newH < ch {
 			c.lastX = ev.X
 			c.lastY = ev.Y
 
@@ -442,7 +581,7 @@ func (c *Composer) processWindowDrag(ev Event) {
 		newW -= int(dx)
 		newY += dy
 		newH -= int(dy)
-		if newX >= 0 && newY >= 0 && int(newX)+newW < cw && int(newY)+newH < ch {
+		if newX >= 0 && newY >= top && int(newX)+newW < cw && int(newY)+newH < ch {
 			c.lastX = ev.X
 			c.lastY = ev.Y
 
@@ -458,7 +597,7 @@ func (c *Composer) processWindowDrag(ev Event) {
 		newX += dx
 		newW -= int(dx)
 		newH += int(dy)
-		if newX >= 0 && newY >= 0 && int(newX)+newW < cw && int(newY)+newH < ch {
+		if newX >= 0 && newY >= top && int(newX)+newW < cw && int(newY)+newH < ch {
 			c.lastX = ev.X
 			c.lastY = ev.Y
 
@@ -473,7 +612,7 @@ func (c *Composer) processWindowDrag(ev Event) {
 	case DragResizeBottomRight:
 		newW += int(dx)
 		newH += int(dy)
-		if newX >= 0 && newY >= 0 && int(newX)+newW < cw && int(newY)+newH < ch {
+		if newX >= 0 && newY >= top && int(newX)+newW < cw && int(newY)+newH < ch {
 			c.lastX = ev.X
 			c.lastY = ev.Y
 
@@ -486,7 +625,7 @@ func (c *Composer) processWindowDrag(ev Event) {
 		newY += dy
 		newW += int(dx)
 		newH -= int(dy)
-		if newX >= 0 && newY >= 0 && int(newX)+newW < cw && int(newY)+newH < ch {
+		if newX >= 0 && newY >= top && int(newX)+newW < cw && int(newY)+newH < ch {
 			c.lastX = ev.X
 			c.lastY = ev.Y
 
@@ -501,12 +640,78 @@ func (c *Composer) processWindowDrag(ev Event) {
 	}
 }
 
+// processWheel sends the wheel event to the window under the mouse. The
+// window is not activated
+func (c *Composer) processWheel(ev Event) {
+	view, hit := c.checkWindowUnderMouse(ev.X, ev.Y)
+	if view == nil || hit != HitInside || (view != c.topWindow() && c.topWindow().Modal()) {
+		return
+	}
+
+	ev.Type = EventMouseWheel
+	view.ProcessEvent(ev)
+	c.repaintHandler(view)
+}
+
+// processHover sends EventMouseMove to the window under the mouse
+func (c *Composer) processHover(ev Event) {
+	if !c.hover {
+		return
+	}
+
+	view, hit := c.checkWindowUnderMouse(ev.X, ev.Y)
+	if view == nil || hit != HitInside {
+		return
+	}
+
+	ev.Type = EventMouseMove
+	ev.Key = 0
+	ev.Mod &^= term.ModMotion
+	view.ProcessEvent(ev)
+	c.repaintHandler(view)
+}
+
+// isDoubleClick returns true if the left button press makes a double
+// click with the previous one
+func (c *Composer) isDoubleClick(ev Event) bool {
+	now := time.Now()
+	if !c.clickTime.IsZero() && now.Sub(c.clickTime) <= doubleClickTime &&
+		c.clickX == ev.X && c.clickY == ev.Y {
+		c.clickTime = time.Time{}
+		return true
+	}
+
+	c.clickTime, c.clickX, c.clickY = now, ev.X, ev.Y
+	return false
+}
+
 func (c *Composer) processMouse(ev Event) {
 	if c.consumer != nil {
 		tmp := c.consumer
 		tmp.ProcessEvent(ev)
-		tmp.Draw()
-		term.Flush()
+		RefreshScreen()
+		return
+	}
+
+	if c.menuBar != nil && c.menuBar.ProcessEvent(ev) {
+		RefreshScreen()
+		return
+	}
+	if c.processOverlayMouse(ev) {
+		RefreshScreen()
+		return
+	}
+	if c.statusBar != nil && c.dragType == DragNone && c.statusBar.ProcessEvent(ev) {
+		RefreshScreen()
+		return
+	}
+
+	if ev.Key == term.MouseWheelUp || ev.Key == term.MouseWheelDown {
+		c.processWheel(ev)
+		return
+	}
+	if ev.Mod&term.ModMotion != 0 && ev.Key == term.MouseRelease {
+		c.processHover(ev)
 		return
 	}
 
@@ -521,7 +726,7 @@ func (c *Composer) processMouse(ev Event) {
 			return
 		}
 
-		if ev.Mod == term.ModMotion && c.dragType != DragNone {
+		if ev.Mod&term.ModMotion != 0 && c.dragType != DragNone {
 			c.processWindowDrag(ev)
 			return
 		}
@@ -542,6 +747,7 @@ func (c *Composer) processMouse(ev Event) {
 				v := c.topWindow().(*TWindow)
 				maximized := v.Maximized()
 				v.SetMaximized(!maximized)
+				RefreshScreen()
 			case HitTop:
 				c.dragType = DragMove
 			case HitBottom:
@@ -564,39 +770,78 @@ func (c *Composer) processMouse(ev Event) {
 		}
 	} else if !c.topWindow().Modal() {
 		c.activateWindow(view)
+		RefreshScreen()
 		return
 	}
+	defer c.repaintHandler(c.topWindow())
 	switch {
-	case ev.Key == term.MouseLeft:
+	case ev.Mod&term.ModMotion != 0:
+		c.sendEventToActiveWindow(ev)
+		if c.downButton != term.MouseRelease {
+			ev.Type = EventMouseDrag
+			ev.Key = c.downButton
+			c.sendEventToActiveWindow(ev)
+		}
+	case ev.Key == term.MouseLeft || ev.Key == term.MouseRight || ev.Key == term.MouseMiddle:
 		c.lastX = ev.X
 		c.lastY = ev.Y
 		c.mdownX = ev.X
 		c.mdownY = ev.Y
+		c.downButton = ev.Key
 		c.sendEventToActiveWindow(ev)
+		if ev.Key == term.MouseLeft && c.isDoubleClick(ev) {
+			ev.Type = EventDoubleClick
+			c.sendEventToActiveWindow(ev)
+		}
 	case ev.Key == term.MouseRelease:
+		button := c.downButton
+		c.downButton = term.MouseRelease
 		c.sendEventToActiveWindow(ev)
-		if c.lastX != ev.X && c.lastY != ev.Y {
+		if button == term.MouseRelease {
 			return
 		}
 
-		ev.Type = EventClick
+		ev.Type = EventMouseUp
+		ev.Key = button
 		c.sendEventToActiveWindow(ev)
+		if c.mdownX != ev.X || c.mdownY != ev.Y {
+			return
+		}
+
+		switch button {
+		case term.MouseLeft:
+			ev.Type = EventClick
+			c.sendEventToActiveWindow(ev)
+		case term.MouseRight:
+			ev.Type = EventRightClick
+			c.sendEventToActiveWindow(ev)
+		}
 	default:
 		c.sendEventToActiveWindow(ev)
 	}
 }
 
 // Stop sends termination event to Composer. Composer should stop
-// console management and quit application
+// console management and quit application. Outside event handlers Stop
+// works with the default application, use Application.Stop for other ones
 func Stop() {
 	ev := Event{Type: EventQuit}
-	go PutEvent(ev)
+	PutEvent(ev)
 }
 
-// DestroyWindow removes the Window from the list of managed Windows
+// DestroyWindow removes the Window from the list of managed Windows,
+// stops all timers bound to the Window and removes its key bindings,
+// command bindings and status bar items
 func (c *Composer) DestroyWindow(view IControl) {
 	ev := Event{Type: EventClose}
 	c.sendEventToActiveWindow(ev)
+	loop.stopTimers(view)
+	c.keymap.removeScope(view)
+	c.removeCommandScope(view)
+	c.hideOwnedOverlays(view)
+	if c.statusBar != nil {
+		c.statusBar.removeScope(view)
+	}
 
 	windows := c.getWindowList()
 	var newOrder []IControl
@@ -607,7 +852,7 @@ func (c *Composer) DestroyWindow(view IControl) {
 	}
 
 	if len(newOrder) == 0 {
-		go Stop()
+		quit()
 		return
 	}
 
@@ -615,94 +860,166 @@ func (c *Composer) DestroyWindow(view IControl) {
 	c.windows = newOrder
 	c.EndUpdate()
 	c.activateWindow(c.topWindow())
+	RefreshScreen()
 }
 
 // IsDeadKey returns true if the pressed key is the first key in
 // the key sequence understood by composer. Dead key is never sent to
 // any control
 func IsDeadKey(key term.Key) bool {
-	if key == term.KeyCtrlS || key == term.KeyCtrlP ||
-		key == term.KeyCtrlW || key == term.KeyCtrlQ {
-		return true
+	return comp.keymap.IsPrefix(key)
+}
+
+// Keymap returns the keymap that translates key sequences to commands
+func (c *Composer) Keymap() *Keymap {
+	return c.keymap
+}
+
+// SetKeymap replaces the composer keymap, e.g. with a keymap loaded from
+// a configuration file
+func (c *Composer) SetKeymap(km *Keymap) {
+	if km == nil {
+		km = NewKeymap()
 	}
+	c.keymap = km
+}
 
-	return false
+// HandleCommand sets the function that is called when a user types a key
+// sequence bound to the command. The event passed to the function is the
+// last key of the sequence with Target set to the binding scope (nil for
+// global bindings). nil fn removes the handler. Handlers of built-in
+// commands can be replaced as well
+func (c *Composer) HandleCommand(command string, fn func(Event)) {
+	if fn == nil {
+		delete(c.commands, command)
+		return
+	}
+	c.commands[command] = fn
 }
 
-func (c *Composer) processKey(ev Event) {
-	if ev.Key == term.KeyEsc {
-		if IsDeadKey(c.lastKey) {
-			c.lastKey = term.KeyEsc
-			return
+func (c *Composer) registerDefaultCommands() {
+	c.commands[CmdQuit] = func(ev Event) {
+		quit()
+	}
+	c.commands[CmdWindowToBottom] = func(ev Event) {
+		c.moveActiveWindowToBottom()
+	}
+	c.commands[CmdWindowMaximize] = func(ev Event) {
+		w, ok := c.topWindow().(*TWindow)
+		if ok && w.Sizable() && (w.TitleButtons()&ButtonMaximize == ButtonMaximize) {
+			maxxed := w.Maximized()
+			w.SetMaximized(!maxxed)
+			RefreshScreen()
+		}
+	}
+	c.commands[CmdWindowClose] = func(ev Event) {
+		c.closeTopWindow()
+	}
+	c.commands[CmdMenu] = func(ev Event) {
+		switch {
+		case c.menuBar == nil || !c.menuBar.Visible():
+		case c.menuBar.IsOpen():
+			c.menuBar.Close()
+		default:
+			c.menuBar.Open(0)
 		}
 	}
 
-	if IsDeadKey(ev.Key) && !IsDeadKey(c.lastKey) {
-		c.lastKey = ev.Key
-		return
+	arrows := []struct {
+		move, size string
+		key        term.Key
+	}{
+		{CmdWindowMoveUp, CmdWindowSizeUp, term.KeyArrowUp},
+		{CmdWindowMoveDown, CmdWindowSizeDown, term.KeyArrowDown},
+		{CmdWindowMoveLeft, CmdWindowSizeLeft, term.KeyArrowLeft},
+		{CmdWindowMoveRight, CmdWindowSizeRight, term.KeyArrowRight},
 	}
+	for _, a := range arrows {
+		event := Event{Type: EventKey, Key: a.key}
+		c.commands[a.move] = func(ev Event) {
+			c.moveTopWindow(event)
+		}
+		c.commands[a.size] = func(ev Event) {
+			c.resizeTopWindow(event)
+		}
+	}
+}
 
-	if !IsDeadKey(ev.Key) {
+// keyScopes returns the active control chain of the top window: from the
+// deepest active control to the window itself
+func (c *Composer) keyScopes() []IControl {
+	wnd := c.topWindow()
+	if wnd == nil {
+		return nil
+	}
+
+	var scopes []IControl
+	for ctrl := ActiveControl(wnd); ctrl != nil && ctrl != wnd; ctrl = ctrl.Parent() {
+		scopes = append(scopes, ctrl)
+	}
+	return append(scopes, wnd)
+}
+
+func (c *Composer) processKey(ev Event) {
+	binding, keys := c.keymap.feed(ev, c.keyScopes())
+
+	for _, key := range keys {
+		if c.consumer == nil && c.menuBar != nil && c.menuBar.processHotkey(key) {
+			continue
+		}
 		if c.consumer != nil {
 			tmp := c.consumer
-			tmp.ProcessEvent(ev)
-			tmp.Draw()
-			term.Flush()
+			tmp.ProcessEvent(key)
 		} else {
-			c.sendEventToActiveWindow(ev)
-			c.topWindow().Draw()
-			term.Flush()
+			c.sendEventToActiveWindow(key)
 		}
 	}
+	if len(keys) > 0 {
+		c.repaintHandler(c.topWindow())
+	}
 
-	newKey := term.KeyEsc
-	switch c.lastKey {
-	case term.KeyCtrlQ:
-		switch ev.Key {
-		case term.KeyCtrlQ:
-			Stop()
-		default:
-			newKey = ev.Key
-		}
-	case term.KeyCtrlS:
-		switch ev.Key {
-		case term.KeyArrowUp, term.KeyArrowDown, term.KeyArrowLeft, term.KeyArrowRight:
-			c.resizeTopWindow(ev)
-		default:
-			newKey = ev.Key
-		}
-	case term.KeyCtrlP:
-		switch ev.Key {
-		case term.KeyArrowUp, term.KeyArrowDown, term.KeyArrowLeft, term.KeyArrowRight:
-			c.moveTopWindow(ev)
-		default:
-			newKey = ev.Key
-		}
-	case term.KeyCtrlW:
-		switch ev.Key {
-		case term.KeyCtrlH:
-			c.moveActiveWindowToBottom()
-		case term.KeyCtrlM:
-			w := c.topWindow().(*TWindow)
-			if w.Sizable() && (w.TitleButtons()&ButtonMaximize == ButtonMaximize) {
-				maxxed := w.Maximized()
-				w.SetMaximized(!maxxed)
-				RefreshScreen()
-			}
-		case term.KeyCtrlC:
-			c.closeTopWindow()
-		default:
-			newKey = ev.Key
-		}
+	if binding != nil {
+		event := ev
+		event.Target = binding.Scope
+		c.runCommand(binding.Command, event)
 	}
+}
 
-	if newKey != term.KeyEsc {
-		event := Event{Key: c.lastKey, Type: EventKey}
-		c.sendEventToActiveWindow(event)
-		event.Key = newKey
-		c.sendEventToActiveWindow(event)
-		c.lastKey = term.KeyEsc
+// runCommand calls the handler of the command. Returns false if the
+// command does not have a handler or it is disabled
+func (c *Composer) runCommand(command string, ev Event) bool {
+	fn, ok := c.commands[command]
+	if !ok || !c.CommandEnabled(command) {
+		return false
+	}
+	fn(ev)
+	return true
+}
+
+// processPaste sends pasted text to the active control. A paste breaks
+// an unfinished key chord
+func (c *Composer) processPaste(ev Event) {
+	c.keymap.reset()
+
+	if c.consumer != nil {
+		tmp := c.consumer
+		tmp.ProcessEvent(ev)
+	} else {
+		c.sendEventToActiveWindow(ev)
 	}
+	c.repaintHandler(c.topWindow())
+}
+
+// repaintHandler damages the window that has processed user input. The
+// control that has captured the input can draw anywhere, so the whole
+// screen is damaged while there is one. Outside the main loop the screen
+// is repainted at once
+func (c *Composer) repaintHandler(wnd IControl) {
+	if !loop.inFrame || c.consumer != nil || wnd == nil {
+		RefreshScreen()
+		return
+	}
+	loop.invalidate(wnd, nil)
 }
 
 func ProcessEvent(ev Event) {
@@ -713,15 +1030,17 @@ func ProcessEvent(ev Event) {
 		RefreshScreen()
 	case EventResize:
 		SetScreenSize(ev.Width, ev.Height)
+		if comp.menuBar != nil {
+			comp.menuBar.SetSize(ev.Width, 1)
+		}
+		if comp.statusBar != nil {
+			comp.statusBar.SetPos(0, types.ACoordY(ev.Height-1))
+			comp.statusBar.SetSize(ev.Width, 1)
+		}
+		comp.fitMaximized()
+		RefreshScreen()
 		for _, c := range comp.windows {
 			wnd := c.(*TWindow)
-			if wnd.Maximized() {
-				wnd.SetSize(ev.Width, ev.Height)
-				wnd.ResizeChildren()
-				wnd.PlaceChildren()
-				RefreshScreen()
-			}
-
 			if wnd.onScreenResize != nil {
 				wnd.onScreenResize(ev)
 			}
@@ -729,6 +1048,8 @@ func ProcessEvent(ev Event) {
 		}
 	case EventKey:
 		comp.processKey(ev)
+	case EventPaste:
+		comp.processPaste(ev)
 	case EventMouse:
 		comp.processMouse(ev)
 	case EventLayout:
@@ -736,6 +1057,7 @@ func ProcessEvent(ev Event) {
 			if c == ev.Target {
 				c.ResizeChildren()
 				c.PlaceChildren()
+				Invalidate(c)
 				break
 			}
 		}
@@ -64,13 +64,27 @@ const (
 	DragResizeTopRight
 )
 
+// Key modifiers of Event. ModAlt is the termbox one, the others use free
+// bits of term.Modifier
+const (
+	ModAlt                 = term.ModAlt
+	ModShift term.Modifier = 1 << 2
+	ModCtrl  term.Modifier = 1 << 3
+
+	// modKeys is all keyboard modifiers
+	modKeys = ModAlt | ModShift | ModCtrl
+)
+
 // Event is structure used by Views and controls to communicate with Composer
 // and vice versa
 type Event struct {
 	// Event type - the first events are mapped to termbox Event and then a few
 	// own events added to the end
 	Type EventType
-	// Mod - is a key modifier. Only Alt modifier is supported
+	// Mod - is a set of key modifiers: ModAlt, ModCtrl and ModShift.
+	// Ctrl+letter keys are reported as control keys (KeyCtrlA etc) without
+	// ModCtrl, use Ctrl method to check for Ctrl in any key. Mouse events
+	// have term.ModMotion when the mouse moves with a button pressed
 	Mod term.Modifier
 	// Msg is a text part of the event. Used by few events: e.g, ListBox click
 	// sends a value of clicked item
@@ -180,6 +194,7 @@ const (
 	ObjSparkChart   = "SparkChart"
 	ObjTableView    = "TableView"
 	ObjButton       = "Button"
+	ObjMenu         = "Menu"
 )
 
 // Available color identifiers that can be used in themes
@@ -193,14 +208,17 @@ const (
 	ColorText         = "Text"
 	ColorDisabledText = "GrayText"
 	ColorDisabledBack = "GrayBack"
+	ColorHotkeyText   = "HotkeyText"
 
 	// editable & listbox-like controls
-	ColorEditBack       = "EditBack"
-	ColorEditText       = "EditText"
-	ColorEditActiveBack = "EditActiveBack"
-	ColorEditActiveText = "EditActiveText"
-	ColorSelectionText  = "SelectionText"
-	ColorSelectionBack  = "SelectionBack"
+	ColorEditBack        = "EditBack"
+	ColorEditText        = "EditText"
+	ColorEditActiveBack  = "EditActiveBack"
+	ColorEditActiveText  = "EditActiveText"
+	ColorEditInvalidBack = "EditInvalidBack"
+	ColorEditInvalidText = "EditInvalidText"
+	ColorSelectionText   = "SelectionText"
+	ColorSelectionBack   = "SelectionBack"
 
 	// button control
 	ColorButtonBack         = "ButtonBack"
@@ -210,6 +228,7 @@ const (
 	ColorButtonShadow       = "ButtonShadowBack"
 	ColorButtonDisabledBack = "ButtonDisabledBack"
 	ColorButtonDisabledText = "ButtonDisabledText"
+	ColorButtonHotkeyText   = "ButtonHotkeyText"
 
 	// scroll control
 	ColorScrollText = "ScrollText"
@@ -225,6 +244,7 @@ const (
 	ColorControlDisabledBack = "ControlDisabledBack"
 	ColorControlDisabledText = "ControlDisabledText"
 	ColorControlShadow       = "ControlShadowBack"
+	ColorControlHotkeyText   = "ControlHotkeyText"
 
 	// progressbar colors
 	ColorProgressBack       = "ProgressBack"
@@ -255,6 +275,21 @@ const (
 	ColorTableLineText       = "TableLineText"
 	ColorTableHeaderText     = "TableHeaderText"
 	ColorTableHeaderBack     = "TableHeaderBack"
+
+	// menu bar and drop-down menus
+	ColorMenuBack             = "MenuBack"
+	ColorMenuText             = "MenuText"
+	ColorMenuActiveBack       = "MenuActiveBack"
+	ColorMenuActiveText       = "MenuActiveText"
+	ColorMenuDisabledText     = "MenuDisabledText"
+	ColorMenuHotkeyText       = "MenuHotkeyText"
+	ColorMenuActiveHotkeyText = "MenuActiveHotkeyText"
+
+	// status bar
+	ColorStatusBack         = "StatusBack"
+	ColorStatusText         = "StatusText"
+	ColorStatusHotkeyText   = "StatusHotkeyText"
+	ColorStatusDisabledText = "StatusDisabledText"
 )
 
 // EventType is event that window or control may process
@@ -300,6 +335,34 @@ const (
 	// A scroll-able control's child has been activated, then notify its parent to handle
 	// the scrolling
 	EventActivateChild
+	// Run the next function queued with Post or Invoke. The event is
+	// handled by the main loop and is never sent to windows and controls
+	EventCall
+	// A user pasted text into the terminal (bracketed paste). Msg is the
+	// whole pasted text with "\n" line ends. The event is sent to the
+	// active control like a key press
+	EventPaste
+
+	/*
+	   mouse events made by Composer from raw EventMouse events. X and Y
+	   are screen coordinates of the mouse, Key is the mouse button
+	*/
+	// A mouse button is released. Key is the button that was pressed
+	EventMouseUp
+	// Mouse wheel is rotated over a control. Key is term.MouseWheelUp or
+	// term.MouseWheelDown. The event goes to the control under the mouse
+	// and then to its parents until one of them processes the event
+	EventMouseWheel
+	// The left button is clicked twice quickly at the same place
+	EventDoubleClick
+	// The right button is pressed and released at the same place
+	EventRightClick
+	// The mouse moves with a button pressed. The event goes to the active
+	// control: the one the button was pressed on
+	EventMouseDrag
+	// The mouse moves without buttons pressed. The event is sent only if
+	// hover tracking is on, see SetMouseHover
+	EventMouseMove
 )
 
 // ConfirmationDialog and SelectDialog exit codes
@@ -343,6 +406,8 @@ const (
 	TableActionDelete
 	// A user clicked on a column header in TableView
 	TableActionSort
+	// A user right clicked a cell or pressed Shift+F10 in TableView
+	TableActionMenu
 )
 
 // SortOrder constants
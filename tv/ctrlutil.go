@@ -6,6 +6,10 @@ import (
 	"github.com/prospero78/goTV/tv/types"
 )
 
+// mouseWheelLines is the number of lines a text control scrolls by one
+// step of mouse wheel
+const mouseWheelLines = 3
+
 // ThumbPosition returns a scrollbar thumb position depending
 // on currently active item(itemNo), total number of items
 // (itemCount), and length/height of the scrollbar(length)
@@ -270,18 +274,32 @@ func NextControl(parent IControl, curr IControl, next bool) IControl {
 }
 
 // SendEventToChild tries to find a child control that should recieve the evetn
-// For mouse click events it looks for a control at coordinates of event,
-// makes it active, and then sends the event to it.
+// For mouse click events (including right and double clicks) it looks for
+// a control at coordinates of event, makes it active, and then sends the
+// event to it. Mouse wheel and hover events go to the control at
+// coordinates of event without activating it. A wheel event that the
+// control does not process goes to its parents.
 // If it is not mouse click event then it looks for the first active child and
 // sends the event to it if it is not nil
 func SendEventToChild(parent IControl, ev Event) bool {
 	var child IControl
-	if IsMouseClickEvent(ev) {
+	switch {
+	case IsMouseClickEvent(ev) || ev.Type == EventRightClick || ev.Type == EventDoubleClick:
 		child = ChildAt(parent, ev.X, ev.Y)
 		if child != nil && !child.Active() {
 			ActivateControl(parent, child)
 		}
-	} else {
+	case ev.Type == EventMouseWheel:
+		for ctrl := ChildAt(parent, ev.X, ev.Y); ctrl != nil && ctrl != parent; ctrl = ctrl.Parent() {
+			ev.Target = ctrl
+			if ctrl.ProcessEvent(ev) {
+				return true
+			}
+		}
+		return false
+	case ev.Type == EventMouseMove:
+		child = ChildAt(parent, ev.X, ev.Y)
+	default:
 		child = ActiveControl(parent)
 	}
 
@@ -60,7 +60,7 @@ func CreateConfirmationDialog(title, question string, buttons []string, defaultB
 		buttons = []string{"OK"}
 	}
 
-	cw, ch := term.Size()
+	cw, ch := ScreenSize()
 
 	dlg.View = AddWindow(types.ACoordX(cw/2-12), types.ACoordY(ch/2-8), 30, 3, title, true, true)
 	WindowManager().BeginUpdate()
@@ -182,7 +182,7 @@ func CreateSelectDialog(title string, items []string, selectedItem int, typ Sele
 		return nil
 	}
 
-	cw, ch := term.Size()
+	cw, ch := ScreenSize()
 
 	dlg.typ = typ
 	dlg.View = AddWindow(types.ACoordX(cw/2-12), types.ACoordY(ch/2-8), 20, 10, title, false, false)
@@ -215,6 +215,9 @@ func CreateSelectDialog(title string, items []string, selectedItem int, typ Sele
 		dlg.edit.OnKeyPress(func(key term.Key, r rune) bool {
 			var input string
 			if key == term.KeyEnter {
+				if !ValidateControls(dlg.View) {
+					return true
+				}
 				input = dlg.edit.Title()
 				dlg.edtResult = input
 				dlg.value = -1
@@ -247,6 +250,9 @@ func CreateSelectDialog(title string, items []string, selectedItem int, typ Sele
 	CreateFrame(frm1, 1, 1, BorderNone, 1)
 	btn1 := CreateButton(frm1, 0, 0, "OK", Fixed, true, true)
 	btn1.OnClick(func(ev Event) {
+		if !ValidateControls(dlg.View) {
+			return
+		}
 		dlg.result = DialogButton1
 		switch {
 		case dlg.typ == SelectDialogList:
@@ -299,6 +305,13 @@ func CreateSelectDialog(title string, items []string, selectedItem int, typ Sele
 	return dlg
 }
 
+// EditField returns the edit field of the dialog created with
+// CreateEditDialog, e.g. to set a validator. The field is validated
+// when a user clicks "OK" or presses Enter. Other dialogs return nil
+func (d *SelectDialog) EditField() *TEditField {
+	return d.edit
+}
+
 // OnClose sets the callback that is called when the
 // dialog is closed
 func (d *SelectDialog) OnClose(fn func()) {
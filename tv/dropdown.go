@@ -0,0 +1,167 @@
+package tv
+
+import (
+	xs "github.com/huandu/xstrings"
+	term "github.com/nsf/termbox-go"
+
+	"github.com/prospero78/goTV/tv/types"
+)
+
+// defaultDropDownHeight is the default maximum number of visible items of
+// a drop-down list
+const defaultDropDownHeight = 8
+
+// dropDown is a list shown under its owner control above all windows,
+// e.g. completions of TEditField. The list does not take focus: the owner
+// passes its keys to processKey, mouse events come from the composer.
+// onAccept is called when a user accepts an item with Enter, Tab or a click
+type dropDown struct {
+	*ListBox
+	owner     IControl
+	maxHeight int
+	onAccept  func(item string)
+}
+
+func newDropDown(owner IControl, onAccept func(string)) *dropDown {
+	d := &dropDown{
+		ListBox:   CreateListBox(nil, 1, 1, Fixed),
+		owner:     owner,
+		maxHeight: defaultDropDownHeight,
+		onAccept:  onAccept,
+	}
+	d.SetActive(true)
+	return d
+}
+
+// show shows the items under the owner, or above it if there is no room
+// below. selected is the highlighted item, -1 highlights nothing. The list
+// is not shown if there is no room for a single item
+func (d *dropDown) show(items []string, selected int) {
+	width, oh := d.owner.Size()
+	x, y := d.owner.Pos().Get()
+	sw, sh := ScreenSize()
+	h := len(items)
+	if h > d.maxHeight {
+		h = d.maxHeight
+	}
+	below, above := sh-int(y)-oh, int(y)
+	if h > below && above > below {
+		if h > above {
+			h = above
+		}
+		y -= types.ACoordY(h)
+	} else {
+		if h > below {
+			h = below
+		}
+		y += types.ACoordY(oh)
+	}
+	if h < 1 {
+		d.hide()
+		return
+	}
+
+	d.Clear()
+	for _, item := range items {
+		d.AddItem(item)
+		if w := xs.Len(item) + 1; w > width {
+			width = w
+		}
+	}
+	if int(x)+width > sw {
+		x = types.ACoordX(sw - width)
+	}
+	if x < 0 {
+		x = 0
+	}
+
+	d.SetPos(x, y)
+	d.SetSize(width, h)
+	d.SelectItem(selected)
+	comp.showOverlay(d)
+}
+
+// hide removes the list from the screen
+func (d *dropDown) hide() {
+	comp.hideOverlay(d)
+}
+
+// isOpen returns true while the list is shown
+func (d *dropDown) isOpen() bool {
+	for _, view := range comp.overlays {
+		if view == d {
+			return true
+		}
+	}
+	return false
+}
+
+// overlayOwner returns the control that shows the list
+func (d *dropDown) overlayOwner() IControl {
+	return d.owner
+}
+
+// accept hides the list and calls onAccept with the selected item.
+// Returns false if no item is selected
+func (d *dropDown) accept() bool {
+	item, ok := d.Item(d.SelectedItem())
+	d.hide()
+	if ok && d.onAccept != nil {
+		d.onAccept(item)
+	}
+	return ok
+}
+
+// processKey handles keys of the open list: arrows and page keys move the
+// selection, Enter and Tab accept the selected item, Esc hides the list.
+// Returns false for other keys and if the list is closed
+func (d *dropDown) processKey(ev Event) bool {
+	if ev.Type != EventKey || !d.isOpen() {
+		return false
+	}
+
+	switch ev.Key {
+	case term.KeyArrowUp, term.KeyArrowDown, term.KeyPgup, term.KeyPgdn:
+		if d.SelectedItem() == -1 && ev.Key == term.KeyArrowDown {
+			d.SelectItem(0)
+			return true
+		}
+		return d.ListBox.ProcessEvent(ev)
+	case term.KeyEnter, term.KeyTab:
+		if d.SelectedItem() == -1 {
+			d.hide()
+			return false
+		}
+		return d.accept()
+	case term.KeyEsc:
+		d.hide()
+		return true
+	}
+	return false
+}
+
+// ProcessEvent handles mouse events over the list: a click accepts the
+// item under the mouse, the wheel scrolls the list
+func (d *dropDown) ProcessEvent(ev Event) bool {
+	if ev.Type != EventMouse {
+		return false
+	}
+
+	switch ev.Key {
+	case term.MouseWheelUp, term.MouseWheelDown:
+		return d.ListBox.ProcessEvent(Event{Type: EventMouseWheel, Key: ev.Key})
+	case term.MouseLeft:
+		if ev.Mod&term.ModMotion != 0 {
+			return true
+		}
+		row := d.topLine + int(ev.Y-d.pos.GetY())
+		if ev.X < d.pos.GetX()+types.ACoordX(d.width.Get()-1) && row < d.ItemCount() {
+			d.SelectItem(row)
+			d.accept()
+		} else {
+			// the scroll bar
+			d.processMouseClick(ev)
+		}
+	}
+	return true
+}
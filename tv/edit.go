@@ -2,6 +2,7 @@ package tv
 
 import (
 	"strings"
+	"unicode"
 
 	xs "github.com/huandu/xstrings"
 	term "github.com/nsf/termbox-go"
@@ -9,7 +10,28 @@ import (
 	"github.com/prospero78/goTV/tv/types"
 )
 
-// OnChange sets the callback that is called when EditField content is changed
+// defaultEditUndoLimit is the default number of changes TEditField can undo
+const defaultEditUndoLimit = 100
+
+// EditChange describes a change of TEditField text: the text Removed at
+// rune index Pos was replaced with the Inserted text
+type EditChange struct {
+	Pos      int
+	Removed  string
+	Inserted string
+}
+
+// editStep is an undo step of TEditField. cursor is the cursor position
+// before the change
+type editStep struct {
+	EditChange
+	cursor int
+}
+
+// OnChange sets the callback that is called when EditField content is
+// changed. Event type is EventChanged, Msg is the new text and X is the
+// rune index where the change starts. LastChange returns the removed and
+// the inserted text
 func (e *TEditField) OnChange(fn func(Event)) {
 	e.onChange = fn
 }
@@ -22,25 +44,43 @@ func (e *TEditField) OnKeyPress(fn func(term.Key, rune) bool) {
 	e.onKeyPress = fn
 }
 
-// SetTitle changes the EditField content and emits OnChage eventif the new value does not equal to old one
+// OnPaste sets the callback that is called when a user pastes text into
+// the field. If the handler processes the text it should return true. If
+// the handler returns false the text is inserted with InsertText
+func (e *TEditField) OnPaste(fn func(string) bool) {
+	e.onPaste = fn
+}
+
+// LastChange returns the last change of the text
+func (e *TEditField) LastChange() EditChange {
+	return e.lastChange
+}
+
+// SetTitle changes the EditField content and emits OnChage eventif the new
+// value does not equal to old one. The undo history is cleared
 func (e *TEditField) SetTitle(title string) {
-	e.setTitleInternal(title)
+	e.setTitleInternal(title, EditChange{Removed: e.title, Inserted: title})
+	e.undo, e.redo = nil, nil
 	e.offset = 0
 	e.End()
 }
 
-func (e *TEditField) setTitleInternal(title string) {
+func (e *TEditField) setTitleInternal(title string, change EditChange) {
 	if e.title != title {
 		e.title = title
+		e.lastChange = change
 
 		if e.onChange != nil {
-			ev := Event{Msg: title}
+			ev := Event{Type: EventChanged, Msg: title, X: types.ACoordX(change.Pos), Target: e}
 			e.onChange(ev)
 		}
 	}
 
-	if title == "" {
-		e.cursorPos = types.ACoordX(xs.Len(title))
+	if length := xs.Len(title); int(e.cursorPos) > length {
+		e.cursorPos = types.ACoordX(length)
+	}
+	if e.anchor > xs.Len(title) {
+		e.anchor = int(e.cursorPos)
 	}
 }
 
@@ -105,6 +145,8 @@ func (e *TEditField) Draw() {
 	fg, bg := RealColor(e.fg, e.Style(), ColorEditText), RealColor(e.bg, e.Style(), ColorEditBack)
 	if !e.Enabled() {
 		fg, bg = RealColor(e.fg, e.Style(), ColorDisabledText), RealColor(e.fg, e.Style(), ColorDisabledBack)
+	} else if e.invalid != nil {
+		fg, bg = RealColor(ColorDefault, e.Style(), ColorEditInvalidText), RealColor(ColorDefault, e.Style(), ColorEditInvalidBack)
 	} else if e.Active() {
 		fg, bg = RealColor(e.fg, e.Style(), ColorEditActiveText), RealColor(e.bg, e.Style(), ColorEditActiveBack)
 	}
@@ -113,129 +155,416 @@ func (e *TEditField) Draw() {
 	SetBackColor(bg)
 	FillRect(x, y, w, 1, ' ')
 	DrawRawText(x, y, textOut)
+
+	if from, to := e.Selection(); from != to && e.Active() {
+		SetTextColor(RealColor(ColorDefault, e.Style(), ColorSelectionText))
+		SetBackColor(RealColor(ColorDefault, e.Style(), ColorSelectionBack))
+		out := []rune(textOut)
+		for i := from; i < to; i++ {
+			sx := i + curOff
+			// skip the scroll markers
+			if sx < 0 || sx >= len(out) || (sx == 0 && e.offset > 0) ||
+				(sx == len(out)-1 && i != xs.Len(e.title)-1 && string(out[sx]) == chRight) {
+				continue
+			}
+			PutChar(x+types.ACoordX(sx), y, out[sx])
+		}
+	}
+
 	if e.Active() {
 		SetCursorPos(e.cursorPos+e.pos.GetX()+types.ACoordX(curOff), e.pos.GetY())
 	}
 }
 
-func (e *TEditField) InsertRune(ch rune) {
-	if e.readonly {
-		return
+// scrollToCursor changes the offset of the displayed text to make the
+// cursor visible
+func (e *TEditField) scrollToCursor() {
+	w, length, cur := int(e.width.Get()), xs.Len(e.title), int(e.cursorPos)
+	switch {
+	case length < w || cur <= w-2 && (e.offset == 0 || cur < e.offset):
+		e.offset = 0
+	case cur < e.offset:
+		e.offset = cur
+	case cur == length && cur+1-e.offset >= w-1:
+		e.offset = length - (w - 2)
+	case cur+1-e.offset > w-2:
+		e.offset = cur - w + 3
+	}
+	if max := length - (w - 2); e.offset > max {
+		e.offset = max
 	}
+	if e.offset < 0 {
+		e.offset = 0
+	}
+}
 
-	if e.maxWidth > 0 && xs.Len(e.title) >= e.maxWidth {
-		return
+// moveCursor moves the cursor to the rune index. If selecting is true the
+// selection is extended to the new position, otherwise the selection is
+// dropped
+func (e *TEditField) moveCursor(pos int, selecting bool) {
+	if length := xs.Len(e.title); pos > length {
+		pos = length
+	}
+	if pos < 0 {
+		pos = 0
 	}
 
-	idx := e.cursorPos
+	e.cursorPos = types.ACoordX(pos)
+	if !selecting {
+		e.anchor = pos
+	}
+	e.typing = false
+	e.scrollToCursor()
+	if e.completions != nil {
+		e.CloseCompletions()
+	}
+}
 
-	switch {
-	case idx == 0:
-		e.setTitleInternal(string(ch) + e.title)
-	case int(idx) >= xs.Len(e.title):
-		e.setTitleInternal(e.title + string(ch))
-	default:
-		e.setTitleInternal(xs.Slice(e.title, 0, int(idx)) + string(ch) + xs.Slice(e.title, int(idx), -1))
+// replace replaces the runes from the index from to the index to with the
+// text as one undo step. The text is truncated to fit the maximum length.
+// The validator can reject or complete the new text. typed text is merged
+// with the previously typed text into one undo step
+func (e *TEditField) replace(from, to int, text string, typed bool) {
+	title := []rune(e.title)
+	ins := []rune(text)
+	if e.maxWidth > 0 && len(title)-(to-from)+len(ins) > e.maxWidth {
+		free := e.maxWidth - len(title) + (to - from)
+		if free < 0 {
+			free = 0
+		}
+		ins = ins[:free]
+	}
+	if from == to && len(ins) == 0 {
+		return
 	}
 
-	e.cursorPos++
+	cursor := from + len(ins)
+	if e.validator != nil {
+		text := string(title[:from]) + string(ins) + string(title[to:])
+		fixed, ok := e.validator.IsValidInput(text, len(ins) > 0)
+		if !ok {
+			return
+		}
+		if fixed != text {
+			// the cursor stays before the unchanged end of the text
+			cursor = xs.Len(fixed) - (len(title) - to)
+			from, to, ins = diffRunes(title, []rune(fixed))
+		}
+	}
 
-	if int(e.cursorPos) >= int(e.width.Get()) {
-		if e.offset == 0 {
-			e.offset = 2
-		} else {
-			e.offset++
+	change := EditChange{Pos: from, Removed: string(title[from:to]), Inserted: string(ins)}
+	n := len(e.undo)
+	if typed && e.typing && n > 0 && e.undo[n-1].Pos+xs.Len(e.undo[n-1].Inserted) == from {
+		e.undo[n-1].Inserted += change.Inserted
+		e.undo[n-1].Removed += change.Removed
+	} else {
+		e.undo = append(e.undo, editStep{EditChange: change, cursor: int(e.cursorPos)})
+		if len(e.undo) > e.undoLimit {
+			e.undo = e.undo[len(e.undo)-e.undoLimit:]
 		}
 	}
+	e.redo = nil
+
+	e.setTitleInternal(string(title[:from])+string(ins)+string(title[to:]), change)
+	e.moveCursor(cursor, false)
+	e.typing = typed
+	if e.invalid != nil {
+		_ = e.Validate()
+	}
+	if e.completer != nil {
+		e.requestCompletions(false)
+	}
 }
 
-func (e *TEditField) Backspace() {
-	if e.title == "" || e.cursorPos == 0 || e.readonly {
+// diffRunes returns the changed part of the text: the runes from the index
+// from to the index to of before are replaced with ins in after
+func diffRunes(before, after []rune) (from, to int, ins []rune) {
+	for from < len(before) && from < len(after) && before[from] == after[from] {
+		from++
+	}
+	tail := 0
+	for tail < len(before)-from && tail < len(after)-from && before[len(before)-1-tail] == after[len(after)-1-tail] {
+		tail++
+	}
+	return from, len(before) - tail, after[from : len(after)-tail]
+}
+
+// InsertRune inserts the rune at the cursor position. The rune replaces
+// the selection, or the rune under the cursor in overwrite mode
+func (e *TEditField) InsertRune(ch rune) {
+	if e.readonly {
+		return
+	}
+	from, to := e.Selection()
+	if e.overwrite && from == to && to < xs.Len(e.title) {
+		to++
+	}
+	e.replace(from, to, string(ch), true)
+}
+
+// InsertText replaces the selection with the text or inserts it at the
+// cursor position as one change, so OnChange is called once. The field is
+// single-line: line breaks and tabs are replaced with spaces and other
+// control characters are removed. The text is truncated if it does not fit
+// the maximum length
+func (e *TEditField) InsertText(text string) {
+	if e.readonly {
 		return
 	}
+	var buf strings.Builder
+	for _, r := range strings.TrimRight(text, "\n") {
+		switch {
+		case r == '\n' || r == '\t':
+			buf.WriteRune(' ')
+		case !unicode.IsControl(r):
+			buf.WriteRune(r)
+		}
+	}
 
-	length := xs.Len(e.title)
+	from, to := e.Selection()
+	e.replace(from, to, buf.String(), false)
+}
 
-	switch {
-	case int(e.cursorPos) >= length:
-		e.cursorPos--
-		e.setTitleInternal(xs.Slice(e.title, 0, length-1))
-	case e.cursorPos == 1:
-		e.cursorPos = 0
-		e.setTitleInternal(xs.Slice(e.title, 1, -1))
-		e.offset = 0
-	default:
-		e.cursorPos--
-		e.setTitleInternal(xs.Slice(e.title, 0, int(e.cursorPos)) + xs.Slice(e.title, int(e.cursorPos+1), -1))
+// deleteSelection removes the selected text. Returns false if nothing is
+// selected
+func (e *TEditField) deleteSelection() bool {
+	from, to := e.Selection()
+	if from == to {
+		return false
 	}
+	e.replace(from, to, "", false)
+	return true
+}
 
-	if length-1 < int(e.width.Get()) {
-		e.offset = 0
+// Backspace deletes the selection or the rune before the cursor
+func (e *TEditField) Backspace() {
+	if e.readonly || e.deleteSelection() || e.cursorPos == 0 {
+		return
 	}
+	e.replace(int(e.cursorPos)-1, int(e.cursorPos), "", false)
 }
 
+// Del deletes the selection or the rune under the cursor
 func (e *TEditField) Del() {
-	length := xs.Len(e.title)
+	if e.readonly || e.deleteSelection() || int(e.cursorPos) >= xs.Len(e.title) {
+		return
+	}
+	e.replace(int(e.cursorPos), int(e.cursorPos)+1, "", false)
+}
 
-	if e.title == "" || int(e.cursorPos) == length || e.readonly {
+// DeleteWordLeft deletes the selection or the text from the start of the
+// word before the cursor to the cursor
+func (e *TEditField) DeleteWordLeft() {
+	if e.readonly || e.deleteSelection() {
 		return
 	}
+	e.replace(e.wordStart(), int(e.cursorPos), "", false)
+}
 
-	if int(e.cursorPos) == length-1 {
-		e.setTitleInternal(xs.Slice(e.title, 0, length-1))
-	} else {
-		e.setTitleInternal(xs.Slice(e.title, 0, int(e.cursorPos)) + xs.Slice(e.title, int(e.cursorPos+1), -1))
+// wordStart returns the index of the start of the word before the cursor
+func (e *TEditField) wordStart() int {
+	title := []rune(e.title)
+	pos := int(e.cursorPos)
+	for pos > 0 && !isWordRune(title[pos-1]) {
+		pos--
 	}
+	for pos > 0 && isWordRune(title[pos-1]) {
+		pos--
+	}
+	return pos
+}
 
-	if length-1 < int(e.width.Get()) {
-		e.offset = 0
+// wordEnd returns the index of the start of the word after the cursor
+func (e *TEditField) wordEnd() int {
+	title := []rune(e.title)
+	pos := int(e.cursorPos)
+	for pos < len(title) && isWordRune(title[pos]) {
+		pos++
+	}
+	for pos < len(title) && !isWordRune(title[pos]) {
+		pos++
 	}
+	return pos
 }
 
 func (e *TEditField) CharLeft() {
-	if e.cursorPos == 0 || e.title == "" {
-		return
-	}
+	e.charLeft(false)
+}
 
-	if int(e.cursorPos) == e.offset {
-		e.offset--
+func (e *TEditField) charLeft(selecting bool) {
+	if from, _ := e.Selection(); !selecting && e.HasSelection() {
+		e.moveCursor(from, false)
+		return
 	}
-
-	e.cursorPos--
+	e.moveCursor(int(e.cursorPos)-1, selecting)
 }
 
 func (e *TEditField) CharRight() {
-	length := xs.Len(e.title)
-	if int(e.cursorPos) == length || e.title == "" {
+	e.charRight(false)
+}
+
+func (e *TEditField) charRight(selecting bool) {
+	if _, to := e.Selection(); !selecting && e.HasSelection() {
+		e.moveCursor(to, false)
 		return
 	}
+	e.moveCursor(int(e.cursorPos)+1, selecting)
+}
 
-	e.cursorPos++
-	if int(e.cursorPos) != length && int(e.cursorPos) >= e.offset+int(e.width.Get())-2 {
-		e.offset++
-	}
+// WordLeft moves the cursor to the start of the previous word
+func (e *TEditField) WordLeft() {
+	e.moveCursor(e.wordStart(), false)
+}
+
+// WordRight moves the cursor to the start of the next word
+func (e *TEditField) WordRight() {
+	e.moveCursor(e.wordEnd(), false)
 }
 
 func (e *TEditField) Home() {
-	e.offset = 0
-	e.cursorPos = 0
+	e.moveCursor(0, false)
 }
 
 func (e *TEditField) End() {
-	length := xs.Len(e.title)
-	e.cursorPos = types.ACoordX(length)
+	e.moveCursor(xs.Len(e.title), false)
+}
 
-	if length < int(e.width.Get()) {
-		return
+// CursorPos returns the rune index of the cursor
+func (e *TEditField) CursorPos() int {
+	return int(e.cursorPos)
+}
+
+// SetCursorPos moves the cursor to the rune index and drops the selection
+func (e *TEditField) SetCursorPos(pos int) {
+	e.moveCursor(pos, false)
+}
+
+// Selection returns the rune indices of the start and the end of the
+// selected text. They are equal if nothing is selected
+func (e *TEditField) Selection() (from, to int) {
+	from, to = e.anchor, int(e.cursorPos)
+	if from > to {
+		from, to = to, from
 	}
+	return from, to
+}
 
-	e.offset = length - (int(e.width.Get()) - 2)
+// HasSelection returns true if some text is selected
+func (e *TEditField) HasSelection() bool {
+	return e.anchor != int(e.cursorPos)
 }
 
-// Clear empties the EditField and emits OnChange event
+// SelectedText returns the selected text
+func (e *TEditField) SelectedText() string {
+	from, to := e.Selection()
+	return xs.Slice(e.title, from, to)
+}
+
+// Select selects the runes from the index from to the index to. The cursor
+// is moved to the end of the selection
+func (e *TEditField) Select(from, to int) {
+	e.moveCursor(from, false)
+	e.moveCursor(to, true)
+}
+
+// SelectAll selects the whole text
+func (e *TEditField) SelectAll() {
+	e.Select(0, xs.Len(e.title))
+}
+
+// CanUndo returns true if there are changes to undo
+func (e *TEditField) CanUndo() bool {
+	return len(e.undo) > 0
+}
+
+// CanRedo returns true if there are undone changes to redo
+func (e *TEditField) CanRedo() bool {
+	return len(e.redo) > 0
+}
+
+// Undo reverts the last change. Returns false if there is nothing to undo
+func (e *TEditField) Undo() bool {
+	if e.readonly || len(e.undo) == 0 {
+		return false
+	}
+
+	step := e.undo[len(e.undo)-1]
+	e.undo = e.undo[:len(e.undo)-1]
+	e.redo = append(e.redo, step)
+
+	title := []rune(e.title)
+	end := step.Pos + xs.Len(step.Inserted)
+	change := EditChange{Pos: step.Pos, Removed: step.Inserted, Inserted: step.Removed}
+	e.setTitleInternal(string(title[:step.Pos])+step.Removed+string(title[end:]), change)
+	e.moveCursor(step.cursor, false)
+	return true
+}
+
+// Redo repeats the last undone change. Returns false if there is nothing
+// to redo
+func (e *TEditField) Redo() bool {
+	if e.readonly || len(e.redo) == 0 {
+		return false
+	}
+
+	step := e.redo[len(e.redo)-1]
+	e.redo = e.redo[:len(e.redo)-1]
+	e.undo = append(e.undo, step)
+
+	title := []rune(e.title)
+	end := step.Pos + xs.Len(step.Removed)
+	e.setTitleInternal(string(title[:step.Pos])+step.Inserted+string(title[end:]), step.EditChange)
+	e.moveCursor(step.Pos+xs.Len(step.Inserted), false)
+	return true
+}
+
+// UndoLimit returns the maximum number of changes that can be undone
+func (e *TEditField) UndoLimit() int {
+	return e.undoLimit
+}
+
+// SetUndoLimit changes the maximum number of changes that can be undone.
+// The oldest changes are forgotten first
+func (e *TEditField) SetUndoLimit(limit int) {
+	if limit < 0 {
+		limit = 0
+	}
+	e.undoLimit = limit
+	if len(e.undo) > limit {
+		e.undo = e.undo[len(e.undo)-limit:]
+	}
+}
+
+// Overwrite returns true if typed characters replace the text under the
+// cursor
+func (e *TEditField) Overwrite() bool {
+	return e.overwrite
+}
+
+// SetOverwrite switches between insert and overwrite modes
+func (e *TEditField) SetOverwrite(overwrite bool) {
+	e.overwrite = overwrite
+}
+
+// ReadOnly returns true if a user cannot change the text
+func (e *TEditField) ReadOnly() bool {
+	return e.readonly
+}
+
+// SetReadOnly enables or disables changing the text. A user still can
+// move the cursor, select and copy the text of a read-only field, the
+// program can change it with SetTitle and Clear
+func (e *TEditField) SetReadOnly(readonly bool) {
+	e.readonly = readonly
+	if readonly {
+		e.CloseCompletions()
+	}
+}
+
+// Clear empties the EditField and emits OnChange event. It can be undone.
+// Read-only fields are cleared as well
 func (e *TEditField) Clear() {
-	e.Home()
-	e.setTitleInternal("")
+	e.replace(0, xs.Len(e.title), "", false)
 }
 
 // SetMaxWidth sets the maximum lenght of the EditField text. If the current text is longer it is truncated
@@ -281,6 +610,62 @@ func (e *TEditField) PasswordMode() bool {
 // If PasswordMode is false then the EditField works as regular text entry
 // control. If PasswordMode is true then the EditField shows its content hidden
 // with star characters ('*' by default)
+// Password fields never show completions
 func (e *TEditField) SetPasswordMode(pass bool) {
 	e.showStars = pass
+	if pass {
+		e.CloseCompletions()
+	}
+}
+
+// SetValidator sets the validator of the text. nil removes the validator
+func (e *TEditField) SetValidator(v Validator) {
+	e.validator = v
+	if v == nil {
+		e.setInvalid(nil)
+	}
+}
+
+// Validator returns the validator of the text
+func (e *TEditField) Validator() Validator {
+	return e.validator
+}
+
+// Validate checks the text with the validator. An invalid field is drawn
+// with EditInvalid colors and the error is shown in the status bar. The
+// field without a validator is always valid
+func (e *TEditField) Validate() error {
+	var err error
+	if e.validator != nil {
+		err = e.validator.Validate(e.title)
+	}
+	e.setInvalid(err)
+	return err
+}
+
+// ValidationError returns the error of the last validation or nil if the
+// field is valid
+func (e *TEditField) ValidationError() error {
+	return e.invalid
+}
+
+// setInvalid marks the field invalid or valid. The status bar message is
+// cleared when the field becomes valid
+func (e *TEditField) setInvalid(err error) {
+	if err == nil && e.invalid != nil {
+		if sb := comp.StatusBar(); sb != nil && sb.Message() == e.invalid.Error() {
+			sb.SetMessage("")
+		}
+	}
+	e.invalid = err
+	if err != nil {
+		e.showInvalid()
+	}
+}
+
+// showInvalid shows the validation error in the status bar
+func (e *TEditField) showInvalid() {
+	if sb := comp.StatusBar(); sb != nil {
+		sb.SetMessage(e.invalid.Error())
+	}
 }
@@ -14,24 +14,64 @@ import (
 /*
 TEditField is a single-line text edit contol. Edit field consumes some keyboard
 events when it is active: all printable charaters; Delete, BackSpace, Home,
-End, left and right arrows; Ctrl+R to clear TEditField.
+End, left and right arrows; Ctrl+R to clear TEditField. Pasted text is
+inserted at once with InsertText.
+Shift with Left, Right, Home and End selects text, Ctrl+A selects all, mouse
+drag selects text as well. Ctrl+Left and Ctrl+Right jump by words,
+Ctrl+Backspace deletes the word before the cursor. Ctrl+C or Ctrl+Insert
+copies the selection (or the whole text if nothing is selected), Ctrl+X or
+Shift+Delete cuts it, Ctrl+V or Shift+Insert pastes at the cursor replacing
+the selection. Ctrl+Z and Ctrl+Y undo and redo changes, the history is
+limited with SetUndoLimit. Insert toggles overwrite mode.
 Edit text can be limited. By default a user can enter text of any length.
 Use SetMaxWidth to limit the maximum text length. If the text is longer than
 maximun then the text is automatically truncated.
-TEditField calls onChage in case of its text is changed. Event field Msg contains the new text
+TEditField calls onChage in case of its text is changed. Event field Msg contains the new text,
+X is the position of the change, LastChange describes the change.
+A Validator set with SetValidator rejects invalid keys while a user types.
+The text is validated when the field loses focus and by ValidateControls
+before a dialog is closed: an invalid field is drawn with EditInvalid colors
+and the error is shown in the status bar.
+SetCompleter adds a drop-down list of completions, see Complete
 */
 type TEditField struct {
 	TBaseControl
 	// cursor position in edit text
 	cursorPos types.ACoordX
+	// selection anchor: the text between the anchor and the cursor is
+	// selected
+	anchor int
 	// the number of the first displayed text character - it is used in case of text is longer than edit width
 	offset    int
 	readonly  bool
 	maxWidth  int
 	showStars bool
+	overwrite bool
+
+	// undo history, typing is true while a user types characters one by
+	// one: they are undone at once
+	undo       []editStep
+	redo       []editStep
+	undoLimit  int
+	typing     bool
+	lastChange EditChange
+
+	validator Validator
+	// the error of the last validation
+	invalid error
+
+	// completion provider and the drop-down list of its matches.
+	// completeSeq is the number of the last request: matches of older
+	// requests are dropped
+	completer     func(string) []string
+	asyncComplete bool
+	completions   *dropDown
+	completeSeq   int
+	accepting     bool
 
 	onChange   func(Event)
 	onKeyPress func(term.Key, rune) bool
+	onPaste    func(string) bool
 
 	autoWidth types.IAutoWidth
 }
@@ -47,6 +87,7 @@ func CreateEditField(parent IControl, width int, text string, scale int) *TEditF
 	e := &TEditField{
 		TBaseControl: NewBaseControl(),
 		autoWidth:    autowidth.New(),
+		undoLimit:    defaultEditUndoLimit,
 	}
 
 	e.onChange = nil
@@ -83,15 +124,58 @@ that the control do not want or cannot process the event and the caller sends
 the event to the control parent
 */
 func (e *TEditField) ProcessEvent(event Event) bool {
+	if event.Type == EventActivate && event.X == 0 {
+		// the field is already inactive when it loses focus
+		e.CloseCompletions()
+		_ = e.Validate()
+	}
+
 	if !e.Active() || !e.Enabled() {
 		return false
 	}
 
+	if event.Type == EventActivate && event.X == 1 && e.invalid != nil {
+		e.showInvalid()
+	}
+
 	if event.Type == EventActivate && event.X == 0 {
-		term.HideCursor()
+		HideCursor()
+	}
+
+	switch event.Type {
+	case EventPaste:
+		if e.onPaste != nil && e.onPaste(event.Msg) {
+			return true
+		}
+		e.InsertText(event.Msg)
+		return true
+	case EventMouse:
+		if event.Key == term.MouseLeft && event.Mod&term.ModMotion == 0 {
+			e.moveCursor(e.indexAt(event.X), false)
+			return true
+		}
+		return false
+	case EventMouseDrag:
+		if event.Key == term.MouseLeft {
+			e.moveCursor(e.indexAt(event.X), true)
+			return true
+		}
+		return false
+	case EventDoubleClick:
+		e.SelectAll()
+		return true
+	}
+
+	if e.completions != nil && e.completions.processKey(event) {
+		return true
 	}
 
 	if event.Type == EventKey && event.Key != term.KeyTab {
+		if event.Key == term.KeyCtrlSpace && event.Ch == 0 {
+			e.Complete()
+			return true
+		}
+
 		if e.onKeyPress != nil {
 			res := e.onKeyPress(event.Key, event.Ch)
 			if res {
@@ -99,6 +183,7 @@ func (e *TEditField) ProcessEvent(event Event) bool {
 			}
 		}
 
+		shift := event.Shift()
 		switch event.Key {
 		case term.KeyEnter:
 			return false
@@ -106,19 +191,44 @@ func (e *TEditField) ProcessEvent(event Event) bool {
 			e.InsertRune(' ')
 			return true
 		case term.KeyBackspace, term.KeyBackspace2:
-			e.Backspace()
+			// xterm-like terminals send DEL (KeyBackspace2) for Backspace
+			// and Ctrl+H (KeyBackspace) for Ctrl+Backspace. CSI u and
+			// modifyOtherKeys report Ctrl as the modifier
+			if event.Key == term.KeyBackspace || event.Mod&ModCtrl != 0 {
+				e.DeleteWordLeft()
+			} else {
+				e.Backspace()
+			}
 			return true
 		case term.KeyDelete:
-			e.Del()
+			if shift {
+				e.cut()
+			} else {
+				e.Del()
+			}
+			return true
+		case term.KeyInsert:
+			switch {
+			case shift:
+				e.paste()
+			case event.Ctrl():
+				e.copy()
+			default:
+				e.overwrite = !e.overwrite
+			}
 			return true
 		case term.KeyArrowLeft:
-			e.CharLeft()
+			if event.Ctrl() {
+				e.moveCursor(e.wordStart(), shift)
+			} else {
+				e.charLeft(shift)
+			}
 			return true
 		case term.KeyHome:
-			e.Home()
+			e.moveCursor(0, shift)
 			return true
 		case term.KeyEnd:
-			e.End()
+			e.moveCursor(xs.Len(e.title), shift)
 			return true
 		case term.KeyCtrlR:
 			if !e.readonly {
@@ -126,19 +236,29 @@ func (e *TEditField) ProcessEvent(event Event) bool {
 			}
 			return true
 		case term.KeyArrowRight:
-			e.CharRight()
+			if event.Ctrl() {
+				e.moveCursor(e.wordEnd(), shift)
+			} else {
+				e.charRight(shift)
+			}
+			return true
+		case term.KeyCtrlA:
+			e.SelectAll()
 			return true
 		case term.KeyCtrlC:
-			if !e.showStars {
-				_ = clipboard.WriteAll(e.Title())
-			}
+			e.copy()
+			return true
+		case term.KeyCtrlX:
+			e.cut()
 			return true
 		case term.KeyCtrlV:
-			if !e.readonly {
-				s, _ := clipboard.ReadAll()
-				e.SetTitle(s)
-				e.End()
-			}
+			e.paste()
+			return true
+		case term.KeyCtrlZ:
+			e.Undo()
+			return true
+		case term.KeyCtrlY:
+			e.Redo()
 			return true
 		default:
 			if event.Ch != 0 {
@@ -151,3 +271,44 @@ func (e *TEditField) ProcessEvent(event Event) bool {
 
 	return false
 }
+
+// indexAt returns the rune index of the text at the screen column x
+func (e *TEditField) indexAt(x types.ACoordX) int {
+	idx := int(x - e.pos.GetX())
+	if e.offset > 0 {
+		idx += e.offset - 1
+	}
+	return idx
+}
+
+// copy copies the selection or the whole text to the clipboard. Text of
+// a password field is not copied
+func (e *TEditField) copy() {
+	if e.showStars {
+		return
+	}
+	text := e.SelectedText()
+	if text == "" {
+		text = e.Title()
+	}
+	_ = clipboard.WriteAll(text)
+}
+
+// cut moves the selection to the clipboard
+func (e *TEditField) cut() {
+	if e.readonly || e.showStars || !e.HasSelection() {
+		return
+	}
+	_ = clipboard.WriteAll(e.SelectedText())
+	e.deleteSelection()
+}
+
+// paste inserts the clipboard text at the cursor replacing the selection
+func (e *TEditField) paste() {
+	if e.readonly {
+		return
+	}
+	if s, err := clipboard.ReadAll(); err == nil {
+		e.InsertText(s)
+	}
+}
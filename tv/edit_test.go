@@ -0,0 +1,174 @@
+package tv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	term "github.com/nsf/termbox-go"
+)
+
+func TestEditFieldPaste(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(40, 10))
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 0, 30, 5, "Paste", false, false)
+	edit := CreateEditField(wnd, 10, "ab", Fixed)
+	ActivateControl(wnd, edit)
+	edit.Home()
+	edit.CharRight()
+
+	changes := 0
+	edit.OnChange(func(ev Event) {
+		changes++
+	})
+
+	ProcessEvent(Event{Type: EventPaste, Msg: "one\ntwo\n"})
+	if edit.Title() != "aone twob" || changes != 1 {
+		t.Errorf("Paste must be one change: '%v', %v changes", edit.Title(), changes)
+	}
+
+	edit.SetMaxWidth(12)
+	ProcessEvent(Event{Type: EventPaste, Msg: "123456"})
+	if edit.Title() != "aone two123b" {
+		t.Errorf("Paste must be truncated: '%v'", edit.Title())
+	}
+
+	edit.SetReadOnly(true)
+	ProcessEvent(Event{Type: EventPaste, Msg: "x"})
+	ProcessEvent(Event{Type: EventKey, Ch: 'y'})
+	if edit.Title() != "aone two123b" {
+		t.Errorf("Read-only text must not be changed: '%v'", edit.Title())
+	}
+	edit.Clear()
+	if edit.Title() != "" {
+		t.Errorf("Clear must empty read-only field: '%v'", edit.Title())
+	}
+}
+
+func TestEditFieldSelection(t *testing.T) {
+	scr := NewHeadlessScreen(40, 10)
+	InitLibrary(scr)
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 0, 30, 5, "Edit", false, false)
+	edit := CreateEditField(wnd, 10, "foo bar baz", Fixed)
+	wnd.ResizeChildren()
+	wnd.PlaceChildren()
+	ActivateControl(wnd, edit)
+
+	var changes []Event
+	edit.OnChange(func(ev Event) {
+		changes = append(changes, ev)
+	})
+
+	ProcessEvent(keyEvent(term.KeyHome))
+	ProcessEvent(Event{Type: EventKey, Key: term.KeyArrowRight, Mod: ModCtrl})
+	ProcessEvent(Event{Type: EventKey, Key: term.KeyArrowRight, Mod: ModCtrl | ModShift})
+	ProcessEvent(Event{Type: EventKey, Key: term.KeyArrowLeft, Mod: ModShift})
+	if edit.SelectedText() != "bar" {
+		t.Errorf("Wrong selection: %q", edit.SelectedText())
+	}
+	ProcessEvent(Event{Type: EventPaste, Msg: "qux"})
+	change := edit.LastChange()
+	if edit.Title() != "foo qux baz" || change.Pos != 4 || change.Removed != "bar" || change.Inserted != "qux" {
+		t.Errorf("Paste must replace the selection: %q, %+v", edit.Title(), change)
+	}
+	if len(changes) != 1 || changes[0].Type != EventChanged || changes[0].X != 4 || changes[0].Msg != "foo qux baz" {
+		t.Errorf("Wrong change event: %+v", changes)
+	}
+
+	// keys as a terminal sends them: DEL is Backspace, Ctrl+H is
+	// Ctrl+Backspace
+	ProcessEvent(keyEvent(term.KeyEnd))
+	feed := func(input string) {
+		events, _ := parseInput([]byte(input))
+		for _, ev := range events {
+			ProcessEvent(ev)
+		}
+	}
+	feed("\x7f")
+	if edit.Title() != "foo qux ba" {
+		t.Errorf("Backspace must delete one rune: %q", edit.Title())
+	}
+	feed("\x08")
+	if edit.Title() != "foo qux " {
+		t.Errorf("Ctrl+Backspace must delete the word: %q", edit.Title())
+	}
+
+	ProcessEvent(keyEvent(term.KeyHome))
+	ProcessEvent(keyEvent(term.KeyInsert))
+	ProcessEvent(Event{Type: EventKey, Ch: 'b'})
+	ProcessEvent(Event{Type: EventKey, Ch: 'a'})
+	ProcessEvent(Event{Type: EventKey, Ch: 'r'})
+	if edit.Title() != "bar qux " || !edit.Overwrite() {
+		t.Errorf("Wrong overwrite: %q", edit.Title())
+	}
+
+	// typed characters are one step
+	for _, want := range []string{"foo qux ", "foo qux ba", "foo qux baz", "foo bar baz"} {
+		ProcessEvent(keyEvent(term.KeyCtrlZ))
+		if edit.Title() != want {
+			t.Errorf("Wrong undo: %q instead of %q", edit.Title(), want)
+		}
+	}
+	ProcessEvent(keyEvent(term.KeyCtrlY))
+	if edit.Title() != "foo qux baz" || !edit.CanRedo() {
+		t.Errorf("Wrong redo: %q", edit.Title())
+	}
+
+	edit.SetUndoLimit(2)
+	edit.SetOverwrite(false)
+	for _, ch := range "123" {
+		edit.InsertText(string(ch))
+	}
+	undone := 0
+	for edit.Undo() {
+		undone++
+	}
+	if undone != 2 || edit.Title() != "foo qux1 baz" {
+		t.Errorf("Undo must be limited: %v, %q", undone, edit.Title())
+	}
+
+	// long text is scrolled to the cursor, mouse drag selects
+	edit.SetTitle("0123456789abcdef")
+	RefreshScreen()
+	if x, _, _ := scr.Cursor(); x != 10 || !strings.HasPrefix(scr.Lines()[1], "║←89abcdef ") {
+		t.Errorf("End must be visible: %v <%v>", x, scr.Lines()[1])
+	}
+	ProcessEvent(mouseAt(3, 1, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(6, 1, term.MouseLeft, term.ModMotion))
+	if edit.SelectedText() != "9ab" {
+		t.Errorf("Wrong mouse selection: %q", edit.SelectedText())
+	}
+	ProcessEvent(keyEvent(term.KeyCtrlA))
+	ProcessEvent(keyEvent(term.KeyDelete))
+	if edit.Title() != "" {
+		t.Errorf("Select all must select the whole text: %q", edit.Title())
+	}
+}
+
+func TestFileDialogPastePath(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(80, 25))
+	defer DeinitLibrary()
+
+	dir, err := ioutil.TempDir("", "tvpaste")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	dlg := CreateFileSelectDialog("Open", "*", dir, false, false)
+	ActivateControl(dlg.View, dlg.edFile)
+	ProcessEvent(Event{Type: EventPaste, Msg: filepath.Join(sub, "new.txt")})
+
+	if dlg.currPath != sub || dlg.edFile.Title() != "new.txt" {
+		t.Errorf("Pasted path must open its directory: '%v', '%v'", dlg.currPath, dlg.edFile.Title())
+	}
+}
@@ -0,0 +1,370 @@
+package tv
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	term "github.com/nsf/termbox-go"
+
+	"github.com/prospero78/goTV/tv/types"
+)
+
+/*
+Event scripts make user sessions reproducible. While recording is on,
+MainLoop writes every event it passes to ProcessEvent into a script: user
+input that comes from the screen backend (keys, mouse, resize) and the
+synthetic events posted with PutEvent (redraw, quit, etc). A script is a
+text file with one JSON object per line, so it is easy to attach to a bug
+report and to edit manually:
+
+	{"delay":0,"source":"screen","type":"key","key":65517}
+	{"delay":120,"source":"screen","type":"key","ch":"a"}
+	{"delay":15,"source":"post","type":"redraw"}
+
+delay is the number of milliseconds passed since the previous event.
+Field Target of Event is not recorded - controls cannot be serialized.
+
+A loaded script is played back with PlayEventScript. Events are posted to
+the main loop either with original delays or as fast as the loop processes
+them.
+*/
+
+// EventSource shows where a recorded event came from
+type EventSource int
+
+// EventSource constants
+const (
+	// SourceScreen - the event is user input read from the screen backend
+	SourceScreen EventSource = iota
+	// SourcePost - the event is posted to the main loop with PutEvent
+	SourcePost
+)
+
+// PlayMode is a speed of event script playback
+type PlayMode int
+
+// PlayMode constants
+const (
+	// PlayRealTime keeps original delays between events
+	PlayRealTime PlayMode = iota
+	// PlayFast posts events one by one without any delay
+	PlayFast
+)
+
+// ScriptEvent is one recorded event of an event script
+type ScriptEvent struct {
+	// Delay is the time passed since the previous event
+	Delay  time.Duration
+	Source EventSource
+	Event  Event
+}
+
+var eventTypeNames = map[EventType]string{
+	EventKey:           "key",
+	EventResize:        "resize",
+	EventMouse:         "mouse",
+	EventError:         "error",
+	EventInterrupt:     "interrupt",
+	EventRaw:           "raw",
+	EventNone:          "none",
+	EventRedraw:        "redraw",
+	EventClose:         "close",
+	EventActivate:      "activate",
+	EventMove:          "move",
+	EventChanged:       "changed",
+	EventClick:         "click",
+	EventDialogClose:   "dialogclose",
+	EventQuit:          "quit",
+	EventCloseWindow:   "closewindow",
+	EventLayout:        "layout",
+	EventActivateChild: "activatechild",
+	EventCall:          "call",
+	EventPaste:         "paste",
+	EventMouseUp:       "mouseup",
+	EventMouseWheel:    "wheel",
+	EventDoubleClick:   "doubleclick",
+	EventRightClick:    "rightclick",
+	EventMouseDrag:     "drag",
+	EventMouseMove:     "mousemove",
+}
+
+var sourceNames = []string{"screen", "post"}
+
+// scriptLine is JSON representation of ScriptEvent
+type scriptLine struct {
+	Delay  int64  `json:"delay"`
+	Source string `json:"source"`
+	Type   string `json:"type"`
+	Key    uint16 `json:"key,omitempty"`
+	Ch     string `json:"ch,omitempty"`
+	Mod    uint8  `json:"mod,omitempty"`
+	X      int    `json:"x,omitempty"`
+	Y      int    `json:"y,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	Msg    string `json:"msg,omitempty"`
+	Err    string `json:"err,omitempty"`
+}
+
+func eventTypeName(tp EventType) string {
+	if name, ok := eventTypeNames[tp]; ok {
+		return name
+	}
+	return strconv.Itoa(int(tp))
+}
+
+func eventTypeByName(name string) (EventType, error) {
+	for tp, n := range eventTypeNames {
+		if n == name {
+			return tp, nil
+		}
+	}
+
+	id, err := strconv.Atoi(name)
+	if err != nil {
+		return EventNone, fmt.Errorf("unknown event type '%v'", name)
+	}
+	return EventType(id), nil
+}
+
+// EventRecorder writes events to an event script
+type EventRecorder struct {
+	mtx  sync.Mutex
+	w    io.Writer
+	enc  *json.Encoder
+	last time.Time
+	err  error
+}
+
+// NewEventRecorder creates a recorder that writes the script to w
+func NewEventRecorder(w io.Writer) *EventRecorder {
+	return &EventRecorder{w: w, enc: json.NewEncoder(w)}
+}
+
+// Record appends the event to the script. Delay is calculated from the
+// time the previous event was recorded. After the first failed write
+// Record does nothing and returns the same error
+func (r *EventRecorder) Record(ev Event, src EventSource) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+
+	now := time.Now()
+	var delay time.Duration
+	if !r.last.IsZero() {
+		delay = now.Sub(r.last)
+	}
+	r.last = now
+
+	r.err = r.enc.Encode(scriptLineFromEvent(ScriptEvent{Delay: delay, Source: src, Event: ev}))
+	return r.err
+}
+
+// Err returns the first error that happened while writing the script
+func (r *EventRecorder) Err() error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return r.err
+}
+
+func scriptLineFromEvent(se ScriptEvent) scriptLine {
+	ev := se.Event
+	line := scriptLine{
+		Delay:  se.Delay.Milliseconds(),
+		Source: sourceNames[SourceScreen],
+		Type:   eventTypeName(ev.Type),
+		Key:    uint16(ev.Key),
+		Mod:    uint8(ev.Mod),
+		X:      int(ev.X),
+		Y:      int(ev.Y),
+		Width:  ev.Width,
+		Height: ev.Height,
+		Msg:    ev.Msg,
+	}
+	if se.Source == SourcePost {
+		line.Source = sourceNames[SourcePost]
+	}
+	if ev.Ch != 0 {
+		line.Ch = string(ev.Ch)
+	}
+	if ev.Err != nil {
+		line.Err = ev.Err.Error()
+	}
+
+	return line
+}
+
+func (l scriptLine) scriptEvent() (ScriptEvent, error) {
+	tp, err := eventTypeByName(l.Type)
+	if err != nil {
+		return ScriptEvent{}, err
+	}
+
+	se := ScriptEvent{
+		Delay:  time.Duration(l.Delay) * time.Millisecond,
+		Source: SourceScreen,
+		Event: Event{
+			Type:   tp,
+			Key:    term.Key(l.Key),
+			Mod:    term.Modifier(l.Mod),
+			X:      types.ACoordX(l.X),
+			Y:      types.ACoordY(l.Y),
+			Width:  l.Width,
+			Height: l.Height,
+			Msg:    l.Msg,
+		},
+	}
+
+	switch l.Source {
+	case "", sourceNames[SourceScreen]:
+	case sourceNames[SourcePost]:
+		se.Source = SourcePost
+	default:
+		return ScriptEvent{}, fmt.Errorf("unknown event source '%v'", l.Source)
+	}
+
+	if l.Ch != "" {
+		se.Event.Ch = []rune(l.Ch)[0]
+	}
+	if l.Err != "" {
+		se.Event.Err = errors.New(l.Err)
+	}
+
+	return se, nil
+}
+
+// LoadEventScript reads the whole event script. Empty lines are skipped
+func LoadEventScript(r io.Reader) ([]ScriptEvent, error) {
+	var script []ScriptEvent
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		data := scanner.Bytes()
+		if len(data) == 0 {
+			continue
+		}
+
+		var line scriptLine
+		if err := json.Unmarshal(data, &line); err != nil {
+			return nil, fmt.Errorf("line %v: %v", lineNo, err)
+		}
+		se, err := line.scriptEvent()
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %v", lineNo, err)
+		}
+		script = append(script, se)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return script, nil
+}
+
+// StartRecording makes MainLoop write all events it processes to w.
+// Recording started before is replaced with the new one
+func StartRecording(w io.Writer) *EventRecorder {
+	r := NewEventRecorder(w)
+	if l := defaultLoop(); l != nil {
+		l.setRecorder(r)
+	}
+	return r
+}
+
+// StopRecording stops writing events and returns the first error that
+// happened while recording
+func StopRecording() error {
+	l := defaultLoop()
+	if l == nil {
+		return nil
+	}
+	r := l.setRecorder(nil)
+	if r == nil {
+		return nil
+	}
+	return r.Err()
+}
+
+// EventPlayer posts events of a script to the main loop
+type EventPlayer struct {
+	loop      *mainLoop
+	script    []ScriptEvent
+	mode      PlayMode
+	synthetic bool
+	stop      chan struct{}
+	done      chan struct{}
+	once      sync.Once
+}
+
+// PlayEventScript starts posting script events to the main loop in a
+// separate goroutine. mode sets whether original delays between events
+// are kept. If synthetic is false only events read from the screen are
+// played back: synthetic ones are usually posted by the application
+// again while it processes the user input
+func PlayEventScript(script []ScriptEvent, mode PlayMode, synthetic bool) *EventPlayer {
+	p := &EventPlayer{
+		loop:      defaultLoop(),
+		script:    script,
+		mode:      mode,
+		synthetic: synthetic,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	go p.run()
+
+	return p
+}
+
+func (p *EventPlayer) run() {
+	defer close(p.done)
+	if p.loop == nil {
+		return
+	}
+
+	for _, se := range p.script {
+		if se.Source == SourcePost && !p.synthetic {
+			continue
+		}
+
+		if p.mode == PlayRealTime && se.Delay > 0 {
+			select {
+			case <-time.After(se.Delay):
+			case <-p.stop:
+				return
+			}
+		}
+
+		select {
+		case p.loop.replay <- se:
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// Stop interrupts playback. Events that are already posted are not
+// cancelled
+func (p *EventPlayer) Stop() {
+	p.once.Do(func() {
+		close(p.stop)
+	})
+	<-p.done
+}
+
+// Wait blocks until all script events are posted or playback is stopped
+func (p *EventPlayer) Wait() {
+	<-p.done
+}
@@ -0,0 +1,151 @@
+package tv
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	term "github.com/nsf/termbox-go"
+)
+
+func TestEventScriptRoundTrip(t *testing.T) {
+	events := []Event{
+		{Type: EventKey, Key: term.KeyArrowDown},
+		{Type: EventKey, Ch: 'Ж', Mod: term.ModAlt},
+		{Type: EventMouse, Key: term.MouseLeft, X: 10, Y: 4},
+		{Type: EventResize, Width: 100, Height: 40},
+		{Type: EventRedraw},
+		{Type: EventError, Err: errors.New("broken pipe")},
+	}
+
+	var buf bytes.Buffer
+	rec := NewEventRecorder(&buf)
+	for i, ev := range events {
+		src := SourceScreen
+		if ev.Type == EventRedraw {
+			src = SourcePost
+		}
+		if err := rec.Record(ev, src); err != nil {
+			t.Fatalf("Failed to record event #%v: %v", i, err)
+		}
+	}
+
+	script, err := LoadEventScript(&buf)
+	if err != nil {
+		t.Fatalf("Failed to load script: %v", err)
+	}
+	if len(script) != len(events) {
+		t.Fatalf("Script must have %v events instead of %v", len(events), len(script))
+	}
+
+	for i, se := range script {
+		want, got := events[i], se.Event
+		if want.Err != nil {
+			if got.Err == nil || got.Err.Error() != want.Err.Error() {
+				t.Errorf("Event #%v error must be %v instead of %v", i, want.Err, got.Err)
+			}
+			want.Err, got.Err = nil, nil
+		}
+		if want != got {
+			t.Errorf("Event #%v must be %+v instead of %+v", i, want, got)
+		}
+	}
+	if script[4].Source != SourcePost || script[0].Source != SourceScreen {
+		t.Errorf("Event source is not restored")
+	}
+}
+
+func TestEventScriptInvalid(t *testing.T) {
+	_, err := LoadEventScript(strings.NewReader("{\"type\":\"key\"}\n{\"type\":\"unknown\"}\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2") {
+		t.Errorf("Unknown event type must fail at line 2, got %v", err)
+	}
+}
+
+// runEditSession starts MainLoop with an EditField that stops the loop
+// when its text becomes stopText
+func runEditSession(scr *HeadlessScreen, stopText string) (*TEditField, chan struct{}) {
+	InitLibrary(scr)
+	wnd := AddWindow(0, 0, 20, 3, "Edit", false, false)
+	edit := CreateEditField(wnd, 10, "", 1)
+	ActivateControl(wnd, edit)
+	edit.OnChange(func(ev Event) {
+		if ev.Msg == stopText {
+			Stop()
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		MainLoop()
+		close(done)
+	}()
+
+	return edit, done
+}
+
+func waitLoop(t *testing.T, done chan struct{}) {
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("MainLoop did not finish")
+	}
+}
+
+func TestEventScriptRecordAndPlay(t *testing.T) {
+	var buf bytes.Buffer
+
+	scr := NewHeadlessScreen(30, 5)
+	_, done := runEditSession(scr, "xyz")
+	StartRecording(&buf)
+	for _, r := range "xyz" {
+		scr.InjectRune(r)
+	}
+	waitLoop(t, done)
+	if err := StopRecording(); err != nil {
+		t.Fatalf("Recording failed: %v", err)
+	}
+	DeinitLibrary()
+
+	script, err := LoadEventScript(&buf)
+	if err != nil {
+		t.Fatalf("Failed to load recorded script: %v", err)
+	}
+	keys := 0
+	quit := false
+	for _, se := range script {
+		if se.Event.Type == EventKey && se.Source == SourceScreen {
+			keys++
+		}
+		if se.Event.Type == EventQuit && se.Source == SourcePost {
+			quit = true
+		}
+	}
+	if keys != 3 || !quit {
+		t.Fatalf("Script must contain 3 keys and quit event:\n%v", buf.String())
+	}
+
+	var replayed bytes.Buffer
+	edit, done := runEditSession(NewHeadlessScreen(30, 5), "xyz")
+	StartRecording(&replayed)
+	PlayEventScript(script, PlayFast, false).Wait()
+	waitLoop(t, done)
+	StopRecording()
+	DeinitLibrary()
+
+	if edit.Title() != "xyz" {
+		t.Errorf("Replayed text must be %v instead of %v", "xyz", edit.Title())
+	}
+	again, _ := LoadEventScript(&replayed)
+	keys = 0
+	for _, se := range again {
+		if se.Event.Type == EventKey && se.Source == SourceScreen {
+			keys++
+		}
+	}
+	if keys != 3 {
+		t.Errorf("Replayed keys must keep their source:\n%v", replayed.String())
+	}
+}
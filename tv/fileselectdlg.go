@@ -167,6 +167,75 @@ func (d *FileSelectDialog) pathDown(dir string) {
 	d.selectFirst()
 }
 
+// Goes to the directory of the pasted path. If the path is a file or it
+// does not exist, the name is put to the EditField. Returns false if the
+// text is not a path to an existing directory or to a file in it
+func (d *FileSelectDialog) openPath(text string) bool {
+	text = strings.TrimSpace(text)
+	if !strings.ContainsRune(text, os.PathSeparator) || strings.ContainsRune(text, '\n') {
+		return false
+	}
+
+	p := text
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(d.currPath, p)
+	}
+	p = filepath.Clean(p)
+
+	dir, name := p, ""
+	if info, err := os.Stat(p); err != nil || !info.IsDir() {
+		dir, name = filepath.Dir(p), filepath.Base(p)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return false
+	}
+
+	d.currPath = dir
+	d.curDir.SetTitle(dir)
+	_ = d.populateFiles()
+	d.selectFirst()
+	d.edFile.SetTitle(name)
+	return true
+}
+
+// Returns the paths that start with the prefix. A relative prefix is
+// completed in the current directory. Directories end with path separator,
+// files which names do not match mask are filtered out
+func (d *FileSelectDialog) completePath(prefix string) []string {
+	dir, base := filepath.Split(prefix)
+	full := dir
+	if !filepath.IsAbs(full) {
+		full = filepath.Join(d.currPath, full)
+	}
+
+	f, err := os.Open(full)
+	if err != nil {
+		return nil
+	}
+	finfos, err := f.Readdir(0)
+	f.Close()
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	lowBase := strings.ToLower(base)
+	for _, finfo := range finfos {
+		if !strings.HasPrefix(strings.ToLower(finfo.Name()), lowBase) || !d.fileFitsMask(finfo) {
+			continue
+		}
+		p := dir + finfo.Name()
+		if finfo.IsDir() {
+			p += string(os.PathSeparator)
+		}
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return strings.ToLower(paths[i]) < strings.ToLower(paths[j])
+	})
+	return paths
+}
+
 // Sets the EditField value with the selected item in ListBox if:
 //   * a directory is selected and option 'select directory' is set
 //   * a file is selected and option 'select directory' is not set
@@ -196,7 +265,7 @@ func (d *FileSelectDialog) updateEditBox() {
 //       for file 'file save' case)
 func CreateFileSelectDialog(title, fileMasks, initPath string, selectDir, mustExist bool) *FileSelectDialog {
 	dlg := new(FileSelectDialog)
-	cw, ch := term.Size()
+	cw, ch := ScreenSize()
 	dlg.selectDir = selectDir
 	dlg.mustExist = mustExist
 
@@ -379,6 +448,12 @@ func CreateFileSelectDialog(title, fileMasks, initPath string, selectDir, mustEx
 		return false
 	})
 
+	dlg.edFile.SetCompleter(dlg.completePath, false)
+
+	dlg.edFile.OnPaste(func(text string) bool {
+		return dlg.openPath(text)
+	})
+
 	dlg.listBox.OnKeyPress(func(key term.Key) bool {
 		if key == term.KeyBackspace || key == term.KeyBackspace2 {
 			dlg.pathUp()
@@ -4,6 +4,7 @@ import (
 	"math"
 
 	xs "github.com/huandu/xstrings"
+	term "github.com/nsf/termbox-go"
 
 	"github.com/prospero78/goTV/tv/autoheight"
 	"github.com/prospero78/goTV/tv/autowidth"
@@ -184,7 +185,58 @@ func (f *Frame) ScrollTo(x types.ACoordX, y types.ACoordY) {
 	f.PlaceChildren()
 }
 
+// scrollBy scrolls the content of a scrollable frame by dy lines: down if
+// dy is positive. The content is not scrolled beyond its first or last
+// line. Returns false if the frame cannot scroll
+func (f *Frame) scrollBy(dy int) bool {
+	x, y := f.pos.Get()
+	_, py := f.Paddings()
+	_, cy, _, ch := f.Clipper()
+
+	bottom := y
+	for _, child := range f.Children() {
+		_, ty := child.Pos().Get()
+		_, th := child.Size()
+		if ty+types.ACoordY(th) > bottom {
+			bottom = ty + types.ACoordY(th)
+		}
+	}
+
+	// the content top cannot go below the clipper top and the content
+	// bottom cannot go above the clipper bottom
+	maxY := cy - py
+	minY := y
+	if hidden := bottom - (cy + types.ACoordY(ch)); hidden > 0 {
+		minY = y - hidden
+	}
+
+	yy := y - types.ACoordY(dy)
+	if yy > maxY {
+		yy = maxY
+	}
+	if yy < minY {
+		yy = minY
+	}
+	if (dy > 0 && yy >= y) || (dy < 0 && yy <= y) {
+		return false
+	}
+
+	f.ScrollTo(x, yy)
+	return true
+}
+
 func (f *Frame) ProcessEvent(ev Event) bool {
+	if ev.Type == EventMouseWheel {
+		if !f.scrollable {
+			return false
+		}
+		dy := mouseWheelLines
+		if ev.Key == term.MouseWheelUp {
+			dy = -dy
+		}
+		return f.scrollBy(dy)
+	}
+
 	if ev.Type != EventActivateChild || (!f.scrollable || ev.Target == nil) {
 		return false
 	}
@@ -0,0 +1,118 @@
+package tv
+
+import (
+	"strings"
+	"unicode"
+
+	term "github.com/nsf/termbox-go"
+)
+
+// hotkeyMark encloses the accelerator letter of a control title: "~S~ave".
+// Double marker "~~" is displayed as a single tilde
+const hotkeyMark = '~'
+
+// parseHotkey removes accelerator markers from the title. It returns the
+// text to display, the rune index of the accelerator inside the text and
+// the accelerator itself (pos is -1 and key is 0 if title has no marker)
+func parseHotkey(title string) (text string, pos int, key rune) {
+	if !strings.ContainsRune(title, hotkeyMark) {
+		return title, -1, 0
+	}
+
+	pos = -1
+	runes := []rune(title)
+	out := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r != hotkeyMark {
+			out = append(out, r)
+			continue
+		}
+		if i+1 < len(runes) && runes[i+1] == hotkeyMark {
+			out = append(out, hotkeyMark)
+			i++
+			continue
+		}
+		if key == 0 && i+2 < len(runes) && runes[i+2] == hotkeyMark && runes[i+1] != hotkeyMark {
+			pos, key = len(out), runes[i+1]
+			out = append(out, key)
+			i += 2
+			continue
+		}
+		out = append(out, r)
+	}
+
+	return string(out), pos, key
+}
+
+// stripHotkey returns the title as it is displayed on the screen
+func stripHotkey(title string) string {
+	text, _, _ := parseHotkey(title)
+	return text
+}
+
+// Hotkey returns the lowercase accelerator letter of the title
+// or 0 if the title does not contain one
+func Hotkey(title string) rune {
+	_, _, key := parseHotkey(title)
+	return unicode.ToLower(key)
+}
+
+// hotkeyTitle replaces the accelerator markers with color tags, so the
+// accelerator is drawn with color and the rest of the title with the
+// current text color
+func hotkeyTitle(title string, color term.Attribute) string {
+	text, pos, key := parseHotkey(title)
+	if key == 0 {
+		return text
+	}
+
+	runes := []rune(text)
+	return string(runes[:pos]) + "<t:" + ColorToString(color) + ">" +
+		string(key) + "<t:>" + string(runes[pos+1:])
+}
+
+// hotkeyControl finds the visible and enabled control of the parent that
+// has the accelerator key in its caption. Only Button, CheckBox, Radio and
+// Label have captions: the title of other controls, e.g. TEditField, is
+// their text. The key is compared as it is typed with QWERTY layout, so
+// Alt+Ч works for "E~x~it" and Alt+X for "~Ч~ас"
+func hotkeyControl(parent IControl, key rune) IControl {
+	key = NormalizeKey(unicode.ToLower(key))
+	fnHotkey := func(c IControl) bool {
+		switch c.(type) {
+		case *Button, *CheckBox, *Radio, *Label:
+		default:
+			return false
+		}
+		return c.Visible() && c.Enabled() && NormalizeKey(Hotkey(c.Title())) == key
+	}
+	return FindFirstControl(parent, fnHotkey)
+}
+
+// processHotkey activates the control of the parent that has the
+// accelerator ev.Ch. Button, CheckBox and Radio are pressed as if
+// a user pressed Space, Label passes the focus to its buddy control
+func processHotkey(parent IControl, ev Event) bool {
+	if ev.Type != EventKey || ev.Ch == 0 || !ev.Alt() {
+		return false
+	}
+
+	ctrl := hotkeyControl(parent, ev.Ch)
+	if ctrl == nil {
+		return false
+	}
+
+	if lbl, ok := ctrl.(*Label); ok {
+		buddy := lbl.Buddy()
+		if buddy == nil || !buddy.Enabled() {
+			return false
+		}
+		ActivateControl(parent, buddy)
+		return true
+	}
+
+	ActivateControl(parent, ctrl)
+	ctrl.ProcessEvent(Event{Type: EventKey, Key: term.KeySpace})
+	return true
+}
@@ -0,0 +1,103 @@
+package tv
+
+import (
+	"testing"
+)
+
+func TestParseHotkey(t *testing.T) {
+	cases := []struct {
+		title string
+		text  string
+		pos   int
+		key   rune
+	}{
+		{"~S~ave", "Save", 0, 'S'},
+		{"Save ~a~s", "Save as", 5, 'a'},
+		{"~О~ткрыть", "Открыть", 0, 'О'},
+		{"No hotkey", "No hotkey", -1, 0},
+		{"~/home", "~/home", -1, 0},
+		{"Home ~~ ~H~ere ~X~", "Home ~ Here ~X~", 7, 'H'},
+	}
+
+	for _, c := range cases {
+		text, pos, key := parseHotkey(c.title)
+		if text != c.text || pos != c.pos || key != c.key {
+			t.Errorf("'%v' parsed as '%v' %v %q", c.title, text, pos, key)
+		}
+	}
+
+	if Hotkey("E~x~it") != 'x' || Hotkey("~Q~uit") != 'q' {
+		t.Errorf("Hotkey must be lowercase")
+	}
+	if hotkeyTitle("E~x~it", ColorYellow) != "E<t:yellow>x<t:>it" {
+		t.Errorf("Wrong colorized title: %v", hotkeyTitle("E~x~it", ColorYellow))
+	}
+}
+
+func TestHotkeyActivates(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(60, 20))
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 0, 40, 12, "Hotkeys", false, false)
+	wnd.SetPack(Vertical)
+	lbl := CreateLabel(wnd, 0, 0, "~N~ame", Fixed)
+	if w, _ := lbl.Constraints(); w != 4 {
+		t.Errorf("Markers must not be counted in the width: %v", w)
+	}
+	edit := CreateEditField(wnd, 10, "", Fixed)
+	lbl.SetBuddy(edit)
+	check := CreateCheckBox(wnd, AutoSize, "~B~old", Fixed, true)
+	btn := CreateButton(wnd, AutoSize, AutoSize, "~O~K", Fixed, true, true)
+	clicked := 0
+	btn.OnClick(func(ev Event) {
+		clicked++
+	})
+	wnd.ResizeChildren()
+	wnd.PlaceChildren()
+
+	alt := func(ch rune) {
+		ProcessEvent(Event{Type: EventKey, Ch: ch, Mod: ModAlt})
+	}
+
+	alt('b')
+	if check.State() != 1 || !check.Active() {
+		t.Errorf("Alt+B must toggle and activate CheckBox: %v", check.State())
+	}
+
+	alt('n')
+	if !edit.Active() || check.Active() {
+		t.Errorf("Alt+N must move the focus to the Label buddy")
+	}
+	if edit.Title() != "" {
+		t.Errorf("Hotkey must not be typed into the buddy: '%v'", edit.Title())
+	}
+
+	alt('O')
+	if clicked != 1 || !btn.Active() {
+		t.Errorf("Alt+O must click the Button: %v", clicked)
+	}
+
+	alt('и')
+	if check.State() != 0 {
+		t.Errorf("Alt+И must work as Alt+B")
+	}
+
+	alt('т')
+	ProcessEvent(Event{Type: EventKey, Ch: 'т'})
+	if edit.Title() != "т" {
+		t.Errorf("Text must keep the typed characters: '%v'", edit.Title())
+	}
+
+	edit.SetTitle("~x~")
+	ActivateControl(wnd, btn)
+	alt('x')
+	if edit.Active() || edit.Title() != "~x~" {
+		t.Errorf("Text of EditField is not a caption: '%v'", edit.Title())
+	}
+
+	check.SetEnabled(false)
+	alt('b')
+	if check.State() != 0 {
+		t.Errorf("Disabled control must ignore its hotkey")
+	}
+}
@@ -0,0 +1,379 @@
+package tv
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	term "github.com/nsf/termbox-go"
+
+	"github.com/prospero78/goTV/tv/types"
+)
+
+// csiKeys maps final bytes of CSI and SS3 sequences to keys
+var csiKeys = map[byte]term.Key{
+	'A': term.KeyArrowUp,
+	'B': term.KeyArrowDown,
+	'C': term.KeyArrowRight,
+	'D': term.KeyArrowLeft,
+	'H': term.KeyHome,
+	'F': term.KeyEnd,
+	'P': term.KeyF1,
+	'Q': term.KeyF2,
+	'R': term.KeyF3,
+	'S': term.KeyF4,
+}
+
+// tildeKeys maps numbers of "ESC [ number ~" sequences to keys
+var tildeKeys = map[int]term.Key{
+	1:  term.KeyHome,
+	2:  term.KeyInsert,
+	3:  term.KeyDelete,
+	4:  term.KeyEnd,
+	5:  term.KeyPgup,
+	6:  term.KeyPgdn,
+	7:  term.KeyHome,
+	8:  term.KeyEnd,
+	11: term.KeyF1,
+	12: term.KeyF2,
+	13: term.KeyF3,
+	14: term.KeyF4,
+	15: term.KeyF5,
+	17: term.KeyF6,
+	18: term.KeyF7,
+	19: term.KeyF8,
+	20: term.KeyF9,
+	21: term.KeyF10,
+	23: term.KeyF11,
+	24: term.KeyF12,
+}
+
+// maxSequenceLen is the length after which an unfinished escape sequence
+// is treated as garbage
+const maxSequenceLen = 32
+
+/*
+parseInput converts terminal input to events. It returns all complete
+events and the tail of data that holds an incomplete sequence: a part of
+a multibyte character or of an escape sequence. The tail must be passed
+back with the next portion of input.
+
+Control characters and space are reported as Key, other characters as Ch.
+A single ESC is KeyEsc, ESC followed by a key in the same portion of input
+is the key with ModAlt (terminals send it for Alt+key). Modifiers of xterm
+sequences ("ESC [ 1 ; 5 C" is Ctrl+Right) and of modifyOtherKeys and
+CSI u encodings are decoded to ModCtrl, ModShift and ModAlt. Mouse events
+are parsed from both SGR (1006) and X10 encodings with modifiers. Text
+pasted in bracketed paste mode is one EventPaste.
+*/
+func parseInput(data []byte) ([]Event, []byte) {
+	var events []Event
+
+	for len(data) > 0 {
+		ev, n, ok := parseEvent(data)
+		if n == 0 {
+			break
+		}
+		if ok {
+			events = append(events, ev)
+		}
+		data = data[n:]
+	}
+
+	return events, data
+}
+
+// parseEvent parses the first event of data. It returns the number of
+// bytes used and whether the event is valid. n is 0 if data is incomplete
+func parseEvent(data []byte) (ev Event, n int, ok bool) {
+	b := data[0]
+	switch {
+	case b == 0x1b:
+		return parseEscape(data)
+	case b <= 0x20 || b == 0x7f:
+		return Event{Type: EventKey, Key: term.Key(b)}, 1, true
+	}
+
+	if !utf8.FullRune(data) {
+		return Event{}, 0, false
+	}
+	r, size := utf8.DecodeRune(data)
+	if r == utf8.RuneError {
+		return Event{}, size, false
+	}
+	return Event{Type: EventKey, Ch: r}, size, true
+}
+
+func parseEscape(data []byte) (ev Event, n int, ok bool) {
+	escKey := Event{Type: EventKey, Key: term.KeyEsc}
+	if len(data) == 1 {
+		return escKey, 1, true
+	}
+
+	switch data[1] {
+	case 'O':
+		if len(data) < 3 {
+			return Event{}, 0, false
+		}
+		if key, ok := csiKeys[data[2]]; ok {
+			return Event{Type: EventKey, Key: key}, 3, true
+		}
+		return Event{}, 3, false
+	case '[':
+	case 0x1b:
+		// ESC ESC [ ... is Alt with a key sequence in some terminals
+		if len(data) < 3 {
+			return escKey, 1, true
+		}
+		if data[2] != '[' && data[2] != 'O' {
+			return escKey, 1, true
+		}
+		ev, n, ok = parseEscape(data[1:])
+		if n == 0 {
+			return ev, 0, ok
+		}
+		ev.Mod |= ModAlt
+		return ev, n + 1, ok
+	default:
+		ev, n, ok = parseEvent(data[1:])
+		if n == 0 {
+			return ev, 0, ok
+		}
+		if ev.Type == EventKey {
+			ev.Mod |= ModAlt
+		}
+		return ev, n + 1, ok
+	}
+
+	if len(data) >= 3 && data[2] == 'M' {
+		// X10 mouse: ESC [ M button x y
+		if len(data) < 6 {
+			return Event{}, 0, false
+		}
+		btn := int(data[3]) - 32
+		x, y := int(data[4])-33, int(data[5])-33
+		return mouseEvent(btn, x, y, btn&3 == 3), 6, true
+	}
+
+	if len(data) >= 3 && data[2] == '[' {
+		// linux console function keys: ESC [ [ A
+		if len(data) < 4 {
+			return Event{}, 0, false
+		}
+		if data[3] >= 'A' && data[3] <= 'E' {
+			return Event{Type: EventKey, Key: term.KeyF1 - term.Key(data[3]-'A')}, 4, true
+		}
+		return Event{}, 4, false
+	}
+
+	// CSI parameter and intermediate bytes are followed by a final byte
+	end := 2
+	for end < len(data) && data[end] >= 0x20 && data[end] < 0x40 {
+		end++
+	}
+	if end == len(data) {
+		if len(data) > maxSequenceLen {
+			return escKey, 1, true
+		}
+		return Event{}, 0, false
+	}
+	if data[end] < 0x40 || data[end] > 0x7e {
+		// not a sequence: it is Alt+[ followed by other keys
+		return Event{Type: EventKey, Ch: '[', Mod: ModAlt}, 2, true
+	}
+
+	params := string(data[2:end])
+	final := data[end]
+	n = end + 1
+
+	if strings.HasPrefix(params, "<") && (final == 'M' || final == 'm') {
+		// SGR mouse: ESC [ < button ; x ; y M (press) or m (release)
+		nums := splitParams(params[1:])
+		if len(nums) != 3 {
+			return Event{}, n, false
+		}
+		return mouseEvent(nums[0], nums[1]-1, nums[2]-1, final == 'm'), n, true
+	}
+
+	if final == '~' && params == "200" {
+		return parsePaste(data, n)
+	}
+
+	nums := splitParams(params)
+	switch {
+	case final == 'M' && len(nums) == 3:
+		// urxvt mouse: ESC [ button ; x ; y M
+		btn := nums[0] - 32
+		return mouseEvent(btn, nums[1]-1, nums[2]-1, btn&3 == 3), n, true
+	case final == '~' && len(nums) == 3 && nums[0] == 27:
+		// modifyOtherKeys: ESC [ 27 ; modifier ; code ~
+		return codeEvent(nums[2], xtermModifier(nums[1])), n, true
+	case final == 'u' && len(nums) >= 1:
+		// CSI u: ESC [ code ; modifier u
+		return codeEvent(nums[0], xtermModifier(param(nums, 1))), n, true
+	case final == 'Z':
+		// back tab
+		return Event{Type: EventKey, Key: term.KeyTab, Mod: ModShift}, n, true
+	}
+
+	key, known := csiKeys[final]
+	if final == '~' {
+		key, known = tildeKeys[param(nums, 0)]
+	}
+	if !known {
+		return Event{}, n, false
+	}
+	return Event{Type: EventKey, Key: key, Mod: xtermModifier(param(nums, 1))}, n, true
+}
+
+// pasteStart and pasteEnd surround the text pasted in bracketed paste
+// mode, pasteOn and pasteOff turn the mode on and off
+const (
+	pasteOn    = "\x1b[?2004h"
+	pasteOff   = "\x1b[?2004l"
+	hoverOn    = "\x1b[?1003h"
+	hoverOff   = "\x1b[?1003l"
+	pasteStart = "\x1b[200~"
+	pasteEnd   = "\x1b[201~"
+)
+
+// parsePaste makes EventPaste from the text that starts at data[start]
+// and ends with pasteEnd. The event is incomplete until pasteEnd arrives
+func parsePaste(data []byte, start int) (ev Event, n int, ok bool) {
+	idx := bytes.Index(data[start:], []byte(pasteEnd))
+	if idx == -1 {
+		return Event{}, 0, false
+	}
+
+	text := string(data[start : start+idx])
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	return Event{Type: EventPaste, Msg: text}, start + idx + len(pasteEnd), true
+}
+
+// param returns the numeric parameter or 0 if it is omitted
+func param(nums []int, idx int) int {
+	if idx < len(nums) {
+		return nums[idx]
+	}
+	return 0
+}
+
+// xtermModifier converts the modifier parameter of xterm sequences (1 +
+// bit mask of Shift, Alt, Ctrl and Meta) to key modifiers. Meta is
+// reported as Alt
+func xtermModifier(param int) term.Modifier {
+	if param <= 1 {
+		return 0
+	}
+
+	var mod term.Modifier
+	bits := param - 1
+	if bits&1 != 0 {
+		mod |= ModShift
+	}
+	if bits&(2|8) != 0 {
+		mod |= ModAlt
+	}
+	if bits&4 != 0 {
+		mod |= ModCtrl
+	}
+	return mod
+}
+
+// codeEvent converts a character code with modifiers from modifyOtherKeys
+// and CSI u sequences to the event the same key produces without them:
+// Ctrl+letter is a control key, Shift+letter is a capital letter
+func codeEvent(code int, mod term.Modifier) Event {
+	ev := Event{Type: EventKey, Mod: mod}
+	ch := rune(code)
+
+	switch {
+	case code < int(term.KeySpace) || code == int(term.KeyBackspace2):
+		ev.Key = term.Key(code)
+		return ev
+	case ch == ' ' && mod&ModCtrl != 0:
+		ev.Key = term.KeyCtrlSpace
+		ev.Mod &^= ModCtrl
+		return ev
+	case ch == ' ':
+		ev.Key = term.KeySpace
+		return ev
+	}
+
+	if mod&ModCtrl != 0 {
+		if key, ok := ctrlKey(ch); ok {
+			ev.Key = key
+			ev.Mod &^= ModCtrl
+			if ch >= 'A' && ch <= 'Z' {
+				ev.Mod |= ModShift
+			}
+			return ev
+		}
+	}
+
+	if mod&ModShift != 0 && unicode.IsLetter(ch) {
+		ch = unicode.ToUpper(ch)
+	}
+	if unicode.IsPrint(ch) {
+		// the character already includes Shift
+		ev.Mod &^= ModShift
+	}
+	ev.Ch = ch
+	return ev
+}
+
+// splitParams parses numeric parameters of a CSI sequence
+func splitParams(params string) []int {
+	var nums []int
+	for _, p := range strings.Split(params, ";") {
+		num, err := strconv.Atoi(p)
+		if err != nil {
+			num = 0
+		}
+		nums = append(nums, num)
+	}
+	return nums
+}
+
+// mouseEvent converts xterm mouse button code to termbox-like event
+func mouseEvent(btn, x, y int, release bool) Event {
+	ev := Event{Type: EventMouse, X: types.ACoordX(x), Y: types.ACoordY(y)}
+
+	switch {
+	case btn&64 != 0:
+		ev.Key = term.MouseWheelUp
+		if btn&1 != 0 {
+			ev.Key = term.MouseWheelDown
+		}
+	case release:
+		ev.Key = term.MouseRelease
+	default:
+		switch btn & 3 {
+		case 0:
+			ev.Key = term.MouseLeft
+		case 1:
+			ev.Key = term.MouseMiddle
+		case 2:
+			ev.Key = term.MouseRight
+		default:
+			ev.Key = term.MouseRelease
+		}
+	}
+	if btn&32 != 0 {
+		ev.Mod |= term.ModMotion
+	}
+	if btn&4 != 0 {
+		ev.Mod |= ModShift
+	}
+	if btn&8 != 0 {
+		ev.Mod |= ModAlt
+	}
+	if btn&16 != 0 {
+		ev.Mod |= ModCtrl
+	}
+
+	return ev
+}
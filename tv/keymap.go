@@ -0,0 +1,662 @@
+package tv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	term "github.com/nsf/termbox-go"
+)
+
+/*
+Keymap maps key sequences to named commands. A sequence is one key press
+(e.g. "alt+pgdn") or a chord of a few key presses (e.g. "ctrl+w ctrl+c").
+While a user types a chord the keys are not sent to windows. If the next
+key does not continue any chord, the typed keys are sent to the active
+window as usual. Esc cancels the chord.
+
+Every binding has a scope: global bindings (Scope is nil) work always,
+window and control bindings work only while the window or the control is
+active. A binding of a narrower scope overrides the same key sequence of
+a wider one: control bindings win over window ones and window bindings win
+over global ones. Bindings of a window and its controls are removed when
+the window is destroyed.
+
+A binding can be sticky: after the command is executed the keymap stays
+in the chord, so the user can repeat the last key as many times as needed
+without typing the chord prefix again, e.g. "ctrl+p up up up" moves the
+window three times. Any other key ends sticky mode.
+
+The composer keymap is created with DefaultKeymap and it can be changed at
+any time, loaded from a file or replaced:
+
+	km := tv.WindowManager().Keymap()
+	km.Unbind("ctrl+q ctrl+q")
+	km.Bind("ctrl+x ctrl+c", tv.CmdQuit)
+	km.BindScope(wnd, "ctrl+s", "Save")
+	tv.WindowManager().HandleCommand("Save", func(ev tv.Event) {
+		...
+	})
+*/
+type Keymap struct {
+	bindings []*KeyBinding
+	// keys of an unfinished chord
+	pending []KeyStroke
+	// sticky is true if pending is a prefix of a sticky binding that has
+	// just been executed
+	sticky bool
+}
+
+// KeyBinding binds a key sequence to a command
+type KeyBinding struct {
+	Keys    []KeyStroke
+	Command string
+	// Scope is a window or a control the binding works for. nil means
+	// the binding is global
+	Scope IControl
+	// Sticky makes the keymap stay in the chord after the command is
+	// executed, so the last key can be repeated
+	Sticky bool
+}
+
+// KeyStroke is one key press of a key sequence. For printable characters
+// Key is 0 and Ch is the character
+type KeyStroke struct {
+	Key term.Key
+	Ch  rune
+	Mod term.Modifier
+}
+
+// Commands of the default keymap
+const (
+	// CmdQuit closes the application
+	CmdQuit = "Quit"
+	// CmdWindowToBottom moves the active window to the bottom of window stack
+	CmdWindowToBottom = "WindowToBottom"
+	// CmdWindowMaximize maximizes or restores the active window
+	CmdWindowMaximize = "WindowMaximize"
+	// CmdWindowClose closes the active window
+	CmdWindowClose = "WindowClose"
+	// CmdWindowMoveUp and other move commands change the active window position
+	CmdWindowMoveUp    = "WindowMoveUp"
+	CmdWindowMoveDown  = "WindowMoveDown"
+	CmdWindowMoveLeft  = "WindowMoveLeft"
+	CmdWindowMoveRight = "WindowMoveRight"
+	// CmdWindowSizeUp and other size commands change the active window
+	// size: Up and Left make the window smaller, Down and Right make it
+	// bigger
+	CmdWindowSizeUp    = "WindowSizeUp"
+	CmdWindowSizeDown  = "WindowSizeDown"
+	CmdWindowSizeLeft  = "WindowSizeLeft"
+	CmdWindowSizeRight = "WindowSizeRight"
+	// CmdMenu opens or closes the menu bar
+	CmdMenu = "Menu"
+)
+
+var keyNames = map[string]term.Key{
+	"f1":        term.KeyF1,
+	"f2":        term.KeyF2,
+	"f3":        term.KeyF3,
+	"f4":        term.KeyF4,
+	"f5":        term.KeyF5,
+	"f6":        term.KeyF6,
+	"f7":        term.KeyF7,
+	"f8":        term.KeyF8,
+	"f9":        term.KeyF9,
+	"f10":       term.KeyF10,
+	"f11":       term.KeyF11,
+	"f12":       term.KeyF12,
+	"insert":    term.KeyInsert,
+	"delete":    term.KeyDelete,
+	"home":      term.KeyHome,
+	"end":       term.KeyEnd,
+	"pgup":      term.KeyPgup,
+	"pgdn":      term.KeyPgdn,
+	"up":        term.KeyArrowUp,
+	"down":      term.KeyArrowDown,
+	"left":      term.KeyArrowLeft,
+	"right":     term.KeyArrowRight,
+	"esc":       term.KeyEsc,
+	"enter":     term.KeyEnter,
+	"tab":       term.KeyTab,
+	"space":     term.KeySpace,
+	"backspace": term.KeyBackspace2,
+}
+
+// keyNameOrder is used to get a name of a key: a few names above map to
+// the same key
+var keyNameOrder = []string{"f1", "f2", "f3", "f4", "f5", "f6", "f7", "f8",
+	"f9", "f10", "f11", "f12", "insert", "delete", "home", "end", "pgup",
+	"pgdn", "up", "down", "left", "right", "esc", "enter", "tab", "space",
+	"backspace"}
+
+// ParseKeys converts a text description of a key sequence to key strokes.
+// Key presses are separated with spaces. A key press is a key name with
+// optional ctrl, alt and shift modifiers: "ctrl+w", "alt+x", "f2",
+// "ctrl+shift+left", "a". Ctrl with a letter or a few symbols produces the
+// same control character as the terminal does, shift with a letter is the
+// capital letter
+func ParseKeys(keys string) ([]KeyStroke, error) {
+	var strokes []KeyStroke
+	for _, part := range strings.Fields(keys) {
+		ks, err := parseKeyStroke(part)
+		if err != nil {
+			return nil, err
+		}
+		strokes = append(strokes, ks)
+	}
+
+	if len(strokes) == 0 {
+		return nil, fmt.Errorf("empty key sequence")
+	}
+	return strokes, nil
+}
+
+func parseKeyStroke(text string) (KeyStroke, error) {
+	var ks KeyStroke
+	ctrl := false
+
+	name := text
+	for {
+		idx := strings.Index(name, "+")
+		if idx <= 0 || idx == len(name)-1 {
+			break
+		}
+		switch strings.ToLower(name[:idx]) {
+		case "ctrl":
+			ctrl = true
+		case "alt":
+			ks.Mod |= ModAlt
+		case "shift":
+			ks.Mod |= ModShift
+		default:
+			return ks, fmt.Errorf("unknown modifier in '%v'", text)
+		}
+		name = name[idx+1:]
+	}
+
+	if key, ok := keyNames[strings.ToLower(name)]; ok {
+		ks.Key = key
+		if ctrl {
+			// terminals send control characters for these keys
+			switch key {
+			case term.KeySpace:
+				ks.Key = term.KeyCtrlSpace
+			case term.KeyBackspace2:
+				ks.Key = term.KeyBackspace
+			default:
+				ks.Mod |= ModCtrl
+			}
+		}
+		return ks, nil
+	}
+
+	if utf8.RuneCountInString(name) != 1 {
+		return ks, fmt.Errorf("unknown key '%v'", text)
+	}
+
+	ch, _ := utf8.DecodeRuneInString(name)
+	if !ctrl {
+		if ks.Mod&ModShift != 0 {
+			if !unicode.IsLetter(ch) {
+				return ks, fmt.Errorf("shift works only with letters in '%v'", text)
+			}
+			ch = unicode.ToUpper(ch)
+			ks.Mod &^= ModShift
+		}
+		ks.Ch = ch
+		return ks, nil
+	}
+
+	key, ok := ctrlKey(ch)
+	if !ok {
+		return ks, fmt.Errorf("key '%v' cannot be used with ctrl", text)
+	}
+	ks.Key = key
+	return ks, nil
+}
+
+// ctrlKey returns the control character a terminal sends for Ctrl+ch
+func ctrlKey(ch rune) (term.Key, bool) {
+	switch {
+	case ch >= 'a' && ch <= 'z':
+		return term.Key(ch - 'a' + 1), true
+	case ch >= 'A' && ch <= 'Z':
+		return term.Key(ch - 'A' + 1), true
+	case ch == '2' || ch == '~' || ch == '@':
+		return term.KeyCtrl2, true
+	case ch == '[' || ch == '3':
+		return term.KeyCtrl3, true
+	case ch == '\\' || ch == '4':
+		return term.KeyCtrl4, true
+	case ch == ']' || ch == '5':
+		return term.KeyCtrl5, true
+	case ch == '6' || ch == '^':
+		return term.KeyCtrl6, true
+	case ch == '/' || ch == '_' || ch == '7':
+		return term.KeyCtrl7, true
+	case ch == '8':
+		return term.KeyBackspace2, true
+	}
+	return 0, false
+}
+
+// isCtrlKey returns true if the key is a control character that is not
+// a key of its own like Tab, Enter or Esc
+func isCtrlKey(key term.Key) bool {
+	return key < term.KeySpace && key != term.KeyTab &&
+		key != term.KeyEnter && key != term.KeyEsc
+}
+
+// KeyStrokeFromEvent returns the key stroke of a key event
+func KeyStrokeFromEvent(ev Event) KeyStroke {
+	ks := KeyStroke{Key: ev.Key, Ch: ev.Ch, Mod: ev.Mod & modKeys}
+	if ks.Ch != 0 {
+		ks.Key = 0
+	}
+	return ks
+}
+
+// Is returns true if the event is the key press described as in
+// ParseKeys, e.g. ev.Is("ctrl+shift+left")
+func (ev Event) Is(key string) bool {
+	if ev.Type != EventKey {
+		return false
+	}
+	ks, err := parseKeyStroke(key)
+	return err == nil && KeyStrokeFromEvent(ev).normalized() == ks.normalized()
+}
+
+// Ctrl returns true if the key was pressed with Ctrl: it has ModCtrl or
+// it is a control character like KeyCtrlA
+func (ev Event) Ctrl() bool {
+	return ev.Mod&ModCtrl != 0 || (ev.Type == EventKey && ev.Ch == 0 && isCtrlKey(ev.Key))
+}
+
+// Shift returns true if the key or the mouse button was pressed with Shift.
+// Capital letters do not have the modifier
+func (ev Event) Shift() bool {
+	return ev.Mod&ModShift != 0
+}
+
+// Alt returns true if the key or the mouse button was pressed with Alt
+func (ev Event) Alt() bool {
+	return ev.Mod&ModAlt != 0
+}
+
+// Event returns a key event for the key stroke
+func (ks KeyStroke) Event() Event {
+	return Event{Type: EventKey, Key: ks.Key, Ch: ks.Ch, Mod: ks.Mod}
+}
+
+// String returns the text description of the key stroke that ParseKeys
+// understands
+func (ks KeyStroke) String() string {
+	var name string
+	ctrl := ks.Mod&ModCtrl != 0
+	switch {
+	case ks.Ch != 0:
+		name = string(ks.Ch)
+	case ks.Key == term.KeyCtrlSpace:
+		name, ctrl = "space", true
+	case ks.Key >= term.KeyCtrlA && ks.Key <= term.KeyCtrlZ && isCtrlKey(ks.Key):
+		name, ctrl = string(rune('a'+ks.Key-term.KeyCtrlA)), true
+	default:
+		for _, n := range keyNameOrder {
+			if keyNames[n] == ks.Key {
+				name = n
+				break
+			}
+		}
+		if name == "" {
+			name = fmt.Sprintf("key%d", ks.Key)
+		}
+	}
+
+	prefix := ""
+	if ctrl {
+		prefix += "ctrl+"
+	}
+	if ks.Mod&ModAlt != 0 {
+		prefix += "alt+"
+	}
+	if ks.Mod&ModShift != 0 {
+		prefix += "shift+"
+	}
+	return prefix + name
+}
+
+// KeysString returns the text description of the key sequence
+func KeysString(keys []KeyStroke) string {
+	names := make([]string, len(keys))
+	for i, ks := range keys {
+		names[i] = ks.String()
+	}
+	return strings.Join(names, " ")
+}
+
+// NewKeymap creates an empty keymap
+func NewKeymap() *Keymap {
+	return new(Keymap)
+}
+
+// DefaultKeymap creates a keymap with the built-in library bindings. See
+// docs/hotkeys.md for the list
+func DefaultKeymap() *Keymap {
+	km := NewKeymap()
+
+	km.mustBind("ctrl+q ctrl+q", CmdQuit, false)
+	km.mustBind("ctrl+w ctrl+h", CmdWindowToBottom, false)
+	km.mustBind("ctrl+w ctrl+m", CmdWindowMaximize, false)
+	km.mustBind("ctrl+w ctrl+c", CmdWindowClose, false)
+	km.mustBind("ctrl+p up", CmdWindowMoveUp, true)
+	km.mustBind("ctrl+p down", CmdWindowMoveDown, true)
+	km.mustBind("ctrl+p left", CmdWindowMoveLeft, true)
+	km.mustBind("ctrl+p right", CmdWindowMoveRight, true)
+	km.mustBind("ctrl+s up", CmdWindowSizeUp, true)
+	km.mustBind("ctrl+s down", CmdWindowSizeDown, true)
+	km.mustBind("ctrl+s left", CmdWindowSizeLeft, true)
+	km.mustBind("ctrl+s right", CmdWindowSizeRight, true)
+	km.mustBind("f10", CmdMenu, false)
+
+	return km
+}
+
+func (km *Keymap) mustBind(keys, command string, sticky bool) {
+	strokes, err := ParseKeys(keys)
+	if err != nil {
+		panic(err)
+	}
+	km.add(&KeyBinding{Keys: strokes, Command: command, Sticky: sticky})
+}
+
+// add appends the binding. A binding of the same scope and key sequence
+// is replaced
+func (km *Keymap) add(b *KeyBinding) {
+	km.remove(b.Scope, b.Keys)
+	km.bindings = append(km.bindings, b)
+	km.reset()
+}
+
+func (km *Keymap) remove(scope IControl, keys []KeyStroke) bool {
+	for i, b := range km.bindings {
+		if b.Scope == scope && sameKeys(b.Keys, keys) {
+			km.bindings = append(km.bindings[:i], km.bindings[i+1:]...)
+			km.reset()
+			return true
+		}
+	}
+	return false
+}
+
+// Bind binds the global key sequence to the command. An old binding of
+// the same sequence is replaced
+func (km *Keymap) Bind(keys, command string) error {
+	return km.BindScope(nil, keys, command)
+}
+
+// BindSticky binds the global key sequence to the command and makes the
+// binding sticky
+func (km *Keymap) BindSticky(keys, command string) error {
+	strokes, err := ParseKeys(keys)
+	if err != nil {
+		return err
+	}
+	km.add(&KeyBinding{Keys: strokes, Command: command, Sticky: true})
+	return nil
+}
+
+// BindScope binds the key sequence to the command for a window or a
+// control. The binding works only while the scope is active
+func (km *Keymap) BindScope(scope IControl, keys, command string) error {
+	strokes, err := ParseKeys(keys)
+	if err != nil {
+		return err
+	}
+	km.add(&KeyBinding{Keys: strokes, Command: command, Scope: scope})
+	return nil
+}
+
+// Unbind removes the global binding of the key sequence. Returns false if
+// the sequence is not bound
+func (km *Keymap) Unbind(keys string) bool {
+	return km.UnbindScope(nil, keys)
+}
+
+// UnbindScope removes the binding of the key sequence for a window or a
+// control
+func (km *Keymap) UnbindScope(scope IControl, keys string) bool {
+	strokes, err := ParseKeys(keys)
+	if err != nil {
+		return false
+	}
+	return km.remove(scope, strokes)
+}
+
+// UnbindCommand removes all bindings of the command in all scopes
+func (km *Keymap) UnbindCommand(command string) {
+	var rest []*KeyBinding
+	for _, b := range km.bindings {
+		if b.Command != command {
+			rest = append(rest, b)
+		}
+	}
+	km.bindings = rest
+	km.reset()
+}
+
+// removeScope removes bindings of the window and all its children
+func (km *Keymap) removeScope(wnd IControl) {
+	var rest []*KeyBinding
+	for _, b := range km.bindings {
+		if b.Scope == nil || !ownedBy(b.Scope, wnd) {
+			rest = append(rest, b)
+		}
+	}
+	km.bindings = rest
+	km.reset()
+}
+
+// Bindings returns all bindings in the order they were added
+func (km *Keymap) Bindings() []KeyBinding {
+	res := make([]KeyBinding, len(km.bindings))
+	for i, b := range km.bindings {
+		res[i] = *b
+	}
+	return res
+}
+
+// Keys returns the key sequence of the first global binding of the
+// command or empty string if the command is not bound
+func (km *Keymap) Keys(command string) string {
+	for _, b := range km.bindings {
+		if b.Scope == nil && b.Command == command {
+			return KeysString(b.Keys)
+		}
+	}
+	return ""
+}
+
+/*
+Load reads global bindings from a text file. The format is similar to
+the theme file format: every line is 'keys=command', lines started with
+'#' or '/' are comments. A command can be followed by the word 'sticky'.
+Empty command removes the binding:
+
+	# emacs-like quit
+	ctrl+x ctrl+c = Quit
+	ctrl+q ctrl+q =
+	ctrl+p up = WindowMoveUp sticky
+*/
+func (km *Keymap) Load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "/") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("line %v: '=' expected", lineNo)
+		}
+		keys := strings.TrimSpace(parts[0])
+		value := strings.Fields(parts[1])
+
+		strokes, err := ParseKeys(keys)
+		if err != nil {
+			return fmt.Errorf("line %v: %v", lineNo, err)
+		}
+
+		switch {
+		case len(value) == 0:
+			km.remove(nil, strokes)
+		case len(value) == 1:
+			km.add(&KeyBinding{Keys: strokes, Command: value[0]})
+		case len(value) == 2 && strings.ToLower(value[1]) == "sticky":
+			km.add(&KeyBinding{Keys: strokes, Command: value[0], Sticky: true})
+		default:
+			return fmt.Errorf("line %v: invalid command '%v'", lineNo, strings.TrimSpace(parts[1]))
+		}
+	}
+
+	return scanner.Err()
+}
+
+// LoadFile reads global bindings from a file. See Load for details
+func (km *Keymap) LoadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return km.Load(file)
+}
+
+// IsPrefix returns true if the key starts any global chord
+func (km *Keymap) IsPrefix(key term.Key) bool {
+	for _, b := range km.bindings {
+		if b.Scope == nil && len(b.Keys) > 1 && b.Keys[0].Key == key && b.Keys[0].Ch == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Pending returns true if the user has typed a part of a chord
+func (km *Keymap) Pending() bool {
+	return len(km.pending) > 0
+}
+
+func (km *Keymap) reset() {
+	km.pending = nil
+	km.sticky = false
+}
+
+/*
+feed processes the next key event. scopes are the active controls from
+the deepest one to the active window. It returns the binding to execute
+(or nil) and key events that must be sent to windows as usual: the key
+itself if it is not bound, or the keys of a broken chord.
+*/
+func (km *Keymap) feed(ev Event, scopes []IControl) (*KeyBinding, []Event) {
+	ks := KeyStrokeFromEvent(ev)
+
+	seq := make([]KeyStroke, len(km.pending), len(km.pending)+1)
+	copy(seq, km.pending)
+	seq = append(seq, ks)
+
+	if b := km.match(seq, scopes); b != nil {
+		km.reset()
+		if b.Sticky {
+			km.pending = seq[:len(seq)-1]
+			km.sticky = true
+		}
+		return b, nil
+	}
+
+	if km.hasPrefix(seq, scopes) {
+		km.pending = seq
+		km.sticky = false
+		return nil, nil
+	}
+
+	if len(km.pending) == 0 {
+		return nil, []Event{ev}
+	}
+
+	// the chord is broken: Esc cancels it, other keys are sent to the
+	// window after the chord keys typed before
+	if ks.Key == term.KeyEsc && ks.Ch == 0 {
+		km.reset()
+		return nil, nil
+	}
+
+	var replay []Event
+	if !km.sticky {
+		for _, p := range km.pending {
+			replay = append(replay, p.Event())
+		}
+	}
+	km.reset()
+
+	b, rest := km.feed(ev, scopes)
+	return b, append(replay, rest...)
+}
+
+// scopeRank returns the priority of the binding scope: 0 is the highest.
+// -1 means the binding does not work for the current scopes
+func scopeRank(b *KeyBinding, scopes []IControl) int {
+	if b.Scope == nil {
+		return len(scopes)
+	}
+	for i, s := range scopes {
+		if s == b.Scope {
+			return i
+		}
+	}
+	return -1
+}
+
+func (km *Keymap) match(seq []KeyStroke, scopes []IControl) *KeyBinding {
+	var best *KeyBinding
+	bestRank := -1
+	for _, b := range km.bindings {
+		rank := scopeRank(b, scopes)
+		if rank == -1 || !sameKeys(b.Keys, seq) {
+			continue
+		}
+		if best == nil || rank <= bestRank {
+			best, bestRank = b, rank
+		}
+	}
+	return best
+}
+
+func (km *Keymap) hasPrefix(seq []KeyStroke, scopes []IControl) bool {
+	for _, b := range km.bindings {
+		if len(b.Keys) > len(seq) && scopeRank(b, scopes) != -1 && sameKeys(b.Keys[:len(seq)], seq) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameKeys(a, b []KeyStroke) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].normalized() != b[i].normalized() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,211 @@
+package tv
+
+import (
+	"strings"
+	"testing"
+
+	term "github.com/nsf/termbox-go"
+)
+
+func TestParseKeys(t *testing.T) {
+	cases := []struct {
+		text string
+		keys []KeyStroke
+	}{
+		{"ctrl+w ctrl+c", []KeyStroke{{Key: term.KeyCtrlW}, {Key: term.KeyCtrlC}}},
+		{"Ctrl+P up", []KeyStroke{{Key: term.KeyCtrlP}, {Key: term.KeyArrowUp}}},
+		{"alt+pgdn", []KeyStroke{{Key: term.KeyPgdn, Mod: term.ModAlt}}},
+		{"alt+x f2", []KeyStroke{{Ch: 'x', Mod: term.ModAlt}, {Key: term.KeyF2}}},
+		{"  a   ", []KeyStroke{{Ch: 'a'}}},
+		{"ctrl+shift+left", []KeyStroke{{Key: term.KeyArrowLeft, Mod: ModCtrl | ModShift}}},
+		{"shift+a ctrl+shift+b", []KeyStroke{{Ch: 'A'}, {Key: term.KeyCtrlB, Mod: ModShift}}},
+		{"ctrl+space shift+tab", []KeyStroke{{Key: term.KeyCtrlSpace}, {Key: term.KeyTab, Mod: ModShift}}},
+	}
+
+	for _, c := range cases {
+		keys, err := ParseKeys(c.text)
+		if err != nil {
+			t.Errorf("Failed to parse '%v': %v", c.text, err)
+			continue
+		}
+		if !sameKeys(keys, c.keys) {
+			t.Errorf("'%v' parsed as %v, expected %v", c.text, keys, c.keys)
+		}
+		again, _ := ParseKeys(KeysString(keys))
+		if !sameKeys(again, keys) {
+			t.Errorf("'%v' does not survive String: %v", c.text, KeysString(keys))
+		}
+	}
+
+	for _, text := range []string{"", "ctrl+ä", "meta+x", "abc", "shift+1"} {
+		if _, err := ParseKeys(text); err == nil {
+			t.Errorf("'%v' must be invalid", text)
+		}
+	}
+}
+
+func TestEventIs(t *testing.T) {
+	events, _ := parseInput([]byte("\x1b[1;6D\x01\x1bx\x1b[97;6u"))
+	keys := []string{"ctrl+shift+left", "ctrl+a", "alt+x", "ctrl+shift+a"}
+
+	for i, ev := range events {
+		if !ev.Is(keys[i]) {
+			t.Errorf("Event %+v must be '%v'", ev, keys[i])
+		}
+		if !ev.Ctrl() && i != 2 || ev.Alt() != (i == 2) {
+			t.Errorf("Wrong modifiers of '%v'", keys[i])
+		}
+	}
+	if events[0].Is("ctrl+left") || events[0].Is("shift+left") {
+		t.Errorf("All modifiers must match")
+	}
+}
+
+func TestKeymapLoad(t *testing.T) {
+	km := DefaultKeymap()
+	config := `
+# emacs-like quit
+ctrl+x ctrl+c = Quit
+ctrl+q ctrl+q =
+ctrl+p up = WindowMoveUp sticky
+`
+	if err := km.Load(strings.NewReader(config)); err != nil {
+		t.Fatalf("Failed to load keymap: %v", err)
+	}
+	if km.Keys(CmdQuit) != "ctrl+x ctrl+c" {
+		t.Errorf("Quit must be rebound, got '%v'", km.Keys(CmdQuit))
+	}
+	if km.IsPrefix(term.KeyCtrlQ) {
+		t.Errorf("Removed chord must not be a prefix")
+	}
+
+	err := km.Load(strings.NewReader("ctrl+x = Quit\nctrl+y Quit\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Invalid line must be reported, got %v", err)
+	}
+}
+
+func feedKeys(km *Keymap, keys string, scopes []IControl) (commands []string, delivered []Event) {
+	strokes, _ := ParseKeys(keys)
+	for _, ks := range strokes {
+		b, evs := km.feed(ks.Event(), scopes)
+		if b != nil {
+			commands = append(commands, b.Command)
+		}
+		delivered = append(delivered, evs...)
+	}
+	return commands, delivered
+}
+
+func TestKeymapChords(t *testing.T) {
+	km := DefaultKeymap()
+
+	cmds, evs := feedKeys(km, "ctrl+p up up left a", nil)
+	if strings.Join(cmds, ",") != "WindowMoveUp,WindowMoveUp,WindowMoveLeft" {
+		t.Errorf("Sticky chord failed: %v", cmds)
+	}
+	if len(evs) != 1 || evs[0].Ch != 'a' {
+		t.Errorf("Key after sticky chord must be delivered: %v", evs)
+	}
+
+	cmds, evs = feedKeys(km, "ctrl+w x", nil)
+	if len(cmds) != 0 || len(evs) != 2 || evs[0].Key != term.KeyCtrlW || evs[1].Ch != 'x' {
+		t.Errorf("Broken chord must be replayed: %v %v", cmds, evs)
+	}
+
+	cmds, evs = feedKeys(km, "ctrl+w esc", nil)
+	if len(cmds) != 0 || len(evs) != 0 || km.Pending() {
+		t.Errorf("Esc must cancel the chord: %v %v", cmds, evs)
+	}
+
+	cmds, _ = feedKeys(km, "ctrl+w ctrl+q ctrl+q", nil)
+	if strings.Join(cmds, ",") != CmdQuit {
+		t.Errorf("Key that breaks a chord must start a new one: %v", cmds)
+	}
+}
+
+func TestKeymapScopes(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(40, 10))
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 0, 20, 5, "Scope", false, false)
+	edit := CreateEditField(wnd, 10, "", Fixed)
+	edit.SetActive(true)
+
+	km := comp.Keymap()
+	_ = km.Bind("f2", "Global")
+	_ = km.BindScope(wnd, "f2", "Window")
+	_ = km.BindScope(edit, "f2", "Edit")
+
+	var got []string
+	for _, name := range []string{"Global", "Window", "Edit"} {
+		name := name
+		comp.HandleCommand(name, func(ev Event) {
+			got = append(got, name)
+		})
+	}
+
+	var delivered []Event
+	wnd.OnKeyDown(func(ev Event, _ interface{}) bool {
+		delivered = append(delivered, ev)
+		return true
+	}, nil)
+
+	f2 := Event{Type: EventKey, Key: term.KeyF2}
+	comp.processKey(f2)
+	km.UnbindScope(edit, "f2")
+	comp.processKey(f2)
+	km.UnbindScope(wnd, "f2")
+	comp.processKey(f2)
+	km.Unbind("f2")
+	comp.processKey(f2)
+
+	if strings.Join(got, ",") != "Edit,Window,Global" {
+		t.Errorf("Narrow scope must win: %v", got)
+	}
+	if len(delivered) != 1 || delivered[0].Key != term.KeyF2 {
+		t.Errorf("Unbound key must reach the window: %v", delivered)
+	}
+
+	_ = km.BindScope(edit, "f3", "Edit")
+	comp.DestroyWindow(wnd)
+	for _, b := range km.Bindings() {
+		if b.Scope != nil {
+			t.Errorf("Bindings of destroyed window must be removed: %v", KeysString(b.Keys))
+		}
+	}
+}
+
+func TestKeyLayouts(t *testing.T) {
+	for ch, key := range map[rune]rune{'й': 'q', 'Ё': '~', 'ґ': '\\', 'ω': 'v', 'ў': 'o', 'x': 'x', '1': '1', 'ä': 'ä'} {
+		if NormalizeKey(ch) != key {
+			t.Errorf("'%c' must be normalized to '%c', got '%c'", ch, key, NormalizeKey(ch))
+		}
+	}
+	if RegisterKeyLayout("абв") == nil {
+		t.Errorf("Short layout must be rejected")
+	}
+
+	km := NewKeymap()
+	_ = km.Bind("alt+x", "Exit")
+	_ = km.Bind("ctrl+w x", "Close")
+
+	b, _ := km.feed(Event{Type: EventKey, Ch: 'ч', Mod: ModAlt}, nil)
+	if b == nil || b.Command != "Exit" {
+		t.Errorf("Alt+Ч must work as Alt+X: %v", b)
+	}
+	if !(Event{Type: EventKey, Ch: 'Ч', Mod: ModAlt}).Is("alt+X") {
+		t.Errorf("Is must ignore the layout")
+	}
+
+	cmds, _ := feedKeys(km, "ctrl+w ч", nil)
+	if strings.Join(cmds, ",") != "Close" {
+		t.Errorf("Chord must ignore the layout: %v", cmds)
+	}
+
+	km.feed(Event{Type: EventKey, Key: term.KeyCtrlW}, nil)
+	_, evs := km.feed(Event{Type: EventKey, Ch: 'ф'}, nil)
+	if len(evs) != 2 || evs[1].Ch != 'ф' {
+		t.Errorf("Broken chord must replay the typed characters: %v", evs)
+	}
+}
@@ -14,7 +14,9 @@ or vertical direction. Other available text features are alignment
 and multi-line ability. Text can be single- or multi-colored with
 tags inside the text. Multi-colored strings have limited support
 of alignment feature: if text is longer than Label width the text
-is always left aligned
+is always left aligned.
+Label title can contain an accelerator: "~N~ame". Alt+N moves the
+focus to the Label buddy control
 */
 type Label struct {
 	TBaseControl
@@ -23,6 +25,7 @@ type Label struct {
 	textDisplay Align
 	autoWidth   types.IAutoWidth
 	autoHeight  types.IAutoHeight
+	buddy       IControl
 }
 
 /*
@@ -42,7 +45,7 @@ func CreateLabel(parent IControl, w, h int, title string, scale int) *Label {
 	}
 	if w == 0 {
 		c.autoWidth.Set()
-		w = xs.Len(title)
+		w = xs.Len(stripHotkey(title))
 	}
 	if h == 0 {
 		c.autoHeight.Set()
@@ -96,8 +99,13 @@ func (l *Label) Draw() {
 		return
 	}
 
+	title := stripHotkey(l.title)
+	if l.Enabled() {
+		title = hotkeyTitle(l.title, RealColor(ColorDefault, l.Style(), ColorHotkeyText))
+	}
+
 	if l.multiline {
-		parser := NewColorParser(l.title, fg, bg)
+		parser := NewColorParser(title, fg, bg)
 		elem := parser.NextElement()
 		xx, yy := l.pos.Get()
 		for elem.Type != ElemEndOfText {
@@ -132,15 +140,15 @@ func (l *Label) Draw() {
 		}
 	} else {
 		if l.direction == Horizontal {
-			shift, str := AlignColorizedText(l.title, int(l.width.Get()), l.align)
-			if str != l.title && l.align != l.textDisplay {
-				shift, str = AlignColorizedText(l.title, int(l.width.Get()), l.textDisplay)
+			shift, str := AlignColorizedText(title, int(l.width.Get()), l.align)
+			if str != title && l.align != l.textDisplay {
+				shift, str = AlignColorizedText(title, int(l.width.Get()), l.textDisplay)
 			}
 			DrawText(l.pos.GetX()+types.ACoordX(shift), l.pos.GetY(), str)
 		} else {
-			shift, str := AlignColorizedText(l.title, int(l.height.Get()), l.align)
-			if str != l.title && l.align != l.textDisplay {
-				shift, str = AlignColorizedText(l.title, int(l.width.Get()), l.textDisplay)
+			shift, str := AlignColorizedText(title, int(l.height.Get()), l.align)
+			if str != title && l.align != l.textDisplay {
+				shift, str = AlignColorizedText(title, int(l.width.Get()), l.textDisplay)
 			}
 			DrawTextVertical(l.pos.GetX(), l.pos.GetY()+types.ACoordY(shift), str)
 		}
@@ -180,3 +188,16 @@ func (l *Label) SetTextDisplay(align Align) {
 
 	l.textDisplay = align
 }
+
+// Buddy returns the control that gets the focus when a user
+// presses the Label accelerator
+func (l *Label) Buddy() IControl {
+	return l.buddy
+}
+
+// SetBuddy sets the control that gets the focus when a user
+// presses the Label accelerator. Usually it is an EditField or
+// a ListBox described by the Label
+func (l *Label) SetBuddy(ctrl IControl) {
+	l.buddy = ctrl
+}
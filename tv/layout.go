@@ -0,0 +1,97 @@
+package tv
+
+import (
+	"fmt"
+	"sync"
+)
+
+// qwertyKeys are the runes of US QWERTY keys in the order the keyboard
+// layouts below are described: the top, home and bottom letter rows
+// without and with Shift, and the backslash key
+const qwertyKeys = "`qwertyuiop[]asdfghjkl;'zxcvbnm,." +
+	"~QWERTYUIOP{}ASDFGHJKL:\"ZXCVBNM<>" +
+	"\\|"
+
+// Keyboard layouts that are known by default. The runes are typed by
+// the same physical keys as qwertyKeys. ASCII runes are skipped: they
+// mean the same on every layout
+const (
+	LayoutRussian = "ёйцукенгшщзхъфывапролджэячсмитьбю" +
+		"ЁЙЦУКЕНГШЩЗХЪФЫВАПРОЛДЖЭЯЧСМИТЬБЮ" +
+		"\\/"
+	LayoutUkrainian = "`йцукенгшщзхїфівапролджєячсмитьбю" +
+		"~ЙЦУКЕНГШЩЗХЇФІВАПРОЛДЖЄЯЧСМИТЬБЮ" +
+		"ґҐ"
+	LayoutBelarusian = "ёйцукенгшўзх'фывапролджэячсмітьбю" +
+		"ЁЙЦУКЕНГШЎЗХ'ФЫВАПРОЛДЖЭЯЧСМІТЬБЮ" +
+		"\\/"
+	LayoutGreek = "`;ςερτυθιοπ[]ασδφγηξκλ΄'ζχψωβνμ,." +
+		"~:΅ΕΡΤΥΘΙΟΠ{}ΑΣΔΦΓΗΞΚΛ¨\"ΖΧΨΩΒΝΜ<>" +
+		"\\|"
+)
+
+var (
+	layoutMtx  sync.RWMutex
+	layoutKeys = make(map[rune]rune)
+)
+
+func init() {
+	// ЙЦУКЕН goes first: if layouts share a rune, the first one wins
+	for _, layout := range []string{LayoutRussian, LayoutUkrainian, LayoutBelarusian, LayoutGreek} {
+		if err := RegisterKeyLayout(layout); err != nil {
+			panic(err)
+		}
+	}
+}
+
+/*
+RegisterKeyLayout adds a keyboard layout to the key normalization: hotkeys
+and keymap bindings typed with the layout work as if they were typed with
+QWERTY one. layout must contain the runes of the layout keys in the order
+of the QWERTY keys:
+
+	`qwertyuiop[]asdfghjkl;'zxcvbnm,.~QWERTYUIOP{}ASDFGHJKL:"ZXCVBNM<>\|
+
+Runes that are registered by another layout already are not changed.
+*/
+func RegisterKeyLayout(layout string) error {
+	keys, qwerty := []rune(layout), []rune(qwertyKeys)
+	if len(keys) != len(qwerty) {
+		return fmt.Errorf("layout must have %v keys, got %v", len(qwerty), len(keys))
+	}
+
+	layoutMtx.Lock()
+	defer layoutMtx.Unlock()
+	for i, ch := range keys {
+		if ch < 0x80 {
+			continue
+		}
+		if _, ok := layoutKeys[ch]; !ok {
+			layoutKeys[ch] = qwerty[i]
+		}
+	}
+	return nil
+}
+
+// NormalizeKey returns the QWERTY rune of the physical key that types ch
+// in one of the registered layouts. Other runes are returned as is.
+// The library uses it only to match hotkeys and key bindings - text
+// controls get the characters a user typed
+func NormalizeKey(ch rune) rune {
+	if ch < 0x80 {
+		return ch
+	}
+
+	layoutMtx.RLock()
+	defer layoutMtx.RUnlock()
+	if key, ok := layoutKeys[ch]; ok {
+		return key
+	}
+	return ch
+}
+
+// normalized returns the key stroke as it is typed with QWERTY layout
+func (ks KeyStroke) normalized() KeyStroke {
+	ks.Ch = NormalizeKey(ks.Ch)
+	return ks
+}
@@ -19,6 +19,10 @@ ListBox calls onSelectItem item function after a user changes currently
 selected item with mouse or using keyboard. Event structure has 2 fields filled:
 Y - selected item number in list(-1 if nothing is selected),
 Msg - text of the selected item.
+
+ListBox calls onContextMenu function when a user right clicks an item
+(the item becomes selected) or presses Shift+F10. Event fields are the
+same as for onSelectItem. Use CreatePopupMenuAt to show a menu for the item.
 */
 type ListBox struct {
 	TBaseControl
@@ -28,8 +32,9 @@ type ListBox struct {
 	topLine       int
 	buttonPos     int
 
-	onSelectItem func(Event)
-	onKeyPress   func(term.Key) bool
+	onSelectItem  func(Event)
+	onKeyPress    func(term.Key) bool
+	onContextMenu func(Event)
 
 	autoWidth  types.IAutoWidth
 	autoHeight types.IAutoHeight
@@ -305,6 +310,40 @@ func (l *ListBox) processMouseClick(ev Event) bool {
 	return true
 }
 
+// processRightClick selects the item under the mouse and asks for its
+// context menu
+func (l *ListBox) processRightClick(ev Event) bool {
+	dx := ev.X - l.pos.GetX()
+	dy := ev.Y - l.pos.GetY()
+	if dx < 0 || int(dx) >= int(l.width.Get())-1 || dy < 0 || int(dy) >= int(l.height.Get()) ||
+		l.topLine+int(dy) >= len(l.items) {
+		return false
+	}
+
+	if l.topLine+int(dy) != l.currSelection {
+		ev.Key = term.MouseLeft
+		l.processMouseClick(ev)
+	}
+	l.emitContextMenu()
+	return true
+}
+
+func (l *ListBox) emitContextMenu() {
+	if l.currSelection != -1 && l.onContextMenu != nil {
+		ev := Event{Y: types.ACoordY(l.currSelection), Msg: l.SelectedItemText()}
+		l.onContextMenu(ev)
+	}
+}
+
+// popupPos returns the screen position of the selected item
+func (l *ListBox) popupPos() (types.ACoordX, types.ACoordY) {
+	x, y := l.pos.Get()
+	if l.currSelection >= l.topLine {
+		y += types.ACoordY(l.currSelection - l.topLine)
+	}
+	return x, y
+}
+
 func (l *ListBox) recalcPositionByScroll() {
 	newPos := ItemByThumbPosition(l.buttonPos, len(l.items), int(l.height.Get()))
 	if newPos < 1 {
@@ -315,6 +354,20 @@ func (l *ListBox) recalcPositionByScroll() {
 	l.EnsureVisible()
 }
 
+// scroll moves the visible part of the list by dy lines. The selection
+// does not change
+func (l *ListBox) scroll(dy int) {
+	maxTop := len(l.items) - int(l.height.Get())
+	top := l.topLine + dy
+	if top > maxTop {
+		top = maxTop
+	}
+	if top < 0 {
+		top = 0
+	}
+	l.topLine = top
+}
+
 /*
 ProcessEvent processes all events come from the control parent. If a control
 processes an event it should return true. If the method returns false it means
@@ -322,6 +375,15 @@ that the control do not want or cannot process the event and the caller sends
 the event to the control parent
 */
 func (l *ListBox) ProcessEvent(event Event) bool {
+	if event.Type == EventMouseWheel && l.Enabled() {
+		if event.Key == term.MouseWheelUp {
+			l.scroll(-mouseWheelLines)
+		} else {
+			l.scroll(mouseWheelLines)
+		}
+		return true
+	}
+
 	if !l.Active() || !l.Enabled() {
 		return false
 	}
@@ -359,11 +421,19 @@ func (l *ListBox) ProcessEvent(event Event) bool {
 				ev := Event{Y: types.ACoordY(l.currSelection), Msg: l.SelectedItemText()}
 				l.onSelectItem(ev)
 			}
+		case term.KeyF10:
+			if !event.Shift() {
+				return false
+			}
+			l.emitContextMenu()
+			return true
 		default:
 			return false
 		}
 	case EventMouse:
 		return l.processMouseClick(event)
+	case EventRightClick:
+		return l.processRightClick(event)
 	}
 
 	return false
@@ -471,6 +541,12 @@ func (l *ListBox) OnSelectItem(fn func(Event)) {
 	l.onSelectItem = fn
 }
 
+// OnContextMenu sets a callback that is called when a user right clicks
+// an item or presses Shift+F10
+func (l *ListBox) OnContextMenu(fn func(Event)) {
+	l.onContextMenu = fn
+}
+
 // OnKeyPress sets the callback that is called when a user presses a Key while
 // the controls is active. If a handler processes the key it should return
 // true. If handler returns false it means that the default handler will
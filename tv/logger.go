@@ -16,6 +16,10 @@ func InitLogger() {
 }
 
 func Logger() *log.Logger {
+	if curApp != nil {
+		return curApp.Logger()
+	}
+
 	if logger == nil {
 		InitLogger()
 	}
@@ -89,6 +89,9 @@ func CreateLoginDialog(title, userName string) *LoginDialog {
 	})
 
 	btnOk.OnClick(func(ev Event) {
+		if !ValidateControls(dlg.View) {
+			return
+		}
 		if dlg.onCheck != nil && !dlg.onCheck(edUser.Title(), edPass.Title()) {
 			lbRes.SetTitle("Invalid username or password")
 			dlg.Action = LoginInvalid
@@ -1,63 +1,389 @@
 package tv
 
 import (
-	term "github.com/nsf/termbox-go"
+	"context"
+	"sync"
 )
 
-// Composer is a service object that manages Views and console, processes
-// events, and provides service methods. One application must have only
-// one object of this type
+// mainLoop is a queue that delivers events posted by Views and other
+// goroutines to the application event loop. Every Application has its
+// own mainLoop
 type mainLoop struct {
-	// a channel to communicate with View(e.g, Views send redraw event to this channel)
-	channel chan Event
+	// events are posted by Views and other goroutines (e.g, Views post
+	// redraw events) and wait for the loop to process them
+	events []Event
+	// wake has a value while the loop has to look at the queued events.
+	// It never blocks senders: one value wakes the loop for all of them
+	wake chan struct{}
+	// replay gets events of a played event script
+	replay chan ScriptEvent
+	// recorder writes all processed events to an event script, if it is set
+	recorder *EventRecorder
+	// calls are functions queued with Post and Invoke. Every call has its
+	// own EventCall in the queue
+	calls []*loopCall
+	// timers are active timers started with AfterFunc and Every
+	timers map[*Timer]struct{}
+	// damaged parts of the screen that are repainted at the end of the
+	// frame. See Invalidate
+	damagedAll   bool
+	damagedCtrls []IControl
+	damagedRects []rect
+	// touched is set when the code run by the loop damages anything
+	touched bool
+	// redrawPending is set while EventRedraw sent to wake the loop up
+	// is not processed yet
+	redrawPending bool
+	// inFrame is true while the loop processes an event. It is accessed
+	// only by the goroutine that runs the loop
+	inFrame bool
+	mtx     sync.Mutex
+}
+
+// loopCall is a function that is run by the main loop
+type loopCall struct {
+	fn   func()
+	done chan struct{}
 }
 
 var (
 	loop *mainLoop
 )
 
-func initMainLoop() {
-	loop = new(mainLoop)
-	loop.channel = make(chan Event)
+func newMainLoop() *mainLoop {
+	l := new(mainLoop)
+	l.wake = make(chan struct{}, 1)
+	l.replay = make(chan ScriptEvent)
+	return l
 }
 
-// MainLoop starts the main application event loop
+// MainLoop starts the main application event loop. It panics if the
+// screen backend fails. Use RunContext to get the error instead
 func MainLoop() {
-	RefreshScreen()
+	defaultApp.MainLoop()
+}
+
+// RunContext starts the main application event loop. See
+// Application.RunContext for details
+func RunContext(ctx context.Context) error {
+	return defaultApp.RunContext(ctx)
+}
 
-	eventQueue := make(chan term.Event)
+// MainLoop starts the application event loop. It returns after the
+// application receives EventQuit, e.g, after Stop is called. It panics
+// if the screen backend fails
+func (a *Application) MainLoop() {
+	if err := a.RunContext(context.Background()); err != nil {
+		panic(err)
+	}
+}
+
+// RunContext starts the application event loop. It returns nil after the
+// application receives EventQuit, e.g, after Stop is called, ctx.Err()
+// after the context is cancelled, or the error the screen backend has
+// reported. The goroutine that reads screen events is stopped before
+// RunContext returns, even if an event handler panics. Events it has read
+// after the loop stopped are processed by the next RunContext call. The
+// screen is not finalized: call DeinitLibrary or Application.Close after that
+func (a *Application) RunContext(ctx context.Context) error {
+	a.Do(RefreshScreen)
+
+	eventQueue := make(chan Event)
+	stopPoll := make(chan struct{})
+	pollDone := make(chan struct{})
+	screen := a.canvas.screen
+	pending := a.pending
+	a.pending = nil
 	go func() {
-		for {
-			eventQueue <- term.PollEvent()
+		defer close(pollDone)
+		queue := pending
+		stopped := false
+		// after the loop stops the goroutine reads events until it takes
+		// the interruption, so Interrupt never waits forever
+		for !stopped || !hasInterrupt(queue) {
+			if len(queue) == 0 || stopped {
+				ev := screen.PollEvent()
+				// a broken screen reports errors until it is interrupted,
+				// the next run needs only one of them
+				if stopped && ev.Type == EventError && len(queue) > 0 && queue[len(queue)-1].Type == EventError {
+					continue
+				}
+				queue = append(queue, ev)
+				continue
+			}
+			select {
+			case eventQueue <- queue[0]:
+				queue = queue[1:]
+			case <-stopPoll:
+				stopped = true
+			}
+		}
+		for _, ev := range queue {
+			if ev.Type != EventInterrupt {
+				a.pending = append(a.pending, ev)
+			}
 		}
 	}()
+	defer func() {
+		close(stopPoll)
+		screen.Interrupt()
+		<-pollDone
+	}()
 
 	for {
-		RefreshScreen()
-
 		select {
+		case <-ctx.Done():
+			return ctx.Err()
 		case ev := <-eventQueue:
-			switch ev.Type {
-			case term.EventError:
-				panic(ev.Err)
-			default:
-				ProcessEvent(termboxEventToLocal(ev))
+			if ev.Type == EventInterrupt {
+				continue
 			}
-		case cmd := <-loop.channel:
-			if cmd.Type == EventQuit {
-				return
+			if stop, err := a.processScreenEvent(ev); stop {
+				return err
 			}
+		case <-a.loop.wake:
+			cmd, ok := a.loop.nextEvent()
+			if !ok {
+				continue
+			}
+			if a.processPostedEvent(cmd) {
+				return nil
+			}
+		case se := <-a.loop.replay:
+			// replayed events are recorded with their original source
+			if se.Source == SourceScreen {
+				if stop, err := a.processScreenEvent(se.Event); stop {
+					return err
+				}
+			} else if a.processPostedEvent(se.Event) {
+				return nil
+			}
+		}
+	}
+}
+
+// processScreenEvent records and processes user input. It returns true
+// and the error if the screen backend has failed
+func (a *Application) processScreenEvent(ev Event) (bool, error) {
+	a.loop.record(ev, SourceScreen)
+	if ev.Type == EventError {
+		return true, ev.Err
+	}
+	a.frame(func() {
+		a.processEvent(ev)
+	})
+	return false, nil
+}
+
+// processPostedEvent records and processes the event posted to the loop.
+// It returns true for EventQuit
+func (a *Application) processPostedEvent(cmd Event) bool {
+	switch cmd.Type {
+	case EventCall:
+		a.frame(a.runCall)
+	case EventRedraw:
+		// redraw requests are already recorded as damage
+		a.loop.record(cmd, SourcePost)
+		a.frame(func() {})
+	case EventQuit:
+		a.loop.record(cmd, SourcePost)
+		return true
+	default:
+		a.loop.record(cmd, SourcePost)
+		a.frame(func() {
 			ProcessEvent(cmd)
+			a.loop.invalidateAll()
+		})
+	}
+	return false
+}
+
+// processEvent passes the event to the composer. The composer damages the
+// window that has processed the event. If the event changes the window
+// layout the whole screen is damaged
+func (a *Application) processEvent(ev Event) {
+	before := windowLayout()
+	ProcessEvent(ev)
+	if !sameLayout(before, windowLayout()) {
+		a.loop.invalidateAll()
+	}
+}
+
+// hasInterrupt returns true if the events contain EventInterrupt
+func hasInterrupt(events []Event) bool {
+	for _, ev := range events {
+		if ev.Type == EventInterrupt {
+			return true
 		}
 	}
+	return false
 }
 
-func _putEvent(ev Event) {
-	loop.channel <- ev
+// runCall runs the oldest queued function. If the function does not
+// damage anything explicitly (with Invalidate, RefreshScreen, etc), the
+// whole screen is repainted after it
+func (a *Application) runCall() {
+	c := a.loop.nextCall()
+	if c == nil {
+		return
+	}
+	defer close(c.done)
+
+	a.loop.mtx.Lock()
+	a.loop.touched = false
+	a.loop.mtx.Unlock()
+
+	c.fn()
+
+	a.loop.mtx.Lock()
+	touched := a.loop.touched
+	a.loop.mtx.Unlock()
+	if !touched {
+		a.loop.invalidateAll()
+	}
+}
+
+// setRecorder replaces the current event recorder and returns the old one
+func (l *mainLoop) setRecorder(r *EventRecorder) *EventRecorder {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	old := l.recorder
+	l.recorder = r
+	return old
+}
+
+func (l *mainLoop) record(ev Event, src EventSource) {
+	l.mtx.Lock()
+	r := l.recorder
+	l.mtx.Unlock()
+
+	if r != nil {
+		_ = r.Record(ev, src)
+	}
+}
+
+// put sends the event to the loop without blocking the caller. Redraw
+// requests are merged: see requestRedraw
+func (l *mainLoop) put(ev Event) {
+	if ev.Type == EventRedraw {
+		l.requestRedraw(ev.Target)
+		return
+	}
+
+	l.send(ev)
+}
+
+// send queues the event and wakes the loop up. It never blocks, even if
+// the loop is not running
+func (l *mainLoop) send(ev Event) {
+	l.mtx.Lock()
+	l.events = append(l.events, ev)
+	l.mtx.Unlock()
+
+	l.notify()
+}
+
+// notify wakes the loop up if it is not woken up yet
+func (l *mainLoop) notify() {
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// nextEvent removes the oldest event from the queue and returns it. The
+// loop takes one event per wake up, so it is woken up again while the
+// queue is not empty: screen events are not delayed by a long queue
+func (l *mainLoop) nextEvent() (Event, bool) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if len(l.events) == 0 {
+		return Event{}, false
+	}
+	ev := l.events[0]
+	l.events[0] = Event{}
+	l.events = l.events[1:]
+	if len(l.events) > 0 {
+		l.notify()
+	}
+	return ev, true
+}
+
+// post queues the function and wakes the loop up. The returned channel is
+// closed after the function finishes
+func (l *mainLoop) post(fn func()) chan struct{} {
+	c := &loopCall{fn: fn, done: make(chan struct{})}
+
+	l.mtx.Lock()
+	l.calls = append(l.calls, c)
+	l.mtx.Unlock()
+
+	l.send(Event{Type: EventCall})
+	return c.done
+}
+
+// nextCall removes the oldest function from the queue and returns it.
+// Functions are run in the order they are posted even if EventCall
+// events are delivered in a different order
+func (l *mainLoop) nextCall() *loopCall {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if len(l.calls) == 0 {
+		return nil
+	}
+	c := l.calls[0]
+	l.calls[0] = nil
+	l.calls = l.calls[1:]
+	return c
+}
+
+// Post queues fn to run in the main loop between events and returns at
+// once. The screen is repainted after fn finishes, so fn may change any
+// control without calling PutEvent(EventRedraw). If fn calls Invalidate
+// for the changed controls, only their windows are repainted. It is the
+// only safe way to update the UI from other goroutines:
+//
+//	go func() {
+//		for i := 0; i <= 100; i++ {
+//			value := i
+//			time.Sleep(100 * time.Millisecond)
+//			tv.Post(func() { progress.SetValue(value) })
+//		}
+//	}()
+//
+// Outside event handlers Post works with the default application created
+// by InitLibrary, use Application.Post for other applications
+func Post(fn func()) {
+	if l := callLoop(); l != nil {
+		l.post(fn)
+	}
+}
+
+// Invoke queues fn to run in the main loop like Post does and waits until
+// fn finishes. It must not be called from event handlers: they already
+// run inside the main loop, so Invoke would never return. Invoke works
+// with the default application, use Application.Invoke for other ones
+func Invoke(fn func()) {
+	if l := callLoop(); l != nil {
+		<-l.post(fn)
+	}
 }
 
 // PutEvent send event to a Composer directly.
-// Used by Views to ask for repainting or for quitting the application
+// Used by Views to ask for repainting or for quitting the application.
+// Outside event handlers PutEvent works with the default application,
+// use Application.PutEvent for other ones
 func PutEvent(ev Event) {
-	go _putEvent(ev)
+	if l := callLoop(); l != nil {
+		l.put(ev)
+	}
+}
+
+// quit asks the loop of the current application to stop. The composer
+// calls it inside the main loop, so it must not use the default loop
+func quit() {
+	loop.put(Event{Type: EventQuit})
 }
@@ -0,0 +1,215 @@
+package tv
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPostAndInvoke(t *testing.T) {
+	scr := NewHeadlessScreen(30, 5)
+	InitLibrary(scr)
+	defer DeinitLibrary()
+
+	// without windows every repaint flushes the screen once
+	done := make(chan struct{})
+	go func() {
+		MainLoop()
+		close(done)
+	}()
+
+	var order []int
+	flushes := make([]int, 0, 5)
+	posted := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			value := i
+			Post(func() {
+				order = append(order, value)
+				flushes = append(flushes, scr.FlushCount())
+				if value == 4 {
+					close(posted)
+				}
+			})
+		}
+	}()
+
+	select {
+	case <-posted:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Posted functions were not called")
+	}
+
+	var before, after int
+	Invoke(func() {
+		before = scr.FlushCount()
+	})
+	Invoke(func() {
+		after = scr.FlushCount()
+	})
+
+	if after-before != 1 {
+		t.Errorf("Invoke must trigger exactly one repaint, got %v", after-before)
+	}
+	for i := range order {
+		if order[i] != i {
+			t.Fatalf("Posted functions must run in order: %v", order)
+		}
+		if i > 0 && flushes[i]-flushes[i-1] != 1 {
+			t.Errorf("Post must trigger exactly one repaint: %v", flushes)
+		}
+	}
+
+	Stop()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("MainLoop did not finish")
+	}
+}
+
+func TestPostWithoutLoop(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(30, 5))
+	defer DeinitLibrary()
+
+	// events posted while the loop is stopped wait in the queue without
+	// goroutines blocked on sending them
+	before := runtime.NumGoroutine()
+	var order []int
+	for i := 0; i < 1000; i++ {
+		value := i
+		Post(func() {
+			order = append(order, value)
+		})
+		PutEvent(Event{Type: EventRedraw})
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("Posting must not start goroutines: %v before, %v after", before, after)
+	}
+
+	Post(Stop)
+	MainLoop()
+	if len(order) != 1000 {
+		t.Fatalf("All posted functions must run, got %v", len(order))
+	}
+	for i := range order {
+		if order[i] != i {
+			t.Fatalf("Posted functions must run in order")
+		}
+	}
+}
+
+func TestRunContextCancel(t *testing.T) {
+	scr := NewHeadlessScreen(30, 5)
+	InitLibrary(scr)
+	defer DeinitLibrary()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	res := make(chan error)
+	go func() {
+		res <- RunContext(ctx)
+	}()
+
+	Invoke(func() {})
+	cancel()
+
+	select {
+	case err := <-res:
+		if err != context.Canceled {
+			t.Errorf("RunContext must return context error instead of %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("RunContext did not return after the context is cancelled")
+	}
+
+	// the screen goroutine is stopped and does not steal events
+	scr.InjectRune('a')
+	time.Sleep(10 * time.Millisecond)
+	if scr.Pending() != 1 {
+		t.Errorf("Screen events must not be read after RunContext returns")
+	}
+}
+
+func TestRunContextKeepsEvents(t *testing.T) {
+	scr := NewHeadlessScreen(30, 5)
+	InitLibrary(scr)
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 0, 20, 3, "Edit", false, false)
+	edit := CreateEditField(wnd, 10, "", Fixed)
+	ActivateControl(wnd, edit)
+
+	// the loop stops at once: the typed rune can be read but not processed
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	scr.InjectRune('a')
+	_ = RunContext(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		MainLoop()
+		close(done)
+	}()
+	var title string
+	deadline := time.Now().Add(5 * time.Second)
+	for title == "" && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		Invoke(func() {
+			title = edit.Title()
+		})
+	}
+	Stop()
+	<-done
+	if title != "a" {
+		t.Errorf("Events read after the loop stopped must not be lost: %q", title)
+	}
+}
+
+func TestRunContextBackendError(t *testing.T) {
+	scr := NewHeadlessScreen(30, 5)
+	InitLibrary(scr)
+	defer DeinitLibrary()
+
+	errBackend := errors.New("connection lost")
+	scr.InjectEvent(Event{Type: EventError, Err: errBackend})
+	if err := RunContext(context.Background()); err != errBackend {
+		t.Errorf("RunContext must return backend error instead of %v", err)
+	}
+}
+
+func TestRunContextPanic(t *testing.T) {
+	var out bytes.Buffer
+	input, _ := io.Pipe()
+	rw := struct {
+		io.Reader
+		io.Writer
+	}{input, &out}
+	scr := NewSessionScreen(rw, "xterm", 30, 5)
+	InitLibrary(NewHeadlessScreen(30, 5))
+	app, _ := NewApplication(scr)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Panic in a handler must be passed to the caller")
+			}
+			app.Close()
+		}()
+
+		app.Post(func() {
+			panic("handler failed")
+		})
+		_ = app.RunContext(context.Background())
+	}()
+
+	if !strings.HasSuffix(out.String(), ansiLeave) {
+		t.Errorf("Terminal must be restored after panic")
+	}
+	app.Close()
+	DeinitLibrary()
+}
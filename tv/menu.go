@@ -0,0 +1,619 @@
+package tv
+
+import (
+	"unicode"
+
+	xs "github.com/huandu/xstrings"
+	term "github.com/nsf/termbox-go"
+
+	"github.com/prospero78/goTV/tv/types"
+)
+
+/*
+MenuItem is one line of a Menu: a command, a checkable option, a separator
+or a submenu. Item title can contain an accelerator: "~S~ave" is selected
+with S key while the menu is open.
+An item is triggered with Enter, its accelerator or a mouse click. The
+item calls its OnClick callback and runs its command (see
+Composer.HandleCommand)
+*/
+type MenuItem struct {
+	title     string
+	shortcut  string
+	command   string
+	checkable bool
+	checked   bool
+	disabled  bool
+	separator bool
+	submenu   *Menu
+	onClick   func(*MenuItem)
+}
+
+/*
+Menu is a list of items shown as a drop-down menu of MenuBar or as a
+submenu of another menu
+*/
+type Menu struct {
+	title string
+	items []*MenuItem
+}
+
+// NewMenu creates an empty menu. title is used when the menu is added
+// to a MenuBar or as a submenu
+func NewMenu(title string) *Menu {
+	return &Menu{title: title}
+}
+
+// Title returns the menu title
+func (m *Menu) Title() string {
+	return m.title
+}
+
+// Items returns all menu items including separators
+func (m *Menu) Items() []*MenuItem {
+	return m.items
+}
+
+// AddItem adds an item that calls fn when it is triggered. shortcut is
+// the text displayed at the right side of the item, e.g "Alt+X". The
+// menu does not bind the shortcut, it is only a hint
+func (m *Menu) AddItem(title, shortcut string, fn func(*MenuItem)) *MenuItem {
+	item := &MenuItem{title: title, shortcut: shortcut, onClick: fn}
+	m.items = append(m.items, item)
+	return item
+}
+
+// AddCommand adds an item that runs the command registered with
+// Composer.HandleCommand. The item shows the keys bound to the command
+// in the composer keymap as its shortcut
+func (m *Menu) AddCommand(title, command string) *MenuItem {
+	item := &MenuItem{title: title, command: command}
+	m.items = append(m.items, item)
+	return item
+}
+
+// AddCheckItem adds an item that toggles its checked state every time
+// it is triggered and then calls fn
+func (m *Menu) AddCheckItem(title string, checked bool, fn func(*MenuItem)) *MenuItem {
+	item := &MenuItem{title: title, checkable: true, checked: checked, onClick: fn}
+	m.items = append(m.items, item)
+	return item
+}
+
+// AddSeparator adds a horizontal line between items
+func (m *Menu) AddSeparator() {
+	m.items = append(m.items, &MenuItem{separator: true})
+}
+
+// AddSubmenu adds an item that opens a nested menu and returns the
+// nested menu
+func (m *Menu) AddSubmenu(title string) *Menu {
+	sub := NewMenu(title)
+	m.items = append(m.items, &MenuItem{title: title, submenu: sub})
+	return sub
+}
+
+// Title returns the item title
+func (mi *MenuItem) Title() string {
+	return mi.title
+}
+
+// SetTitle changes the item title
+func (mi *MenuItem) SetTitle(title string) {
+	mi.title = title
+}
+
+// Shortcut returns the text displayed at the right side of the item. If
+// it is not set, command items show the keys bound to the command
+func (mi *MenuItem) Shortcut() string {
+	if mi.shortcut != "" || mi.command == "" || comp == nil {
+		return mi.shortcut
+	}
+	return keysLabel(comp.keymap.Keys(mi.command))
+}
+
+// SetShortcut changes the shortcut hint of the item
+func (mi *MenuItem) SetShortcut(shortcut string) {
+	mi.shortcut = shortcut
+}
+
+// Command returns the command the item runs
+func (mi *MenuItem) Command() string {
+	return mi.command
+}
+
+// SetCommand changes the command the item runs
+func (mi *MenuItem) SetCommand(command string) {
+	mi.command = command
+}
+
+// Enabled returns false if the item is grayed out and cannot be triggered.
+// The item of a disabled command is disabled as well
+func (mi *MenuItem) Enabled() bool {
+	if mi.disabled {
+		return false
+	}
+	return mi.command == "" || comp == nil || comp.CommandEnabled(mi.command)
+}
+
+// SetEnabled enables or disables the item
+func (mi *MenuItem) SetEnabled(enabled bool) {
+	mi.disabled = !enabled
+}
+
+// Checkable returns true if the item toggles its checked state
+func (mi *MenuItem) Checkable() bool {
+	return mi.checkable
+}
+
+// Checked returns true if the item is checked
+func (mi *MenuItem) Checked() bool {
+	return mi.checked
+}
+
+// SetChecked checks or unchecks the item. The item becomes checkable
+func (mi *MenuItem) SetChecked(checked bool) {
+	mi.checkable = true
+	mi.checked = checked
+}
+
+// Separator returns true if the item is a separator line
+func (mi *MenuItem) Separator() bool {
+	return mi.separator
+}
+
+// Submenu returns the nested menu of the item or nil
+func (mi *MenuItem) Submenu() *Menu {
+	return mi.submenu
+}
+
+// OnClick sets the callback that is called when the item is triggered
+func (mi *MenuItem) OnClick(fn func(*MenuItem)) {
+	mi.onClick = fn
+}
+
+// selectable returns true if the menu cursor can stop at the item
+func (mi *MenuItem) selectable() bool {
+	return !mi.separator
+}
+
+// trigger toggles the checkable item and runs its callback and command
+func (mi *MenuItem) trigger() {
+	if mi.checkable {
+		mi.checked = !mi.checked
+	}
+	if mi.onClick != nil {
+		mi.onClick(mi)
+	}
+	if mi.command != "" {
+		comp.runCommand(mi.command, Event{Type: EventClick, Msg: mi.command})
+	}
+}
+
+// keysLabel makes a keymap key sequence look like a menu shortcut:
+// "ctrl+w ctrl+c" -> "Ctrl+W Ctrl+C"
+func keysLabel(keys string) string {
+	out := []rune(keys)
+	start := true
+	for i, r := range out {
+		if start {
+			out[i] = unicode.ToUpper(r)
+		}
+		start = r == '+' || r == ' '
+	}
+	return string(out)
+}
+
+// menu object runes: frame (─│┌┐└┘), separator ends (├┤), check mark
+// and submenu arrow
+const (
+	menuHLine = iota
+	menuVLine
+	menuTopLeft
+	menuTopRight
+	menuBottomLeft
+	menuBottomRight
+	menuSepLeft
+	menuSepRight
+	menuCheck
+	menuArrow
+	menuRuneCount
+)
+
+func menuRunes() []rune {
+	parts := []rune(SysObject(ObjMenu))
+	if len(parts) < menuRuneCount {
+		parts = []rune("─│┌┐└┘├┤√►")
+	}
+	return parts
+}
+
+// menuBox is an open drop-down menu on the screen
+type menuBox struct {
+	menu     *Menu
+	x        types.ACoordX
+	y        types.ACoordY
+	w, h     int
+	selected int
+	// top is the first visible item of a menu that is taller than the
+	// screen
+	top int
+}
+
+// newMenuBox calculates the size of the menu drop-down and places it at
+// x, y so it fits the screen. A menu taller than the screen shows only a
+// part of its items and scrolls them
+func newMenuBox(menu *Menu, x types.ACoordX, y types.ACoordY) *menuBox {
+	box := &menuBox{menu: menu, selected: -1}
+
+	titleW, keyW := 0, 0
+	for _, item := range menu.items {
+		if l := xs.Len(stripHotkey(item.title)); l > titleW {
+			titleW = l
+		}
+		if l := xs.Len(item.Shortcut()); l > keyW {
+			keyW = l
+		}
+		if item.submenu != nil && keyW < 1 {
+			keyW = 1
+		}
+	}
+	if keyW > 0 {
+		keyW += 2
+	}
+
+	box.w = titleW + keyW + 5
+	box.h = len(menu.items) + 2
+
+	cw, ch := ScreenSize()
+	if box.h > ch {
+		box.h = ch
+	}
+	if int(x)+box.w > cw {
+		x = types.ACoordX(cw - box.w)
+	}
+	if int(y)+box.h > ch {
+		y = types.ACoordY(ch - box.h)
+	}
+	if x < 0 {
+		x = 0
+	}
+	if y < 0 {
+		y = 0
+	}
+	box.x, box.y = x, y
+	box.moveSelection(1)
+	return box
+}
+
+// selectedItem returns the item under the menu cursor or nil
+func (box *menuBox) selectedItem() *MenuItem {
+	if box.selected < 0 || box.selected >= len(box.menu.items) {
+		return nil
+	}
+	return box.menu.items[box.selected]
+}
+
+// moveSelection moves the cursor to the next selectable item in the
+// direction dir (1 or -1) wrapping around the menu
+func (box *menuBox) moveSelection(dir int) {
+	count := len(box.menu.items)
+	idx := box.selected
+	for i := 0; i < count; i++ {
+		idx += dir
+		if idx >= count {
+			idx = 0
+		} else if idx < 0 {
+			idx = count - 1
+		}
+		if box.menu.items[idx].selectable() {
+			box.selected = idx
+			box.scrollTo(idx)
+			return
+		}
+	}
+}
+
+// rows returns the number of visible items
+func (box *menuBox) rows() int {
+	return box.h - 2
+}
+
+// scrollTo scrolls the items so the item idx is visible
+func (box *menuBox) scrollTo(idx int) {
+	if idx < box.top {
+		box.top = idx
+	} else if idx >= box.top+box.rows() {
+		box.top = idx - box.rows() + 1
+	}
+	box.scroll(0)
+}
+
+// scroll moves the visible part of the items by delta rows keeping it
+// inside the menu
+func (box *menuBox) scroll(delta int) {
+	box.top += delta
+	if last := len(box.menu.items) - box.rows(); box.top > last {
+		box.top = last
+	}
+	if box.top < 0 {
+		box.top = 0
+	}
+}
+
+// itemAt returns the index of the item at the screen position or -1
+func (box *menuBox) itemAt(x types.ACoordX, y types.ACoordY) int {
+	if !box.contains(x, y) {
+		return -1
+	}
+	row := int(y-box.y) - 1
+	if row < 0 || row >= box.rows() {
+		return -1
+	}
+	idx := box.top + row
+	if idx >= len(box.menu.items) || !box.menu.items[idx].selectable() {
+		return -1
+	}
+	return idx
+}
+
+func (box *menuBox) contains(x types.ACoordX, y types.ACoordY) bool {
+	return x >= box.x && x < box.x+types.ACoordX(box.w) &&
+		y >= box.y && y < box.y+types.ACoordY(box.h)
+}
+
+// hotkeyItem returns the index of the item with the accelerator ch or -1
+func (box *menuBox) hotkeyItem(ch rune) int {
+	key := NormalizeKey(unicode.ToLower(ch))
+	for i, item := range box.menu.items {
+		if item.selectable() && NormalizeKey(Hotkey(item.title)) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// submenuPos returns the position of the drop-down of the selected item
+func (box *menuBox) submenuPos() (types.ACoordX, types.ACoordY) {
+	return box.x + types.ACoordX(box.w) - 1, box.y + types.ACoordY(box.selected-box.top)
+}
+
+func (box *menuBox) draw() {
+	PushAttributes()
+	defer PopAttributes()
+
+	parts := menuRunes()
+	fg, bg := RealColor(ColorDefault, "", ColorMenuText), RealColor(ColorDefault, "", ColorMenuBack)
+	SetTextColor(fg)
+	SetBackColor(bg)
+	FillRect(box.x, box.y, box.w, box.h, ' ')
+
+	right := box.x + types.ACoordX(box.w-1)
+	bottom := box.y + types.ACoordY(box.h-1)
+	DrawHorizontalLine(box.x+1, box.y, box.w-2, parts[menuHLine])
+	DrawHorizontalLine(box.x+1, bottom, box.w-2, parts[menuHLine])
+	DrawVerticalLine(box.x, box.y+1, box.h-2, parts[menuVLine])
+	DrawVerticalLine(right, box.y+1, box.h-2, parts[menuVLine])
+	PutChar(box.x, box.y, parts[menuTopLeft])
+	PutChar(right, box.y, parts[menuTopRight])
+	PutChar(box.x, bottom, parts[menuBottomLeft])
+	PutChar(right, bottom, parts[menuBottomRight])
+
+	if box.top > 0 || box.top+box.rows() < len(box.menu.items) {
+		// arrows on the frame show that a part of the items is hidden
+		arrows := []rune(SysObject(ObjScrollBar))
+		if len(arrows) < 4 {
+			arrows = []rune("░■▲▼")
+		}
+		if box.top > 0 {
+			PutChar(right, box.y+1, arrows[2])
+		}
+		if box.top+box.rows() < len(box.menu.items) {
+			PutChar(right, bottom-1, arrows[3])
+		}
+	}
+
+	for i, item := range box.menu.items {
+		if i < box.top || i >= box.top+box.rows() {
+			continue
+		}
+		y := box.y + types.ACoordY(i-box.top+1)
+		if item.separator {
+			SetTextColor(fg)
+			SetBackColor(bg)
+			DrawHorizontalLine(box.x+1, y, box.w-2, parts[menuHLine])
+			PutChar(box.x, y, parts[menuSepLeft])
+			PutChar(right, y, parts[menuSepRight])
+			continue
+		}
+
+		itemFg, itemBg, hk := fg, bg, RealColor(ColorDefault, "", ColorMenuHotkeyText)
+		if i == box.selected {
+			itemFg = RealColor(ColorDefault, "", ColorMenuActiveText)
+			itemBg = RealColor(ColorDefault, "", ColorMenuActiveBack)
+			hk = RealColor(ColorDefault, "", ColorMenuActiveHotkeyText)
+		}
+		if !item.Enabled() {
+			itemFg = RealColor(ColorDefault, "", ColorMenuDisabledText)
+		}
+		SetTextColor(itemFg)
+		SetBackColor(itemBg)
+		FillRect(box.x+1, y, box.w-2, 1, ' ')
+
+		if item.checkable && item.checked {
+			PutChar(box.x+1, y, parts[menuCheck])
+		}
+		title := stripHotkey(item.title)
+		if item.Enabled() {
+			title = hotkeyTitle(item.title, hk)
+		}
+		DrawText(box.x+3, y, title)
+
+		if item.submenu != nil {
+			PutChar(right-2, y, parts[menuArrow])
+		} else if key := item.Shortcut(); key != "" {
+			DrawRawText(right-1-types.ACoordX(xs.Len(key)), y, key)
+		}
+	}
+}
+
+// menuTitleWidth returns the width of the menu title in the menu bar
+func menuTitleWidth(title string) int {
+	return xs.Len(stripHotkey(title)) + 2
+}
+
+// menuStack is an open drop-down menu with its open submenus. The last
+// box is the one that gets keyboard input
+type menuStack struct {
+	boxes []*menuBox
+}
+
+func (ms *menuStack) open(menu *Menu, x types.ACoordX, y types.ACoordY) {
+	ms.boxes = []*menuBox{newMenuBox(menu, x, y)}
+}
+
+func (ms *menuStack) close() {
+	ms.boxes = nil
+}
+
+func (ms *menuStack) isOpen() bool {
+	return len(ms.boxes) != 0
+}
+
+func (ms *menuStack) last() *menuBox {
+	return ms.boxes[len(ms.boxes)-1]
+}
+
+// openSubmenu opens the submenu of the selected item of the last box
+func (ms *menuStack) openSubmenu() bool {
+	box := ms.last()
+	item := box.selectedItem()
+	if item == nil || item.submenu == nil || !item.Enabled() || len(item.submenu.items) == 0 {
+		return false
+	}
+
+	x, y := box.submenuPos()
+	ms.boxes = append(ms.boxes, newMenuBox(item.submenu, x, y))
+	return true
+}
+
+// closeSubmenu closes the last box if it is a submenu
+func (ms *menuStack) closeSubmenu() bool {
+	if len(ms.boxes) < 2 {
+		return false
+	}
+	ms.boxes = ms.boxes[:len(ms.boxes)-1]
+	return true
+}
+
+// activate opens the submenu of the selected item or returns the item
+// to trigger. Disabled items return nil
+func (ms *menuStack) activate() *MenuItem {
+	item := ms.last().selectedItem()
+	if item == nil || !item.Enabled() {
+		return nil
+	}
+	if item.submenu != nil {
+		ms.openSubmenu()
+		return nil
+	}
+	return item
+}
+
+// processKey moves the cursor and opens or closes submenus. It returns
+// the item to trigger and false if the key is not processed: Esc and
+// Left in the root box, Right on an item without submenu and keys that
+// are not menu keys
+func (ms *menuStack) processKey(ev Event) (*MenuItem, bool) {
+	box := ms.last()
+
+	switch {
+	case ev.Key == term.KeyEsc || ev.Key == term.KeyArrowLeft:
+		return nil, ms.closeSubmenu()
+	case ev.Key == term.KeyArrowRight:
+		return nil, ms.openSubmenu()
+	case ev.Key == term.KeyArrowDown:
+		box.moveSelection(1)
+	case ev.Key == term.KeyArrowUp:
+		box.moveSelection(-1)
+	case ev.Key == term.KeyHome:
+		box.selected = -1
+		box.moveSelection(1)
+	case ev.Key == term.KeyEnd:
+		box.selected = len(box.menu.items)
+		box.moveSelection(-1)
+	case ev.Key == term.KeyEnter:
+		return ms.activate(), true
+	case ev.Ch != 0 && !ev.Alt():
+		idx := box.hotkeyItem(ev.Ch)
+		if idx == -1 {
+			return nil, false
+		}
+		box.selected = idx
+		return ms.activate(), true
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+// boxAt returns the index of the innermost box under the mouse or -1
+func (ms *menuStack) boxAt(x types.ACoordX, y types.ACoordY) int {
+	for i := len(ms.boxes) - 1; i >= 0; i-- {
+		if ms.boxes[i].contains(x, y) {
+			return i
+		}
+	}
+	return -1
+}
+
+// track moves the cursor to the item under the mouse and closes
+// submenus opened after the box with the item. Returns false if there
+// is no item under the mouse
+func (ms *menuStack) track(x types.ACoordX, y types.ACoordY) bool {
+	boxIdx := ms.boxAt(x, y)
+	if boxIdx == -1 {
+		return false
+	}
+	idx := ms.boxes[boxIdx].itemAt(x, y)
+	if idx == -1 {
+		return false
+	}
+	ms.boxes = ms.boxes[:boxIdx+1]
+	ms.boxes[boxIdx].selected = idx
+	return true
+}
+
+// processMouse follows the mouse with the cursor and returns the item to
+// trigger when the button is released over it. inside is false if the
+// mouse is outside all boxes
+func (ms *menuStack) processMouse(ev Event) (item *MenuItem, inside bool) {
+	inside = ms.boxAt(ev.X, ev.Y) != -1
+
+	switch {
+	case ev.Key == term.MouseWheelUp || ev.Key == term.MouseWheelDown:
+		// the wheel scrolls a menu taller than the screen and closes
+		// submenus that would stay at the old item positions
+		if idx := ms.boxAt(ev.X, ev.Y); idx != -1 {
+			delta := 1
+			if ev.Key == term.MouseWheelUp {
+				delta = -1
+			}
+			ms.boxes = ms.boxes[:idx+1]
+			ms.boxes[idx].scroll(delta)
+		}
+	case ev.Mod&term.ModMotion != 0 || ev.Key == term.MouseLeft:
+		ms.track(ev.X, ev.Y)
+	case ev.Key == term.MouseRelease:
+		if ms.track(ev.X, ev.Y) {
+			item = ms.activate()
+		}
+	}
+	return item, inside
+}
+
+func (ms *menuStack) draw() {
+	for _, box := range ms.boxes {
+		box.draw()
+	}
+}
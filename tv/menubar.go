@@ -0,0 +1,243 @@
+package tv
+
+import (
+	"unicode"
+
+	term "github.com/nsf/termbox-go"
+
+	"github.com/prospero78/goTV/tv/types"
+)
+
+/*
+MenuBar is the main menu of the application. It occupies the top line of
+the screen and is managed by Composer: create it with CreateMenuBar and
+fill with AddMenu. Windows are maximized below the menu bar.
+The menu bar is opened with F10 (command Menu in the keymap), Alt+letter
+of the menu title accelerator, or a mouse click. While a drop-down is open
+the menu bar grabs all events:
+- Up, Down, Home, End move the cursor
+- Left, Right switch to the previous or the next menu and close submenus
+- Enter or the item accelerator triggers the item or opens its submenu
+- Esc closes the last opened drop-down
+*/
+type MenuBar struct {
+	TBaseControl
+	menus []*Menu
+	// index of the open menu, -1 if the menu bar is closed
+	selected int
+	// open drop-down of the selected menu and its open submenus
+	stack menuStack
+}
+
+// CreateMenuBar creates an empty menu bar and attaches it to the top of
+// the screen. The previous menu bar is replaced
+func CreateMenuBar() *MenuBar {
+	mb := &MenuBar{
+		TBaseControl: NewBaseControl(),
+		selected:     -1,
+	}
+	mb.SetTabStop(false)
+	comp.SetMenuBar(mb)
+	return mb
+}
+
+// AddMenu adds a new menu to the end of the menu bar and returns it. The
+// title can contain an accelerator: "~F~ile" is opened with Alt+F
+func (mb *MenuBar) AddMenu(title string) *Menu {
+	menu := NewMenu(title)
+	mb.menus = append(mb.menus, menu)
+	return menu
+}
+
+// Menus returns all menus of the menu bar
+func (mb *MenuBar) Menus() []*Menu {
+	return mb.menus
+}
+
+// IsOpen returns true if a drop-down menu is shown
+func (mb *MenuBar) IsOpen() bool {
+	return mb.selected != -1
+}
+
+// Open shows the drop-down of the menu with index idx
+func (mb *MenuBar) Open(idx int) {
+	if idx < 0 || idx >= len(mb.menus) {
+		return
+	}
+	if wnd := comp.topWindow(); wnd != nil && wnd.Modal() {
+		return
+	}
+
+	mb.selected = idx
+	mb.stack.open(mb.menus[idx], mb.menuPos(idx), mb.pos.GetY()+1)
+	GrabEvents(mb)
+	RefreshScreen()
+}
+
+// Close hides all drop-down menus
+func (mb *MenuBar) Close() {
+	if mb.selected == -1 {
+		return
+	}
+
+	mb.selected = -1
+	mb.stack.close()
+	if comp.consumer == mb {
+		ReleaseEvents()
+	}
+	RefreshScreen()
+}
+
+// menuPos returns the screen column of the menu title
+func (mb *MenuBar) menuPos(idx int) types.ACoordX {
+	x := mb.pos.GetX() + 1
+	for i := 0; i < idx; i++ {
+		x += types.ACoordX(menuTitleWidth(mb.menus[i].title))
+	}
+	return x
+}
+
+// menuAt returns the index of the menu which title is at column x or -1
+func (mb *MenuBar) menuAt(x types.ACoordX) int {
+	for i, menu := range mb.menus {
+		start := mb.menuPos(i)
+		if x >= start && x < start+types.ACoordX(menuTitleWidth(menu.title)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Draw repaints the menu bar and all open drop-downs
+func (mb *MenuBar) Draw() {
+	if mb.hidden {
+		return
+	}
+
+	PushAttributes()
+	defer PopAttributes()
+
+	x, y := mb.pos.Get()
+	w, _ := mb.Size()
+	fg, bg := RealColor(mb.fg, mb.Style(), ColorMenuText), RealColor(mb.bg, mb.Style(), ColorMenuBack)
+	SetTextColor(fg)
+	SetBackColor(bg)
+	FillRect(x, y, w, 1, ' ')
+
+	for i, menu := range mb.menus {
+		hk := RealColor(ColorDefault, mb.Style(), ColorMenuHotkeyText)
+		if i == mb.selected {
+			SetTextColor(RealColor(ColorDefault, mb.Style(), ColorMenuActiveText))
+			SetBackColor(RealColor(ColorDefault, mb.Style(), ColorMenuActiveBack))
+			hk = RealColor(ColorDefault, mb.Style(), ColorMenuActiveHotkeyText)
+		} else {
+			SetTextColor(fg)
+			SetBackColor(bg)
+		}
+		DrawText(mb.menuPos(i), y, " "+hotkeyTitle(menu.title, hk)+" ")
+	}
+
+	mb.stack.draw()
+}
+
+// processHotkey opens the menu with the accelerator of Alt+letter event
+func (mb *MenuBar) processHotkey(ev Event) bool {
+	if mb.hidden || ev.Type != EventKey || ev.Ch == 0 || !ev.Alt() {
+		return false
+	}
+
+	key := NormalizeKey(unicode.ToLower(ev.Ch))
+	for i, menu := range mb.menus {
+		if NormalizeKey(Hotkey(menu.title)) == key {
+			mb.Open(i)
+			return mb.IsOpen()
+		}
+	}
+	return false
+}
+
+// ProcessEvent processes keyboard and mouse events while the menu bar is
+// open, and mouse clicks on the closed menu bar
+func (mb *MenuBar) ProcessEvent(ev Event) bool {
+	switch ev.Type {
+	case EventKey:
+		if !mb.IsOpen() {
+			return false
+		}
+		mb.processKey(ev)
+		return true
+	case EventMouse:
+		return mb.processMouse(ev)
+	}
+	return false
+}
+
+// switchMenu opens the next (dir=1) or the previous (dir=-1) menu
+func (mb *MenuBar) switchMenu(dir int) {
+	count := len(mb.menus)
+	mb.Open((mb.selected + dir + count) % count)
+}
+
+// trigger closes the menu and runs the item
+func (mb *MenuBar) trigger(item *MenuItem) {
+	if item == nil {
+		return
+	}
+	mb.Close()
+	item.trigger()
+}
+
+func (mb *MenuBar) processKey(ev Event) {
+	item, ok := mb.stack.processKey(ev)
+	switch {
+	case ok:
+		mb.trigger(item)
+	case ev.Key == term.KeyEsc:
+		mb.Close()
+	case ev.Key == term.KeyArrowRight:
+		mb.switchMenu(1)
+	case ev.Key == term.KeyArrowLeft:
+		mb.switchMenu(-1)
+	case ev.Ch != 0 && ev.Alt():
+		if !mb.processHotkey(ev) {
+			mb.Close()
+		}
+	}
+}
+
+// processMouse handles mouse events. Clicking a menu title opens or
+// closes the menu, releasing the button over an item triggers it, clicking
+// outside the menus closes them
+func (mb *MenuBar) processMouse(ev Event) bool {
+	onBar := ev.Y == mb.pos.GetY() && !mb.hidden
+	press := ev.Key == term.MouseLeft && ev.Mod&term.ModMotion == 0
+
+	if !mb.IsOpen() {
+		if onBar && press {
+			if idx := mb.menuAt(ev.X); idx != -1 {
+				mb.Open(idx)
+			}
+		}
+		return onBar
+	}
+
+	item, inside := mb.stack.processMouse(ev)
+	switch {
+	case inside:
+		mb.trigger(item)
+	case onBar && ev.Mod&term.ModMotion != 0:
+		if idx := mb.menuAt(ev.X); idx != -1 && idx != mb.selected {
+			mb.Open(idx)
+		}
+	case onBar && press:
+		idx := mb.menuAt(ev.X)
+		if idx == -1 || idx == mb.selected {
+			mb.Close()
+		} else {
+			mb.Open(idx)
+		}
+	case !onBar && (press || ev.Key == term.MouseRight || ev.Key == term.MouseMiddle):
+		mb.Close()
+	}
+	return true
+}
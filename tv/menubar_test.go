@@ -0,0 +1,180 @@
+package tv
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	term "github.com/nsf/termbox-go"
+)
+
+func keyEvent(k term.Key) Event {
+	return Event{Type: EventKey, Key: k}
+}
+
+func TestMenuBarKeyboard(t *testing.T) {
+	scr := NewHeadlessScreen(60, 20)
+	InitLibrary(scr)
+	defer DeinitLibrary()
+
+	AddWindow(0, 1, 30, 10, "Main", false, false)
+	mb := CreateMenuBar()
+
+	var got []string
+	record := func(item *MenuItem) {
+		got = append(got, stripHotkey(item.Title()))
+	}
+	file := mb.AddMenu("~F~ile")
+	file.AddItem("~N~ew", "F3", record)
+	file.AddSeparator()
+	wrap := file.AddCheckItem("~W~rap", false, record)
+	file.AddItem("~P~rint", "", record).SetEnabled(false)
+	recent := file.AddSubmenu("~R~ecent")
+	recent.AddItem("~O~ne", "", record)
+	edit := mb.AddMenu("~E~dit")
+	_ = comp.Keymap().Bind("f5", "Hello")
+	comp.HandleCommand("Hello", func(ev Event) {
+		got = append(got, "command")
+	})
+	edit.AddCommand("~H~ello", "Hello")
+
+	RefreshScreen()
+	if !strings.HasPrefix(scr.Lines()[0], "  File  Edit ") {
+		t.Errorf("Menu bar is not drawn: <%v>", scr.Lines()[0])
+	}
+
+	ProcessEvent(keyEvent(term.KeyF10))
+	if !mb.IsOpen() || comp.consumer != mb {
+		t.Fatalf("F10 must open the first menu")
+	}
+	RefreshScreen()
+	if line := scr.Lines()[2]; !strings.Contains(line, "New") || !strings.Contains(line, "F3") {
+		t.Errorf("Drop-down is not drawn: <%v>", line)
+	}
+
+	// Down skips the separator, Enter toggles the check item
+	ProcessEvent(keyEvent(term.KeyArrowDown))
+	ProcessEvent(keyEvent(term.KeyEnter))
+	if !wrap.Checked() || mb.IsOpen() || comp.consumer != nil {
+		t.Errorf("Enter must trigger the item and close the menu")
+	}
+
+	// disabled item is not triggered
+	ProcessEvent(Event{Type: EventKey, Ch: 'f', Mod: ModAlt})
+	ProcessEvent(Event{Type: EventKey, Ch: 'p'})
+
+	// submenu is opened with Right and its item by the accelerator
+	ProcessEvent(keyEvent(term.KeyEnd))
+	ProcessEvent(keyEvent(term.KeyArrowRight))
+	ProcessEvent(Event{Type: EventKey, Ch: 'o'})
+
+	// Right on item without submenu switches menu, shortcut is from keymap
+	ProcessEvent(keyEvent(term.KeyF10))
+	ProcessEvent(keyEvent(term.KeyArrowRight))
+	RefreshScreen()
+	if !strings.Contains(scr.Lines()[2], "F5") {
+		t.Errorf("Command item must show its keys: <%v>", scr.Lines()[2])
+	}
+	ProcessEvent(Event{Type: EventKey, Ch: 'р'})
+
+	if strings.Join(got, ",") != "Wrap,One,command" {
+		t.Errorf("Wrong triggered items: %v", got)
+	}
+
+	ProcessEvent(Event{Type: EventKey, Ch: 'e', Mod: ModAlt})
+	ProcessEvent(keyEvent(term.KeyEsc))
+	if mb.IsOpen() || comp.consumer != nil {
+		t.Errorf("Esc must close the menu")
+	}
+}
+
+func TestMenuBarMouse(t *testing.T) {
+	scr := NewHeadlessScreen(60, 20)
+	InitLibrary(scr)
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 1, 30, 10, "Main", false, false)
+	mb := CreateMenuBar()
+	mb.AddMenu("~F~ile").AddItem("~Q~uit", "", nil)
+	clicked := 0
+	mb.AddMenu("~E~dit").AddItem("~C~opy", "", func(item *MenuItem) {
+		clicked++
+	})
+
+	x := int(mb.menuPos(1)) + 1
+	ProcessEvent(mouseAt(x, 0, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(x, 0, term.MouseRelease, 0))
+	if !mb.IsOpen() || mb.selected != 1 {
+		t.Fatalf("Click on the title must open the menu")
+	}
+
+	ProcessEvent(mouseAt(x, 2, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(x, 2, term.MouseRelease, 0))
+	if clicked != 1 || mb.IsOpen() {
+		t.Errorf("Click on the item must trigger it: %v", clicked)
+	}
+
+	ProcessEvent(mouseAt(x, 0, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(40, 15, term.MouseLeft, 0))
+	if mb.IsOpen() {
+		t.Errorf("Click outside must close the menu")
+	}
+
+	wnd.SetMaximized(true)
+	if _, y := wnd.Pos().Get(); y != 1 {
+		t.Errorf("Maximized window must be below the menu bar: %v", y)
+	}
+	ProcessEvent(Event{Type: EventResize, Width: 50, Height: 15})
+	if w, h := wnd.Size(); w != 50 || h != 14 {
+		t.Errorf("Maximized window must fit the desktop: %vx%v", w, h)
+	}
+	if w, _ := mb.Size(); w != 50 {
+		t.Errorf("Menu bar must follow the screen width: %v", w)
+	}
+}
+
+func TestMenuTallerThanScreen(t *testing.T) {
+	scr := NewHeadlessScreen(40, 8)
+	InitLibrary(scr)
+	defer DeinitLibrary()
+
+	mb := CreateMenuBar()
+	var got string
+	items := mb.AddMenu("~I~tems")
+	for i := 0; i < 20; i++ {
+		items.AddItem(fmt.Sprintf("Item %v", i), "", func(item *MenuItem) {
+			got = item.Title()
+		})
+	}
+
+	ProcessEvent(keyEvent(term.KeyF10))
+	RefreshScreen()
+	lines := scr.Lines()
+	if !strings.Contains(lines[0], "┌") || !strings.Contains(lines[7], "└") {
+		t.Fatalf("Drop-down must fit the screen:\n%v", scr.String())
+	}
+	if !strings.Contains(lines[1], "Item 0") || !strings.Contains(lines[6], "Item 5") {
+		t.Errorf("First items must be visible:\n%v", scr.String())
+	}
+
+	// the cursor scrolls the items
+	ProcessEvent(keyEvent(term.KeyEnd))
+	RefreshScreen()
+	lines = scr.Lines()
+	if !strings.Contains(lines[6], "Item 19") || !strings.Contains(lines[1], "Item 14") {
+		t.Errorf("End must scroll to the last item:\n%v", scr.String())
+	}
+	ProcessEvent(keyEvent(term.KeyEnter))
+	if got != "Item 19" {
+		t.Errorf("Enter must trigger the last item: %q", got)
+	}
+
+	// items are found at their scrolled positions
+	ProcessEvent(keyEvent(term.KeyF10))
+	ProcessEvent(mouseAt(5, 3, term.MouseWheelDown, 0))
+	ProcessEvent(mouseAt(5, 1, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(5, 1, term.MouseRelease, 0))
+	if got != "Item 1" {
+		t.Errorf("Click must trigger the scrolled item: %q", got)
+	}
+}
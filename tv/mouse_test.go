@@ -0,0 +1,215 @@
+package tv
+
+import (
+	"testing"
+
+	term "github.com/nsf/termbox-go"
+
+	"github.com/prospero78/goTV/tv/types"
+)
+
+// mouseProbe is a control that records mouse events it gets
+type mouseProbe struct {
+	TBaseControl
+	events []Event
+}
+
+func createMouseProbe(parent IControl, w, h int) *mouseProbe {
+	c := &mouseProbe{TBaseControl: NewBaseControl()}
+	c.parent = parent
+	c.SetSize(w, h)
+	c.SetConstraints(w, h)
+	c.SetScale(1)
+	parent.AddChild(c)
+	return c
+}
+
+// Draw does nothing: the probe is invisible
+func (c *mouseProbe) Draw() {
+}
+
+func (c *mouseProbe) ProcessEvent(ev Event) bool {
+	if ev.Type != EventActivate {
+		c.events = append(c.events, ev)
+	}
+	return true
+}
+
+func (c *mouseProbe) types() []EventType {
+	var res []EventType
+	for _, ev := range c.events {
+		res = append(res, ev.Type)
+	}
+	c.events = nil
+	return res
+}
+
+func sameTypes(a, b []EventType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func mouseAt(x, y int, key term.Key, mod term.Modifier) Event {
+	return Event{Type: EventMouse, X: types.ACoordX(x), Y: types.ACoordY(y), Key: key, Mod: mod}
+}
+
+func TestMouseEvents(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(40, 10))
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 0, 20, 6, "Mouse", false, false)
+	probe := createMouseProbe(wnd, 10, 3)
+	wnd.ResizeChildren()
+	wnd.PlaceChildren()
+	px, py := probe.Pos().Get()
+	x, y := int(px)+1, int(py)+1
+
+	ProcessEvent(mouseAt(x, y, term.MouseRight, 0))
+	ProcessEvent(mouseAt(x, y, term.MouseRelease, 0))
+	got := probe.types()
+	if !sameTypes(got, []EventType{EventMouse, EventMouse, EventMouseUp, EventRightClick}) {
+		t.Errorf("Right click: %v", got)
+	}
+
+	ProcessEvent(mouseAt(x, y, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(x, y, term.MouseRelease, 0))
+	ProcessEvent(mouseAt(x, y, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(x, y, term.MouseRelease, 0))
+	got = probe.types()
+	want := []EventType{EventMouse, EventMouse, EventMouseUp, EventClick,
+		EventMouse, EventDoubleClick, EventMouse, EventMouseUp, EventClick}
+	if !sameTypes(got, want) {
+		t.Errorf("Double click: %v", got)
+	}
+
+	ProcessEvent(mouseAt(x, y, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(x+1, y, term.MouseLeft, term.ModMotion))
+	ProcessEvent(mouseAt(x+1, y, term.MouseRelease, 0))
+	events := probe.events
+	got = probe.types()
+	if !sameTypes(got, []EventType{EventMouse, EventMouse, EventMouseDrag, EventMouse, EventMouseUp}) ||
+		events[2].Key != term.MouseLeft || events[4].Key != term.MouseLeft {
+		t.Errorf("Drag: %v", got)
+	}
+
+	ProcessEvent(mouseAt(x, y, term.MouseWheelDown, 0))
+	ProcessEvent(mouseAt(x, y, term.MouseRelease, term.ModMotion))
+	got = probe.types()
+	if !sameTypes(got, []EventType{EventMouseWheel}) {
+		t.Errorf("Hover must be off by default: %v", got)
+	}
+
+	SetMouseHover(true)
+	ProcessEvent(mouseAt(x, y, term.MouseRelease, term.ModMotion))
+	got = probe.types()
+	if !sameTypes(got, []EventType{EventMouseMove}) {
+		t.Errorf("Hover: %v", got)
+	}
+}
+
+func TestMouseWheelScrolls(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(60, 20))
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 0, 20, 8, "List", false, false)
+	wnd.SetPack(Vertical)
+	lbox := CreateListBox(wnd, 16, 4, 1)
+	for i := 0; i < 10; i++ {
+		lbox.AddItem("item")
+	}
+	lbox.SelectItem(0)
+	CreateEditField(wnd, 10, "", Fixed)
+	wnd.ResizeChildren()
+	wnd.PlaceChildren()
+	lx, ly := lbox.Pos().Get()
+
+	top := AddWindow(30, 0, 20, 8, "Top", false, false)
+	ProcessEvent(mouseAt(int(lx)+1, int(ly)+1, term.MouseWheelDown, 0))
+	if lbox.SelectedItem() != 0 || lbox.topLine != mouseWheelLines {
+		t.Errorf("Wheel must scroll inactive ListBox, top %v selected %v", lbox.topLine, lbox.SelectedItem())
+	}
+	if comp.topWindow() != top {
+		t.Errorf("Wheel must not activate the window")
+	}
+}
+
+func TestTableViewDoubleClick(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(60, 20))
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 0, 40, 12, "Table", false, false)
+	table := CreateTableView(wnd, 30, 8, 1)
+	table.SetColumns([]Column{{Title: "A", Width: 10}, {Title: "B", Width: 10}})
+	table.SetRowCount(3)
+	wnd.ResizeChildren()
+	wnd.PlaceChildren()
+
+	var actions []TableEvent
+	table.OnAction(func(ev TableEvent) {
+		actions = append(actions, ev)
+	})
+
+	tx, ty := table.Pos().Get()
+	x, y := int(tx)+2, int(ty)+3
+	for i := 0; i < 2; i++ {
+		ProcessEvent(mouseAt(x, y, term.MouseLeft, 0))
+		ProcessEvent(mouseAt(x, y, term.MouseRelease, 0))
+	}
+
+	if len(actions) != 1 || actions[0].Action != TableActionEdit || actions[0].Row != 1 {
+		t.Errorf("Double click must edit the cell: %+v", actions)
+	}
+
+	table.SetRowCount(20)
+	selected := 0
+	table.OnSelectCell(func(col, row int) {
+		selected++
+	})
+	ProcessEvent(mouseAt(x, y, term.MouseWheelDown, 0))
+	if _, first, _, _ := table.VisibleArea(); first != mouseWheelLines || table.SelectedRow() != 1 || selected != 0 {
+		t.Errorf("Wheel must scroll rows without selection: %v, row %v", first, table.SelectedRow())
+	}
+}
+
+func TestMouseWheelScrollsFrame(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(60, 20))
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 0, 20, 8, "Frame", false, false)
+	frame := CreateFrame(wnd, 16, 4, BorderNone, 1)
+	frame.SetPack(Vertical)
+	frame.SetScrollable(true)
+	for i := 0; i < 10; i++ {
+		CreateLabel(frame, 10, 1, "line", Fixed)
+	}
+	wnd.ResizeChildren()
+	wnd.PlaceChildren()
+
+	fx, fy := frame.Pos().Get()
+	ProcessEvent(mouseAt(int(fx)+1, int(fy)+1, term.MouseWheelUp, 0))
+	if _, y := frame.Pos().Get(); y != fy {
+		t.Errorf("Frame must not scroll above its first line: %v -> %v", fy, y)
+	}
+
+	ProcessEvent(mouseAt(int(fx)+1, int(fy)+1, term.MouseWheelDown, 0))
+	if _, y := frame.Pos().Get(); y != fy-mouseWheelLines {
+		t.Errorf("Frame must scroll down: %v -> %v", fy, y)
+	}
+	for i := 0; i < 10; i++ {
+		ProcessEvent(mouseAt(int(fx)+1, int(fy)+1, term.MouseWheelDown, 0))
+	}
+	ProcessEvent(mouseAt(int(fx)+1, int(fy)+1, term.MouseWheelUp, 0))
+	ProcessEvent(mouseAt(int(fx)+1, int(fy)+1, term.MouseWheelUp, 0))
+	ProcessEvent(mouseAt(int(fx)+1, int(fy)+1, term.MouseWheelUp, 0))
+	ProcessEvent(mouseAt(int(fx)+1, int(fy)+1, term.MouseWheelUp, 0))
+	if _, y := frame.Pos().Get(); y != fy {
+		t.Errorf("Frame must scroll back: %v -> %v", fy, y)
+	}
+}
@@ -88,7 +88,7 @@ func (e *EditField) ProcessEvent(event tv.Event) bool {
 	}
 
 	if event.Type == tv.EventActivate && event.X == 0 {
-		term.HideCursor()
+		tv.HideCursor()
 	}
 
 	if event.Type == tv.EventMouse && event.Key == term.MouseLeft {
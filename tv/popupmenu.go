@@ -0,0 +1,145 @@
+package tv
+
+import (
+	term "github.com/nsf/termbox-go"
+
+	"github.com/prospero78/goTV/tv/types"
+)
+
+/*
+PopupMenu is a context menu shown above all windows, e.g. at the mouse
+position after a right click or at the selected row of a TableView. While
+the menu is shown it grabs all events. The menu is closed when a user
+triggers an item, presses Esc or clicks outside the menu.
+Keyboard and mouse work the same way as in MenuBar drop-downs.
+The chosen item calls its callbacks and then OnClose callback is called.
+Result returns the chosen item or nil if the menu was canceled:
+
+	pm := tv.CreatePopupMenuAt(menu, table)
+	pm.OnClose(func() {
+		if item := pm.Result(); item != nil {
+			// item is chosen
+		}
+	})
+*/
+type PopupMenu struct {
+	TBaseControl
+	stack   menuStack
+	result  *MenuItem
+	onClose func()
+}
+
+// CreatePopupMenu shows the menu with the top left corner at the screen
+// position x, y. The menu is moved to fit the screen
+func CreatePopupMenu(menu *Menu, x types.ACoordX, y types.ACoordY) *PopupMenu {
+	pm := &PopupMenu{TBaseControl: NewBaseControl()}
+	pm.stack.open(menu, x, y)
+	box := pm.stack.last()
+	pm.SetPos(box.x, box.y)
+	pm.SetSize(box.w, box.h)
+
+	comp.showOverlay(pm)
+	GrabEvents(pm)
+	return pm
+}
+
+// CreatePopupMenuAt shows the menu below the current item of the
+// control: the selected row of ListBox, the selected cell of TableView
+// or the first line of other controls
+func CreatePopupMenuAt(menu *Menu, ctrl IControl) *PopupMenu {
+	x, y := PopupPos(ctrl)
+	return CreatePopupMenu(menu, x, y+1)
+}
+
+// PopupPos returns the screen position of the current item of the
+// control: the selected row of ListBox, the selected cell of TableView
+// or the control top left corner
+func PopupPos(ctrl IControl) (types.ACoordX, types.ACoordY) {
+	if p, ok := ctrl.(interface {
+		popupPos() (types.ACoordX, types.ACoordY)
+	}); ok {
+		return p.popupPos()
+	}
+	return ctrl.Pos().Get()
+}
+
+// Close hides the menu without choosing an item
+func (pm *PopupMenu) Close() {
+	pm.finish(nil)
+}
+
+// Result returns the chosen item or nil if the menu was canceled or is
+// still shown
+func (pm *PopupMenu) Result() *MenuItem {
+	return pm.result
+}
+
+// OnClose sets the callback that is called after the menu is closed
+func (pm *PopupMenu) OnClose(fn func()) {
+	pm.onClose = fn
+}
+
+// IsOpen returns true while the menu is shown
+func (pm *PopupMenu) IsOpen() bool {
+	return pm.stack.isOpen()
+}
+
+// finish closes the menu, triggers the chosen item and calls OnClose
+func (pm *PopupMenu) finish(item *MenuItem) {
+	if !pm.stack.isOpen() {
+		return
+	}
+
+	pm.stack.close()
+	pm.result = item
+	comp.hideOverlay(pm)
+	if comp.consumer == pm {
+		ReleaseEvents()
+	}
+
+	if item != nil {
+		item.trigger()
+	}
+	if pm.onClose != nil {
+		pm.onClose()
+	}
+}
+
+// Draw repaints the menu and its open submenus
+func (pm *PopupMenu) Draw() {
+	pm.stack.draw()
+}
+
+// ProcessEvent processes keyboard and mouse events while the menu is shown
+func (pm *PopupMenu) ProcessEvent(ev Event) bool {
+	if !pm.stack.isOpen() {
+		return false
+	}
+
+	switch ev.Type {
+	case EventKey:
+		item, ok := pm.stack.processKey(ev)
+		switch {
+		case ok:
+			if item != nil {
+				pm.finish(item)
+			}
+		case ev.Key == term.KeyEsc:
+			pm.finish(nil)
+		}
+		return true
+	case EventMouse:
+		item, inside := pm.stack.processMouse(ev)
+		switch {
+		case inside:
+			if item != nil {
+				pm.finish(item)
+			}
+		case ev.Mod&term.ModMotion == 0 &&
+			(ev.Key == term.MouseLeft || ev.Key == term.MouseRight || ev.Key == term.MouseMiddle):
+			pm.finish(nil)
+		}
+		return true
+	}
+	return false
+}
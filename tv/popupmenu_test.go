@@ -0,0 +1,108 @@
+package tv
+
+import (
+	"strings"
+	"testing"
+
+	term "github.com/nsf/termbox-go"
+)
+
+func TestPopupMenuTable(t *testing.T) {
+	scr := NewHeadlessScreen(60, 20)
+	InitLibrary(scr)
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 0, 40, 12, "Table", false, false)
+	table := CreateTableView(wnd, 30, 8, 1)
+	table.SetColumns([]Column{{Title: "A", Width: 10}, {Title: "B", Width: 10}})
+	table.SetRowCount(5)
+	wnd.ResizeChildren()
+	wnd.PlaceChildren()
+
+	menu := NewMenu("")
+	menu.AddItem("~E~dit row", "", nil)
+	deleted := -1
+	menu.AddItem("~D~elete row", "", func(item *MenuItem) {
+		deleted = table.SelectedRow()
+	})
+
+	var pm *PopupMenu
+	closed := 0
+	table.OnAction(func(ev TableEvent) {
+		if ev.Action == TableActionMenu {
+			pm = CreatePopupMenuAt(menu, table)
+			pm.OnClose(func() {
+				closed++
+			})
+		}
+	})
+
+	tx, ty := table.Pos().Get()
+	x, y := int(tx)+12, int(ty)+4
+	ProcessEvent(mouseAt(x, y, term.MouseRight, 0))
+	ProcessEvent(mouseAt(x, y, term.MouseRelease, 0))
+	if pm == nil || table.SelectedRow() != 2 || table.SelectedCol() != 1 {
+		t.Fatalf("Right click must select the cell and emit TableActionMenu")
+	}
+	if px, py := PopupPos(table); int(px) != int(tx)+10 || int(py) != y {
+		t.Errorf("Popup must be at the selected cell: %v:%v", px, py)
+	}
+	if comp.consumer != pm {
+		t.Errorf("Popup must grab events")
+	}
+	RefreshScreen()
+	if line := scr.Lines()[y+2]; !strings.Contains(line, "Edit row") {
+		t.Errorf("Popup is not drawn below the cell: <%v>", line)
+	}
+
+	ProcessEvent(keyEvent(term.KeyArrowDown))
+	ProcessEvent(keyEvent(term.KeyEnter))
+	if deleted != 2 || pm.Result() != menu.Items()[1] || closed != 1 || pm.IsOpen() {
+		t.Errorf("Enter must choose the item and close the popup")
+	}
+	if comp.consumer != nil || len(comp.overlays) != 0 {
+		t.Errorf("Closed popup must release events")
+	}
+
+	table.SetActive(true)
+	ProcessEvent(Event{Type: EventKey, Key: term.KeyF10, Mod: ModShift})
+	ProcessEvent(keyEvent(term.KeyEsc))
+	if pm.Result() != nil || closed != 2 || comp.consumer != nil {
+		t.Errorf("Esc must cancel the popup")
+	}
+
+	ProcessEvent(Event{Type: EventKey, Key: term.KeyF10, Mod: ModShift})
+	ProcessEvent(mouseAt(55, 18, term.MouseLeft, 0))
+	if pm.IsOpen() || pm.Result() != nil || closed != 3 {
+		t.Errorf("Click outside must cancel the popup")
+	}
+}
+
+func TestPopupMenuListBox(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(60, 20))
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 0, 30, 10, "List", false, false)
+	lbox := CreateListBox(wnd, 20, 5, 1)
+	for _, s := range []string{"one", "two", "three"} {
+		lbox.AddItem(s)
+	}
+	wnd.ResizeChildren()
+	wnd.PlaceChildren()
+
+	var got []string
+	lbox.OnContextMenu(func(ev Event) {
+		got = append(got, ev.Msg)
+	})
+
+	lx, ly := lbox.Pos().Get()
+	ProcessEvent(mouseAt(int(lx)+1, int(ly)+2, term.MouseRight, 0))
+	ProcessEvent(mouseAt(int(lx)+1, int(ly)+2, term.MouseRelease, 0))
+	ProcessEvent(Event{Type: EventKey, Key: term.KeyF10, Mod: ModShift})
+	if strings.Join(got, ",") != "three,three" || lbox.SelectedItem() != 2 {
+		t.Errorf("Right click must select the item: %v", got)
+	}
+	if _, y := PopupPos(lbox); y != ly+2 {
+		t.Errorf("Popup must be at the selected item: %v", y)
+	}
+}
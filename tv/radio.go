@@ -11,6 +11,7 @@ import (
 /*
 Radio button control. Unite a few radios in one radio group to
 make a user select one of available choices.
+Title can contain an accelerator: "~L~eft" selects the radio with Alt+L.
 */
 type Radio struct {
 	TBaseControl
@@ -37,7 +38,7 @@ func CreateRadio(parent IControl, width int, title string, scale int) *Radio {
 	}
 
 	if width == 0 {
-		width = xs.Len(title) + 4
+		width = xs.Len(stripHotkey(title)) + 4
 		c.autoWidth.Set()
 	}
 
@@ -99,7 +100,11 @@ func (c *Radio) Draw() {
 		return
 	}
 
-	shift, text := AlignColorizedText(c.title, w-4, c.align)
+	title := stripHotkey(c.title)
+	if c.Enabled() {
+		title = hotkeyTitle(c.title, RealColor(ColorDefault, c.Style(), ColorControlHotkeyText))
+	}
+	shift, text := AlignColorizedText(title, w-4, c.align)
 	DrawText(x+types.ACoordX(4+shift), y, text)
 }
 
@@ -0,0 +1,264 @@
+package tv
+
+import (
+	"github.com/prospero78/goTV/tv/types"
+)
+
+/*
+The main loop repaints the screen in frames. A frame is one event (or one
+function queued with Post) processed by the loop followed by a single
+repaint and a single Flush of the screen backend.
+
+Everything that asks for a repaint while the frame is processed only marks
+a part of the screen as damaged: RefreshScreen and EventRedraw without
+Target mark the whole screen, Invalidate and EventRedraw with Target mark
+the control, InvalidateRect marks a rectangle. At the end of the frame
+only windows that intersect the damaged area (and windows above them) are
+repainted. User input damages only the window that has processed it, or
+the whole screen if the event has moved, resized, shown, hidden, added or
+removed a window. A handler that changes other windows must call Invalidate
+or RefreshScreen.
+
+Redraw requests sent with PutEvent from other goroutines are merged: all
+requests made before the loop gets to the next frame are painted in that
+frame.
+*/
+
+// Invalidate marks the control as needing repaint. At the end of the
+// current frame only the window that holds the control and windows that
+// overlap it are repainted. Must be called from the main loop, e.g. from
+// an event handler or from a function passed to Post
+func Invalidate(ctrl IControl) {
+	loop.invalidate(ctrl, nil)
+}
+
+// InvalidateRect marks the screen rectangle as needing repaint at the end
+// of the current frame. Must be called from the main loop
+func InvalidateRect(x types.ACoordX, y types.ACoordY, w, h int) {
+	loop.invalidate(nil, &rect{x: x, y: y, w: w, h: h})
+}
+
+// invalidate records damage made by the main loop code
+func (l *mainLoop) invalidate(ctrl IControl, r *rect) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.touched = true
+	if ctrl != nil {
+		l.damagedCtrls = append(l.damagedCtrls, ctrl)
+	}
+	if r != nil {
+		l.damagedRects = append(l.damagedRects, *r)
+	}
+}
+
+// windowState is the place of a window on the screen
+type windowState struct {
+	wnd     IControl
+	bounds  rect
+	visible bool
+}
+
+// windowLayout returns the places of all windows from bottom to top
+func windowLayout() []windowState {
+	windows := comp.getWindowList()
+	layout := make([]windowState, len(windows))
+	for i, wnd := range windows {
+		x, y := wnd.Pos().Get()
+		w, h := wnd.Size()
+		layout[i] = windowState{wnd: wnd, bounds: rect{x: x, y: y, w: w, h: h}, visible: wnd.Visible()}
+	}
+	return layout
+}
+
+// sameLayout returns true if windows have the same order and places
+func sameLayout(a, b []windowState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// invalidateAll marks the whole screen as damaged
+func (l *mainLoop) invalidateAll() {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.touched = true
+	l.damagedAll = true
+}
+
+// requestRedraw is a goroutine safe way to damage the control or the
+// whole screen if target is nil. The loop is woken up only if there is
+// no pending redraw request yet
+func (l *mainLoop) requestRedraw(target IControl) {
+	l.mtx.Lock()
+	if target == nil {
+		l.damagedAll = true
+	} else {
+		l.damagedCtrls = append(l.damagedCtrls, target)
+	}
+	wake := !l.redrawPending
+	l.redrawPending = true
+	l.mtx.Unlock()
+
+	if wake {
+		l.send(Event{Type: EventRedraw})
+	}
+}
+
+// takeDamage returns the damaged area and resets it
+func (l *mainLoop) takeDamage() (all bool, rects []rect) {
+	l.mtx.Lock()
+	all, ctrls, rects := l.damagedAll, l.damagedCtrls, l.damagedRects
+	l.damagedAll, l.damagedCtrls, l.damagedRects = false, nil, nil
+	l.redrawPending = false
+	l.mtx.Unlock()
+
+	if all {
+		return true, nil
+	}
+	for _, ctrl := range ctrls {
+		x, y := ctrl.Pos().Get()
+		w, h := ctrl.Size()
+		rects = append(rects, rect{x: x, y: y, w: w, h: h})
+	}
+	return false, rects
+}
+
+// frame processes one event inside the application and repaints the
+// damaged part of the screen
+func (a *Application) frame(fn func()) {
+	a.Do(func() {
+		a.loop.inFrame = true
+		func() {
+			defer func() { a.loop.inFrame = false }()
+			fn()
+		}()
+
+		all, rects := a.loop.takeDamage()
+		switch {
+		case all:
+			repaintAll()
+		case len(rects) > 0:
+			repaintRects(rects)
+		}
+	})
+}
+
+// repaintAll clears the screen and draws all visible windows
+func repaintAll() {
+	comp.BeginUpdate()
+	_ = canvas.screen.Clear(ColorWhite, ColorBlack)
+	comp.EndUpdate()
+
+	for _, wnd := range comp.getWindowList() {
+		if wnd.Visible() {
+			wnd.Draw()
+		}
+	}
+	drawOverlays()
+
+	comp.BeginUpdate()
+	_ = canvas.screen.Flush()
+	comp.EndUpdate()
+}
+
+// repaintRects draws only windows that intersect the damaged rectangles.
+// If a window covers a rectangle completely, windows below it are not
+// drawn. A drawn window damages its whole area, so all windows above it
+// that overlap it are drawn as well
+func repaintRects(rects []rect) {
+	windows := comp.getWindowList()
+	bounds := make([]rect, len(windows))
+	need := make([]bool, len(windows))
+	for i, wnd := range windows {
+		x, y := wnd.Pos().Get()
+		w, h := wnd.Size()
+		bounds[i] = rect{x: x, y: y, w: w, h: h}
+	}
+
+	var damaged []rect
+	for _, r := range rects {
+		first := -1
+		for i := len(windows) - 1; i >= 0; i-- {
+			if windows[i].Visible() && bounds[i].contains(r) {
+				first = i
+				break
+			}
+		}
+		if first == -1 {
+			clearRect(r)
+			damaged = append(damaged, r)
+			first = 0
+		}
+
+		for i := first; i < len(windows); i++ {
+			if bounds[i].intersects(r) {
+				need[i] = true
+			}
+		}
+	}
+
+	for i, wnd := range windows {
+		if !wnd.Visible() {
+			continue
+		}
+		for _, d := range damaged {
+			if need[i] {
+				break
+			}
+			need[i] = bounds[i].intersects(d)
+		}
+		if need[i] {
+			wnd.Draw()
+			damaged = append(damaged, bounds[i])
+		}
+	}
+	drawOverlays()
+
+	comp.BeginUpdate()
+	_ = canvas.screen.Flush()
+	comp.EndUpdate()
+}
+
+// drawOverlays draws the parts of the screen that are always above
+// windows: the status bar, the menu bar with its drop-downs and popups.
+// They are small, so they are drawn after every repaint
+func drawOverlays() {
+	if comp.statusBar != nil {
+		comp.statusBar.Draw()
+	}
+	if comp.menuBar != nil {
+		comp.menuBar.Draw()
+	}
+	for _, view := range comp.overlays {
+		view.Draw()
+	}
+}
+
+// clearRect fills the rectangle with the desktop background
+func clearRect(r rect) {
+	for y := r.y; y < r.y+types.ACoordY(r.h); y++ {
+		for x := r.x; x < r.x+types.ACoordX(r.w); x++ {
+			canvas.screen.SetCell(int(x), int(y), ' ', ColorWhite, ColorBlack)
+		}
+	}
+}
+
+func (r rect) intersects(o rect) bool {
+	return r.w > 0 && r.h > 0 && o.w > 0 && o.h > 0 &&
+		r.x < o.x+types.ACoordX(o.w) && o.x < r.x+types.ACoordX(r.w) &&
+		r.y < o.y+types.ACoordY(o.h) && o.y < r.y+types.ACoordY(r.h)
+}
+
+func (r rect) contains(o rect) bool {
+	return o.x >= r.x && o.y >= r.y &&
+		o.x+types.ACoordX(o.w) <= r.x+types.ACoordX(r.w) &&
+		o.y+types.ACoordY(o.h) <= r.y+types.ACoordY(r.h)
+}
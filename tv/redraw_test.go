@@ -0,0 +1,244 @@
+package tv
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	term "github.com/nsf/termbox-go"
+
+	"github.com/prospero78/goTV/tv/types"
+)
+
+func TestRedrawRequestsMerged(t *testing.T) {
+	scr := NewHeadlessScreen(30, 5)
+	stop := startLoopOn(t, scr)
+	defer stop()
+
+	release := make(chan struct{})
+	before := 0
+	Post(func() {
+		<-release
+		before = scr.FlushCount()
+	})
+	for i := 0; i < 100; i++ {
+		PutEvent(Event{Type: EventRedraw})
+	}
+	close(release)
+
+	after := 0
+	Invoke(func() {
+		after = scr.FlushCount()
+	})
+
+	if after-before != 1 {
+		t.Errorf("Redraw requests must be merged into one frame, got %v flushes", after-before)
+	}
+}
+
+func TestInvalidateRepaintsOnlyWindow(t *testing.T) {
+	scr := NewHeadlessScreen(40, 8)
+	stop := startLoopOn(t, scr)
+	defer stop()
+
+	var lbl1, lbl2 *Label
+	Invoke(func() {
+		wnd1 := AddWindow(0, 0, 15, 3, "One", false, false)
+		lbl1 = CreateLabel(wnd1, 8, 1, "old1", Fixed)
+		wnd2 := AddWindow(20, 0, 15, 3, "Two", false, false)
+		lbl2 = CreateLabel(wnd2, 8, 1, "old2", Fixed)
+	})
+
+	Invoke(func() {
+		lbl1.SetTitle("new1")
+		lbl2.SetTitle("new2")
+		Invalidate(lbl1)
+	})
+	text := scr.String()
+	if !strings.Contains(text, "new1") || !strings.Contains(text, "old2") {
+		t.Errorf("Only the invalidated window must be repainted:\n%v", text)
+	}
+
+	Invoke(func() {})
+	text = scr.String()
+	if !strings.Contains(text, "new1") || !strings.Contains(text, "new2") {
+		t.Errorf("Function that invalidates nothing must repaint the whole screen:\n%v", text)
+	}
+}
+
+func TestInputRepaintsOnlyHandlerWindow(t *testing.T) {
+	scr := NewHeadlessScreen(40, 8)
+	stop := startLoopOn(t, scr)
+	defer stop()
+
+	var lbl *Label
+	var edit *TEditField
+	Invoke(func() {
+		wnd1 := AddWindow(0, 0, 15, 3, "One", false, false)
+		lbl = CreateLabel(wnd1, 8, 1, "old1", Fixed)
+		wnd2 := AddWindow(20, 0, 15, 3, "Two", false, false)
+		edit = CreateEditField(wnd2, 8, "", Fixed)
+		ActivateControl(wnd2, edit)
+	})
+	Invoke(func() {
+		lbl.SetTitle("new1")
+		Invalidate(edit)
+	})
+
+	scr.InjectRune('x')
+	title := ""
+	deadline := time.Now().Add(5 * time.Second)
+	for title == "" && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		Invoke(func() {
+			title = edit.Title()
+			Invalidate(edit)
+		})
+	}
+	text := scr.String()
+	if !strings.Contains(text, "║x") || !strings.Contains(text, "old1") {
+		t.Errorf("Key must repaint only the active window:\n%v", text)
+	}
+
+	// activation of another window changes the window order
+	scr.InjectEvent(Event{Type: EventMouse, Key: term.MouseLeft, X: 2, Y: 1})
+	deadline = time.Now().Add(5 * time.Second)
+	for !strings.Contains(text, "new1") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		Invoke(func() {
+			Invalidate(edit)
+		})
+		text = scr.String()
+	}
+	if !strings.Contains(text, "new1") {
+		t.Errorf("Window activation must repaint the screen:\n%v", text)
+	}
+}
+
+func TestInvalidateOverlappedWindow(t *testing.T) {
+	scr := NewHeadlessScreen(40, 10)
+	stop := startLoopOn(t, scr)
+	defer stop()
+
+	var lbl *Label
+	Invoke(func() {
+		wnd := AddWindow(0, 0, 20, 6, "Below", false, false)
+		wnd.SetPack(Vertical)
+		lbl = CreateLabel(wnd, 16, 1, "below", Fixed)
+		AddWindow(5, 1, 20, 6, "Above", false, false)
+	})
+
+	Invoke(func() {
+		lbl.SetTitle("changed label")
+		Invalidate(lbl)
+	})
+	partial := scr.String()
+
+	Invoke(RefreshScreen)
+	full := scr.String()
+
+	if partial != full {
+		t.Errorf("Partial repaint differs from full one:\n%v\n--\n%v", partial, full)
+	}
+	if !strings.Contains(partial, "chan╔") {
+		t.Errorf("Window above must stay on top:\n%v", partial)
+	}
+
+	Invoke(func() {
+		InvalidateRect(30, 8, 5, 2)
+	})
+	if scr.String() != full {
+		t.Errorf("Repainting the desktop must not change windows:\n%v", scr.String())
+	}
+}
+
+func startLoopOn(t *testing.T, scr Screen) func() {
+	InitLibrary(scr)
+
+	done := make(chan struct{})
+	go func() {
+		MainLoop()
+		close(done)
+	}()
+
+	return func() {
+		Stop()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("MainLoop did not finish")
+		}
+		DeinitLibrary()
+	}
+}
+
+// createDashboard fills the screen with windows that have a chart each
+func createDashboard() []*SparkChart {
+	var charts []*SparkChart
+	for i := 0; i < 8; i++ {
+		x, y := (i%4)*20, (i/4)*12
+		wnd := AddWindow(types.ACoordX(x), types.ACoordY(y), 20, 12, "Metric", false, false)
+		chart := CreateSparkChart(wnd, 16, 8, 1)
+		chart.SetData([]float64{1, 5, 3, 8, 2, 7})
+		wnd.ResizeChildren()
+		wnd.PlaceChildren()
+		charts = append(charts, chart)
+	}
+
+	return charts
+}
+
+// legacyRefresh repaints the screen the way RefreshScreen did before
+// frames were introduced: it clears the screen and flushes it after
+// every window
+func legacyRefresh() {
+	_ = canvas.screen.Clear(ColorWhite, ColorBlack)
+	for _, wnd := range comp.getWindowList() {
+		wnd.Draw()
+		_ = canvas.screen.Flush()
+	}
+	_ = canvas.screen.Flush()
+}
+
+func newBenchScreen() Screen {
+	rw := struct {
+		io.Reader
+		io.Writer
+	}{strings.NewReader(""), ioutil.Discard}
+	return NewSessionScreen(rw, "xterm", 80, 24)
+}
+
+// BenchmarkChartUpdateLegacy measures one chart update on a dashboard
+// with the old repaint: the whole screen is redrawn and flushed many
+// times per update
+func BenchmarkChartUpdateLegacy(b *testing.B) {
+	InitLibrary(newBenchScreen())
+	defer DeinitLibrary()
+	charts := createDashboard()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		charts[0].AddData(float64(i % 10))
+		legacyRefresh()
+	}
+}
+
+// BenchmarkChartUpdateFrame measures one chart update on a dashboard
+// when only the chart window is invalidated and the screen is flushed
+// once
+func BenchmarkChartUpdateFrame(b *testing.B) {
+	InitLibrary(newBenchScreen())
+	defer DeinitLibrary()
+	charts := createDashboard()
+	app := DefaultApplication()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		app.frame(func() {
+			charts[0].AddData(float64(i % 10))
+			Invalidate(charts[0])
+		})
+	}
+}
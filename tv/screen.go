@@ -0,0 +1,58 @@
+package tv
+
+import (
+	term "github.com/nsf/termbox-go"
+)
+
+/*
+Screen is a backend that the library uses to output the picture and to
+read user input. Canvas writes all cells through the Screen and MainLoop
+reads events from it, so the library does not depend on a real terminal.
+Termbox based backend is used by default (see NewTermboxScreen), but any
+other implementation can be passed to InitLibrary: e.g, a backend for
+another terminal library, a test double or a remote session.
+
+Colors and keys use the same values as termbox does: term.Attribute,
+term.Key and term.Modifier, so a backend has to convert its own values
+to them.
+*/
+type Screen interface {
+	// Init prepares the backend for drawing and reading events. It is
+	// called once by InitLibrary
+	Init() error
+	// Close restores the backend state and releases all resources
+	Close()
+	// Size returns the current screen width and height in cells
+	Size() (width int, height int)
+	// SetCell changes the rune and colors of the cell at x, y. The change
+	// must not be visible until Flush is called
+	SetCell(x, y int, ch rune, fg, bg term.Attribute)
+	// Cell returns the rune and colors of the cell at x, y from the back
+	// buffer
+	Cell(x, y int) term.Cell
+	// Clear fills the back buffer with spaces of the given colors
+	Clear(fg, bg term.Attribute) error
+	// SetCursor moves the text caret to x, y and shows it
+	SetCursor(x, y int)
+	// HideCursor makes the text caret invisible
+	HideCursor()
+	// Flush makes everything drawn since the last call visible
+	Flush() error
+	// PollEvent waits for the next user event and returns it. Errors are
+	// reported as events with type EventError and filled Err field
+	PollEvent() Event
+	// Interrupt makes PollEvent that is waiting for an event return an
+	// event with type EventInterrupt. If nobody waits, the next PollEvent
+	// call returns it. Interrupt may block until PollEvent takes the
+	// interruption (termbox does), so the caller must make sure that
+	// PollEvent is called
+	Interrupt()
+}
+
+// HoverScreen is implemented by screens that can report mouse moves
+// without pressed buttons. Most terminals do not send them unless asked,
+// see SetMouseHover
+type HoverScreen interface {
+	// SetMouseHover turns reporting of all mouse moves on or off
+	SetMouseHover(on bool)
+}
@@ -0,0 +1,267 @@
+package tv
+
+import (
+	"strings"
+	"sync"
+
+	term "github.com/nsf/termbox-go"
+
+	"github.com/prospero78/goTV/tv/types"
+)
+
+// headlessQueueSize is the number of injected events that HeadlessScreen
+// keeps before Inject* calls start blocking
+const headlessQueueSize = 256
+
+/*
+HeadlessScreen is an in-memory Screen that does not require a terminal.
+It keeps two cell grids like termbox does: the back one that the library
+draws to and the front one that is updated by Flush. Events are injected
+by test code with Inject* methods and are returned by PollEvent in the same
+order, so the library processes them as if they came from a real terminal.
+Use it to test windows and controls with 'go test':
+
+	scr := tv.NewHeadlessScreen(80, 25)
+	tv.InitLibrary(scr)
+	tv.AddWindow(0, 0, 20, 5, "Hello", false, false)
+	tv.RefreshScreen()
+	lines := scr.Lines()
+*/
+type HeadlessScreen struct {
+	mtx       sync.RWMutex
+	width     int
+	height    int
+	back      []term.Cell
+	front     []term.Cell
+	cursorX   int
+	cursorY   int
+	flushes   int
+	events    chan Event
+	interrupt chan struct{}
+}
+
+// NewHeadlessScreen creates a new in-memory screen of the given size
+func NewHeadlessScreen(width, height int) *HeadlessScreen {
+	s := &HeadlessScreen{
+		cursorX:   -1,
+		cursorY:   -1,
+		events:    make(chan Event, headlessQueueSize),
+		interrupt: make(chan struct{}, 1),
+	}
+	s.resize(width, height)
+
+	return s
+}
+
+func (s *HeadlessScreen) resize(width, height int) {
+	if width < 0 {
+		width = 0
+	}
+	if height < 0 {
+		height = 0
+	}
+
+	copyCells := func(old []term.Cell) []term.Cell {
+		cells := make([]term.Cell, width*height)
+		for i := range cells {
+			cells[i] = term.Cell{Ch: ' ', Fg: ColorWhite, Bg: ColorBlack}
+		}
+		for y := 0; y < height && y < s.height; y++ {
+			for x := 0; x < width && x < s.width; x++ {
+				cells[y*width+x] = old[y*s.width+x]
+			}
+		}
+		return cells
+	}
+
+	s.back = copyCells(s.back)
+	s.front = copyCells(s.front)
+	s.width, s.height = width, height
+}
+
+// Init does nothing: the screen is ready right after creation
+func (s *HeadlessScreen) Init() error {
+	return nil
+}
+
+// Close does nothing: the screen does not hold any resources
+func (s *HeadlessScreen) Close() {
+}
+
+// Size returns the screen size
+func (s *HeadlessScreen) Size() (width int, height int) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.width, s.height
+}
+
+// SetCell changes the cell in the back buffer. Cells outside the screen
+// are skipped
+func (s *HeadlessScreen) SetCell(x, y int, ch rune, fg, bg term.Attribute) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if x < 0 || x >= s.width || y < 0 || y >= s.height {
+		return
+	}
+	s.back[y*s.width+x] = term.Cell{Ch: ch, Fg: fg, Bg: bg}
+}
+
+// Cell returns the cell from the back buffer
+func (s *HeadlessScreen) Cell(x, y int) term.Cell {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if x < 0 || x >= s.width || y < 0 || y >= s.height {
+		return term.Cell{Ch: ' '}
+	}
+	return s.back[y*s.width+x]
+}
+
+// Clear fills the back buffer with spaces
+func (s *HeadlessScreen) Clear(fg, bg term.Attribute) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for i := range s.back {
+		s.back[i] = term.Cell{Ch: ' ', Fg: fg, Bg: bg}
+	}
+	return nil
+}
+
+// SetCursor remembers the caret position
+func (s *HeadlessScreen) SetCursor(x, y int) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.cursorX, s.cursorY = x, y
+}
+
+// HideCursor makes the caret invisible
+func (s *HeadlessScreen) HideCursor() {
+	s.SetCursor(-1, -1)
+}
+
+// Flush copies the back buffer to the front one
+func (s *HeadlessScreen) Flush() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	copy(s.front, s.back)
+	s.flushes++
+	return nil
+}
+
+// PollEvent returns the next injected event. It blocks until an event
+// is injected or Interrupt is called
+func (s *HeadlessScreen) PollEvent() Event {
+	select {
+	case ev := <-s.events:
+		return ev
+	case <-s.interrupt:
+		return Event{Type: EventInterrupt}
+	}
+}
+
+// Interrupt makes PollEvent return EventInterrupt
+func (s *HeadlessScreen) Interrupt() {
+	select {
+	case s.interrupt <- struct{}{}:
+	default:
+	}
+}
+
+// InjectEvent puts any event to the screen event queue
+func (s *HeadlessScreen) InjectEvent(ev Event) {
+	s.events <- ev
+}
+
+// InjectKey emulates a key press. For printable characters key must be 0
+// and ch must be the character
+func (s *HeadlessScreen) InjectKey(key term.Key, ch rune, mod term.Modifier) {
+	s.InjectEvent(Event{Type: EventKey, Key: key, Ch: ch, Mod: mod})
+}
+
+// InjectRune emulates typing a printable character
+func (s *HeadlessScreen) InjectRune(ch rune) {
+	s.InjectKey(0, ch, 0)
+}
+
+// InjectMouse emulates a mouse event at x, y: button press (term.MouseLeft
+// etc), button release (term.MouseRelease) or a motion if mod is
+// term.ModMotion
+func (s *HeadlessScreen) InjectMouse(x types.ACoordX, y types.ACoordY, key term.Key, mod term.Modifier) {
+	s.InjectEvent(Event{Type: EventMouse, X: x, Y: y, Key: key, Mod: mod})
+}
+
+// InjectResize changes the screen size and emulates terminal resize. The
+// content that fits the new size is preserved
+func (s *HeadlessScreen) InjectResize(width, height int) {
+	s.mtx.Lock()
+	s.resize(width, height)
+	s.mtx.Unlock()
+
+	s.InjectEvent(Event{Type: EventResize, Width: width, Height: height})
+}
+
+// Pending returns the number of injected events that are not read yet
+func (s *HeadlessScreen) Pending() int {
+	return len(s.events)
+}
+
+// CellAt returns the visible cell at x, y, i.e. the cell as it was at the
+// moment of the last Flush
+func (s *HeadlessScreen) CellAt(x, y int) term.Cell {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if x < 0 || x >= s.width || y < 0 || y >= s.height {
+		return term.Cell{Ch: ' '}
+	}
+	return s.front[y*s.width+x]
+}
+
+// Cursor returns the caret position and whether it is visible
+func (s *HeadlessScreen) Cursor() (x, y int, visible bool) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.cursorX, s.cursorY, s.cursorX >= 0 && s.cursorY >= 0
+}
+
+// FlushCount returns how many times the screen was flushed
+func (s *HeadlessScreen) FlushCount() int {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.flushes
+}
+
+// Lines returns the visible screen content as text: one string per
+// screen row. Colors are dropped
+func (s *HeadlessScreen) Lines() []string {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	lines := make([]string, s.height)
+	var sb strings.Builder
+	for y := 0; y < s.height; y++ {
+		sb.Reset()
+		for x := 0; x < s.width; x++ {
+			ch := s.front[y*s.width+x].Ch
+			if ch == 0 {
+				ch = ' '
+			}
+			sb.WriteRune(ch)
+		}
+		lines[y] = sb.String()
+	}
+
+	return lines
+}
+
+// String returns the visible screen content as one text block
+func (s *HeadlessScreen) String() string {
+	return strings.Join(s.Lines(), "\n")
+}
@@ -0,0 +1,106 @@
+package tv
+
+import (
+	"strings"
+	"testing"
+
+	term "github.com/nsf/termbox-go"
+)
+
+func TestHeadlessWindow(t *testing.T) {
+	scr := NewHeadlessScreen(30, 8)
+	if !InitLibrary(scr) {
+		t.Fatalf("Failed to init library with headless screen")
+	}
+	defer DeinitLibrary()
+
+	AddWindow(1, 1, 20, 4, "Demo", false, false)
+	RefreshScreen()
+
+	lines := scr.Lines()
+	if len(lines) != 8 {
+		t.Fatalf("Screen must have %v lines instead of %v", 8, len(lines))
+	}
+	if !strings.Contains(lines[1], "Demo") {
+		t.Errorf("Window title is not drawn: <%v>", lines[1])
+	}
+	if ch := scr.CellAt(1, 1).Ch; ch != '╔' {
+		t.Errorf("Active window corner must be %q instead of %q", '╔', ch)
+	}
+	if scr.FlushCount() == 0 {
+		t.Errorf("RefreshScreen must flush the screen")
+	}
+}
+
+func TestHeadlessEditField(t *testing.T) {
+	scr := NewHeadlessScreen(30, 8)
+	InitLibrary(scr)
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 0, 20, 3, "Edit", false, false)
+	edit := CreateEditField(wnd, 10, "", 1)
+	ActivateControl(wnd, edit)
+
+	for _, r := range "abc" {
+		scr.InjectRune(r)
+	}
+	scr.InjectKey(term.KeyArrowLeft, 0, 0)
+	scr.InjectRune('x')
+	for scr.Pending() > 0 {
+		ProcessEvent(scr.PollEvent())
+	}
+	RefreshScreen()
+
+	if edit.Title() != "abxc" {
+		t.Errorf("Edit text must be %v instead of %v", "abxc", edit.Title())
+	}
+	if !strings.Contains(scr.Lines()[1], "abxc") {
+		t.Errorf("Edit text is not drawn: <%v>", scr.Lines()[1])
+	}
+	if _, _, visible := scr.Cursor(); !visible {
+		t.Errorf("Active EditField must show the cursor")
+	}
+}
+
+func TestHeadlessResize(t *testing.T) {
+	scr := NewHeadlessScreen(20, 5)
+	InitLibrary(scr)
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 0, 10, 3, "Max", false, false)
+	wnd.SetMaximized(true)
+
+	scr.InjectResize(40, 10)
+	ProcessEvent(scr.PollEvent())
+
+	w, h := ScreenSize()
+	if w != 40 || h != 10 {
+		t.Errorf("Screen size must be 40x10 instead of %vx%v", w, h)
+	}
+	w, h = wnd.Size()
+	if w != 40 || h != 10 {
+		t.Errorf("Maximized window size must be 40x10 instead of %vx%v", w, h)
+	}
+	if ch := scr.CellAt(39, 9).Ch; ch != '╝' {
+		t.Errorf("Window corner must be drawn at the new screen corner, found %q", ch)
+	}
+}
+
+func TestHeadlessTableView(t *testing.T) {
+	scr := NewHeadlessScreen(40, 10)
+	InitLibrary(scr)
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 0, 30, 8, "Table", false, false)
+	table := CreateTableView(wnd, 25, 6, 1)
+	table.SetColumns([]Column{{Title: "Name", Width: 10}})
+	table.SetRowCount(3)
+	table.OnDrawCell(func(info *ColumnDrawInfo) {
+		info.Text = "row"
+	})
+	RefreshScreen()
+
+	if !strings.Contains(scr.String(), "Name") {
+		t.Errorf("TableView header is not drawn:\n%v", scr.String())
+	}
+}
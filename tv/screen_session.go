@@ -0,0 +1,371 @@
+package tv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-runewidth"
+	term "github.com/nsf/termbox-go"
+)
+
+// sessionQueueSize is the number of parsed input events that SessionScreen
+// keeps while the main loop is busy
+const sessionQueueSize = 256
+
+// ErrScreenClosed is reported by PollEvent of a closed SessionScreen
+var ErrScreenClosed = errors.New("screen is closed")
+
+// ANSI sequences that SessionScreen sends to the remote terminal
+const (
+	ansiEnter = "\x1b[?1049h\x1b[?25l\x1b[?1000h\x1b[?1002h\x1b[?1006h\x1b[?2004h\x1b[0m\x1b[2J"
+	ansiLeave = "\x1b[?1003l\x1b[?2004l\x1b[?1006l\x1b[?1002l\x1b[?1000l\x1b[0m\x1b[2J\x1b[?25h\x1b[?1049l"
+)
+
+/*
+SessionScreen is a Screen that works with a remote terminal over any
+io.ReadWriter: an SSH channel, a telnet connection, a pty, etc. It does
+not use termbox at all: the screen writes ANSI escape sequences to the
+writer and parses keys and mouse events read from the reader itself. So,
+one process can serve many users, every user with its own Application:
+
+	scr := tv.NewSessionScreen(channel, ptyReq.Term, ptyReq.Width, ptyReq.Height)
+	app, err := tv.NewApplication(scr)
+	...
+	// on every window-change request of the SSH session
+	scr.Resize(width, height)
+
+The remote terminal size cannot be detected from the byte stream, so the
+caller must push size changes with Resize. The screen does not close the
+ReadWriter: it is the caller who owns the connection.
+*/
+type SessionScreen struct {
+	mtx       sync.Mutex
+	rw        io.ReadWriter
+	termType  string
+	colors256 bool
+	width     int
+	height    int
+	back      []term.Cell
+	front     []term.Cell
+	redraw    bool
+	cursorX   int
+	cursorY   int
+	events    chan Event
+	interrupt chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSessionScreen creates a screen for a remote terminal of the given
+// type (the value of TERM variable, e.g. "xterm-256color") and size
+func NewSessionScreen(rw io.ReadWriter, termType string, width, height int) *SessionScreen {
+	s := &SessionScreen{
+		rw:        rw,
+		termType:  termType,
+		colors256: strings.Contains(termType, "256color"),
+		cursorX:   -1,
+		cursorY:   -1,
+		events:    make(chan Event, sessionQueueSize),
+		interrupt: make(chan struct{}, 1),
+		closed:    make(chan struct{}),
+	}
+	s.resize(width, height)
+
+	return s
+}
+
+func (s *SessionScreen) resize(width, height int) {
+	if width < 0 {
+		width = 0
+	}
+	if height < 0 {
+		height = 0
+	}
+
+	blank := term.Cell{Ch: ' ', Fg: ColorDefault, Bg: ColorDefault}
+	back := make([]term.Cell, width*height)
+	for i := range back {
+		back[i] = blank
+	}
+	for y := 0; y < height && y < s.height; y++ {
+		for x := 0; x < width && x < s.width; x++ {
+			back[y*width+x] = s.back[y*s.width+x]
+		}
+	}
+
+	s.back = back
+	s.front = make([]term.Cell, width*height)
+	s.width, s.height = width, height
+	s.redraw = true
+}
+
+// Init prepares the remote terminal (switches it to alternate screen and
+// turns on mouse reporting) and starts reading input
+func (s *SessionScreen) Init() error {
+	if _, err := io.WriteString(s.rw, ansiEnter); err != nil {
+		return err
+	}
+
+	go s.readInput()
+
+	return nil
+}
+
+// Close restores the remote terminal state. The ReadWriter is not closed
+func (s *SessionScreen) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+		_, _ = io.WriteString(s.rw, ansiLeave)
+	})
+}
+
+// TermType returns the terminal type the screen was created with
+func (s *SessionScreen) TermType() string {
+	return s.termType
+}
+
+// Size returns the remote terminal size
+func (s *SessionScreen) Size() (width int, height int) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.width, s.height
+}
+
+// Resize changes the screen size after the remote terminal is resized and
+// sends EventResize to the main loop. The whole screen is repainted on the
+// next Flush
+func (s *SessionScreen) Resize(width, height int) {
+	s.mtx.Lock()
+	s.resize(width, height)
+	s.mtx.Unlock()
+
+	s.sendEvent(Event{Type: EventResize, Width: width, Height: height})
+}
+
+// SetCell changes the cell in the back buffer. Cells outside the screen
+// are skipped
+func (s *SessionScreen) SetCell(x, y int, ch rune, fg, bg term.Attribute) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if x < 0 || x >= s.width || y < 0 || y >= s.height {
+		return
+	}
+	s.back[y*s.width+x] = term.Cell{Ch: ch, Fg: fg, Bg: bg}
+}
+
+// Cell returns the cell from the back buffer
+func (s *SessionScreen) Cell(x, y int) term.Cell {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if x < 0 || x >= s.width || y < 0 || y >= s.height {
+		return term.Cell{Ch: ' '}
+	}
+	return s.back[y*s.width+x]
+}
+
+// Clear fills the back buffer with spaces
+func (s *SessionScreen) Clear(fg, bg term.Attribute) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for i := range s.back {
+		s.back[i] = term.Cell{Ch: ' ', Fg: fg, Bg: bg}
+	}
+	return nil
+}
+
+// SetCursor moves the caret. The caret is shown on the next Flush
+func (s *SessionScreen) SetCursor(x, y int) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.cursorX, s.cursorY = x, y
+}
+
+// HideCursor makes the caret invisible on the next Flush
+func (s *SessionScreen) HideCursor() {
+	s.SetCursor(-1, -1)
+}
+
+// Flush sends the cells that were changed since the previous call to the
+// remote terminal
+func (s *SessionScreen) Flush() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var buf bytes.Buffer
+	if s.redraw {
+		buf.WriteString("\x1b[0m\x1b[2J")
+	}
+
+	lastX, lastY := -1, -1
+	var lastFg, lastBg term.Attribute
+	attrSet := false
+	for y := 0; y < s.height; y++ {
+		// a wide rune was replaced: the cell it covered must be sent
+		forceNext := false
+		for x := 0; x < s.width; x++ {
+			idx := y*s.width + x
+			cell := s.back[idx]
+			ch := cell.Ch
+			if ch < ' ' {
+				ch = ' '
+			}
+			w := runewidth.RuneWidth(ch)
+			if !s.redraw && !forceNext && cell == s.front[idx] {
+				if w == 2 {
+					x++
+				}
+				continue
+			}
+			forceNext = runewidth.RuneWidth(s.front[idx].Ch) == 2
+			s.front[idx] = cell
+
+			if lastY != y || lastX != x {
+				buf.WriteString("\x1b[" + strconv.Itoa(y+1) + ";" + strconv.Itoa(x+1) + "H")
+			}
+			if !attrSet || cell.Fg != lastFg || cell.Bg != lastBg {
+				buf.WriteString(s.sgr(cell.Fg, cell.Bg))
+				lastFg, lastBg, attrSet = cell.Fg, cell.Bg, true
+			}
+
+			buf.WriteRune(ch)
+			lastX, lastY = x+w, y
+			switch {
+			case w == 0:
+				// the terminal position after a combining rune is unknown
+				lastX = -1
+			case w == 2 && x+1 < s.width:
+				// the terminal draws the rune over the next cell
+				x++
+				s.front[idx+1] = s.back[idx+1]
+				forceNext = false
+			}
+		}
+	}
+	s.redraw = false
+
+	if s.cursorX >= 0 && s.cursorY >= 0 && s.cursorX < s.width && s.cursorY < s.height {
+		buf.WriteString("\x1b[" + strconv.Itoa(s.cursorY+1) + ";" + strconv.Itoa(s.cursorX+1) + "H\x1b[?25h")
+	} else {
+		buf.WriteString("\x1b[?25l")
+	}
+
+	_, err := s.rw.Write(buf.Bytes())
+	return err
+}
+
+// sgr returns the sequence that selects the text and background colors
+func (s *SessionScreen) sgr(fg, bg term.Attribute) string {
+	var sb strings.Builder
+	sb.WriteString("\x1b[0")
+	if fg&term.AttrBold != 0 {
+		sb.WriteString(";1")
+	}
+	if fg&term.AttrUnderline != 0 {
+		sb.WriteString(";4")
+	}
+	if fg&term.AttrReverse != 0 || bg&term.AttrReverse != 0 {
+		sb.WriteString(";7")
+	}
+	sb.WriteString(";" + s.ansiColor(fg, 30))
+	sb.WriteString(";" + s.ansiColor(bg, 40))
+	sb.WriteString("m")
+
+	return sb.String()
+}
+
+// ansiColor converts a termbox color to SGR parameter. base is 30 for
+// the text color and 40 for the background one
+func (s *SessionScreen) ansiColor(attr term.Attribute, base int) string {
+	clr := int(attr & 0x1FF)
+	switch {
+	case clr == int(ColorDefault):
+		return strconv.Itoa(base + 9)
+	case clr <= 8:
+		return strconv.Itoa(base + clr - 1)
+	case s.colors256:
+		return strconv.Itoa(base+8) + ";5;" + strconv.Itoa(clr-1)
+	case clr <= 16:
+		return strconv.Itoa(base + 60 + clr - 9)
+	default:
+		return strconv.Itoa(base + (clr-1)%8)
+	}
+}
+
+// PollEvent waits for the next event read from the remote terminal or for
+// the next resize
+func (s *SessionScreen) PollEvent() Event {
+	select {
+	case ev := <-s.events:
+		return ev
+	case <-s.interrupt:
+		return Event{Type: EventInterrupt}
+	case <-s.closed:
+		return Event{Type: EventError, Err: ErrScreenClosed}
+	}
+}
+
+// SetMouseHover asks the terminal to report all mouse moves or only moves
+// with a button pressed
+func (s *SessionScreen) SetMouseHover(on bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	seq := hoverOff
+	if on {
+		seq = hoverOn
+	}
+	_, _ = io.WriteString(s.rw, seq)
+}
+
+// Interrupt makes PollEvent return EventInterrupt
+func (s *SessionScreen) Interrupt() {
+	select {
+	case s.interrupt <- struct{}{}:
+	default:
+	}
+}
+
+func (s *SessionScreen) sendEvent(ev Event) bool {
+	select {
+	case s.events <- ev:
+		return true
+	case <-s.closed:
+		return false
+	}
+}
+
+// readInput reads the input stream until an error happens and converts
+// it to events. The read error is reported as EventError
+func (s *SessionScreen) readInput() {
+	var pending []byte
+	buf := make([]byte, 256)
+	for {
+		n, err := s.rw.Read(buf)
+		if n > 0 {
+			pending = append(pending, buf[:n]...)
+			var events []Event
+			events, pending = parseInput(pending)
+			for _, ev := range events {
+				if !s.sendEvent(ev) {
+					return
+				}
+			}
+		}
+		if err != nil {
+			s.sendEvent(Event{Type: EventError, Err: err})
+			return
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package tv
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	term "github.com/nsf/termbox-go"
+)
+
+func TestParseInput(t *testing.T) {
+	cases := []struct {
+		input string
+		want  []Event
+		rest  string
+	}{
+		{"ab", []Event{{Type: EventKey, Ch: 'a'}, {Type: EventKey, Ch: 'b'}}, ""},
+		{"я", []Event{{Type: EventKey, Ch: 'я'}}, ""},
+		{"\xd1", nil, "\xd1"},
+		{"\r\t \x7f", []Event{{Type: EventKey, Key: term.KeyEnter}, {Type: EventKey, Key: term.KeyTab},
+			{Type: EventKey, Key: term.KeySpace}, {Type: EventKey, Key: term.KeyBackspace2}}, ""},
+		{"\x1b", []Event{{Type: EventKey, Key: term.KeyEsc}}, ""},
+		{"\x1b[A\x1bOB\x1b[1;5C", []Event{{Type: EventKey, Key: term.KeyArrowUp},
+			{Type: EventKey, Key: term.KeyArrowDown}, {Type: EventKey, Key: term.KeyArrowRight, Mod: ModCtrl}}, ""},
+		{"\x1b[3~\x1b[24~\x1bOP", []Event{{Type: EventKey, Key: term.KeyDelete},
+			{Type: EventKey, Key: term.KeyF12}, {Type: EventKey, Key: term.KeyF1}}, ""},
+		{"\x1b[5", nil, "\x1b[5"},
+		{"\x1b[<0;5;3M\x1b[<0;5;3m\x1b[<65;1;1M", []Event{{Type: EventMouse, Key: term.MouseLeft, X: 4, Y: 2},
+			{Type: EventMouse, Key: term.MouseRelease, X: 4, Y: 2}, {Type: EventMouse, Key: term.MouseWheelDown}}, ""},
+		{"\x1b[M !!", []Event{{Type: EventMouse, Key: term.MouseLeft}}, ""},
+		{"\x1bx\x1b\x1b[6~", []Event{{Type: EventKey, Ch: 'x', Mod: ModAlt},
+			{Type: EventKey, Key: term.KeyPgdn, Mod: ModAlt}}, ""},
+		{"\x1b[1;6D\x1b[3;2~\x1b[Z", []Event{{Type: EventKey, Key: term.KeyArrowLeft, Mod: ModCtrl | ModShift},
+			{Type: EventKey, Key: term.KeyDelete, Mod: ModShift}, {Type: EventKey, Key: term.KeyTab, Mod: ModShift}}, ""},
+		{"\x1b[97;6u\x1b[27;5;13~\x1b[97;2u", []Event{{Type: EventKey, Key: term.KeyCtrlA, Mod: ModShift},
+			{Type: EventKey, Key: term.KeyEnter, Mod: ModCtrl}, {Type: EventKey, Ch: 'A'}}, ""},
+		{"\x1b[200~a\r\nb\x1b[201~x", []Event{{Type: EventPaste, Msg: "a\nb"}, {Type: EventKey, Ch: 'x'}}, ""},
+		{"\x1b[200~abc", nil, "\x1b[200~abc"},
+		{"\x1b[[B\x1b[32;3;4M", []Event{{Type: EventKey, Key: term.KeyF2}, {Type: EventMouse, Key: term.MouseLeft, X: 2, Y: 3}}, ""},
+		{"\x1b[<20;2;2M", []Event{{Type: EventMouse, Key: term.MouseLeft, X: 1, Y: 1, Mod: ModCtrl | ModShift}}, ""},
+		{"\x1b[\rab", []Event{{Type: EventKey, Ch: '[', Mod: ModAlt}, {Type: EventKey, Key: term.KeyEnter},
+			{Type: EventKey, Ch: 'a'}, {Type: EventKey, Ch: 'b'}}, ""},
+		{"\x1b[1я", []Event{{Type: EventKey, Ch: '[', Mod: ModAlt}, {Type: EventKey, Ch: '1'}, {Type: EventKey, Ch: 'я'}}, ""},
+	}
+
+	for _, c := range cases {
+		events, rest := parseInput([]byte(c.input))
+		if len(events) != len(c.want) || string(rest) != c.rest {
+			t.Errorf("Input %q: got %v events and rest %q, want %v events and rest %q",
+				c.input, len(events), rest, len(c.want), c.rest)
+			continue
+		}
+		for i, ev := range events {
+			if ev != c.want[i] {
+				t.Errorf("Input %q: event %v must be %+v instead of %+v", c.input, i, c.want[i], ev)
+			}
+		}
+	}
+}
+
+// pipeTerminal is the client side of a session: it collects everything
+// the screen writes and sends user input
+type pipeTerminal struct {
+	conn net.Conn
+	mtx  sync.Mutex
+	out  bytes.Buffer
+}
+
+func newPipeTerminal(conn net.Conn) *pipeTerminal {
+	p := &pipeTerminal{conn: conn}
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := conn.Read(buf)
+			p.mtx.Lock()
+			p.out.Write(buf[:n])
+			p.mtx.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return p
+}
+
+func (p *pipeTerminal) output() string {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.out.String()
+}
+
+func (p *pipeTerminal) waitOutput(t *testing.T, text string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(p.output(), text) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Terminal did not receive %q", text)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSessionScreenOverPipe(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(10, 3))
+	defer DeinitLibrary()
+
+	server, client := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	terminal := newPipeTerminal(client)
+
+	scr := NewSessionScreen(server, "xterm", 30, 5)
+	app, err := NewApplication(scr)
+	if err != nil {
+		t.Fatalf("Failed to create application: %v", err)
+	}
+	terminal.waitOutput(t, "\x1b[?1049h")
+
+	var edit *TEditField
+	app.Do(func() {
+		wnd := AddWindow(0, 0, 20, 3, "Remote", false, false)
+		edit = CreateEditField(wnd, 10, "", 1)
+		ActivateControl(wnd, edit)
+		edit.OnChange(func(ev Event) {
+			if ev.Msg == "ih" {
+				app.Stop()
+			}
+		})
+	})
+
+	done := make(chan struct{})
+	go func() {
+		app.MainLoop()
+		close(done)
+	}()
+
+	terminal.waitOutput(t, "Remote")
+	scr.Resize(40, 8)
+	if w, h := scr.Size(); w != 40 || h != 8 {
+		t.Errorf("Screen size must be 40x8 instead of %vx%v", w, h)
+	}
+	terminal.waitOutput(t, "\x1b[2J")
+
+	if _, err := client.Write([]byte("h\x1b[Di")); err != nil {
+		t.Fatalf("Failed to send input: %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Application main loop did not finish")
+	}
+
+	if edit.Title() != "ih" {
+		t.Errorf("Edit text must be 'ih' instead of '%v'", edit.Title())
+	}
+
+	app.Close()
+	terminal.waitOutput(t, "\x1b[?1049l")
+	if ev := scr.PollEvent(); ev.Type != EventError || ev.Err != ErrScreenClosed {
+		t.Errorf("Closed screen must return error event instead of %+v", ev)
+	}
+}
+
+func TestSessionScreenFlush(t *testing.T) {
+	var buf bytes.Buffer
+	rw := struct {
+		*bytes.Buffer
+	}{&buf}
+
+	scr := NewSessionScreen(rw, "xterm-256color", 4, 2)
+	scr.SetCell(1, 0, 'a', ColorRedBold, ColorDefault)
+	scr.SetCell(2, 0, 'b', ColorRedBold, ColorDefault)
+	scr.SetCursor(0, 1)
+	if err := scr.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	out := buf.String()
+	for _, s := range []string{"\x1b[2J", "\x1b[0;1;31;49mab", "\x1b[2;1H\x1b[?25h"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Output %q must contain %q", out, s)
+		}
+	}
+
+	buf.Reset()
+	scr.SetCell(3, 1, 'c', term.Attribute(100), ColorBlack)
+	scr.HideCursor()
+	scr.Flush()
+	if want := "\x1b[2;4H\x1b[0;38;5;99;40mc\x1b[?25l"; buf.String() != want {
+		t.Errorf("Only changed cells must be sent: got %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	scr.SetCell(0, 0, '世', ColorRedBold, ColorDefault)
+	scr.SetCell(1, 0, 'x', ColorRedBold, ColorDefault)
+	scr.SetCell(2, 0, 'd', ColorRedBold, ColorDefault)
+	scr.Flush()
+	if want := "\x1b[1;1H\x1b[0;1;31;49m世d\x1b[?25l"; buf.String() != want {
+		t.Errorf("Wide rune must cover the next cell: got %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	scr.SetCell(0, 0, 'e', ColorRedBold, ColorDefault)
+	scr.Flush()
+	if want := "\x1b[1;1H\x1b[0;1;31;49mex\x1b[?25l"; buf.String() != want {
+		t.Errorf("Cell under the replaced wide rune must be sent: got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,95 @@
+package tv
+
+import (
+	term "github.com/nsf/termbox-go"
+
+	"github.com/prospero78/goTV/tv/types"
+)
+
+// TermboxScreen is the default Screen that draws to the process terminal
+// with termbox-go library
+type TermboxScreen struct {
+	// raw input that is read from termbox but not parsed yet and events
+	// parsed from it but not returned by PollEvent
+	buf     [256]byte
+	pending []byte
+	events  []Event
+}
+
+// NewTermboxScreen creates a new termbox based Screen
+func NewTermboxScreen() *TermboxScreen {
+	return new(TermboxScreen)
+}
+
+// Init initializes termbox and turns on mouse support and bracketed paste
+func (s *TermboxScreen) Init() error {
+	if err := term.Init(); err != nil {
+		return err
+	}
+	term.SetInputMode(term.InputEsc | term.InputMouse)
+	setBracketedPaste(true)
+
+	return nil
+}
+
+// Close finalizes termbox and makes the console cursor visible
+func (s *TermboxScreen) Close() {
+	setBracketedPaste(false)
+	s.SetMouseHover(false)
+	term.SetCursor(3, 3)
+	term.Close()
+}
+
+// Size returns the terminal size
+func (s *TermboxScreen) Size() (width int, height int) {
+	return term.Size()
+}
+
+// SetCell changes the cell in termbox back buffer
+func (s *TermboxScreen) SetCell(x, y int, ch rune, fg, bg term.Attribute) {
+	term.SetCell(x, y, ch, fg, bg)
+}
+
+// Cell returns the cell from termbox back buffer
+func (s *TermboxScreen) Cell(x, y int) term.Cell {
+	width, height := term.Size()
+	if x < 0 || x >= width || y < 0 || y >= height {
+		return term.Cell{Ch: ' '}
+	}
+
+	cells := term.CellBuffer()
+	return cells[y*width+x]
+}
+
+// Clear clears termbox back buffer
+func (s *TermboxScreen) Clear(fg, bg term.Attribute) error {
+	return term.Clear(fg, bg)
+}
+
+// SetCursor shows the terminal cursor at the given position
+func (s *TermboxScreen) SetCursor(x, y int) {
+	term.SetCursor(x, y)
+}
+
+// HideCursor hides the terminal cursor
+func (s *TermboxScreen) HideCursor() {
+	term.HideCursor()
+}
+
+// Flush synchronizes the terminal with termbox back buffer
+func (s *TermboxScreen) Flush() error {
+	return term.Flush()
+}
+
+// Interrupt interrupts termbox PollEvent. It blocks until PollEvent takes
+// the interruption
+func (s *TermboxScreen) Interrupt() {
+	term.Interrupt()
+}
+
+func termboxEventToLocal(ev term.Event) Event {
+	e := Event{Type: EventType(ev.Type), Ch: ev.Ch,
+		Key: ev.Key, Err: ev.Err, X: types.ACoordX(ev.MouseX), Y: types.ACoordY(ev.MouseY),
+		Mod: ev.Mod, Width: ev.Width, Height: ev.Height}
+	return e
+}
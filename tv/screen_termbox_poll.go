@@ -0,0 +1,61 @@
+//go:build !windows
+// +build !windows
+
+package tv
+
+import (
+	"os"
+
+	term "github.com/nsf/termbox-go"
+)
+
+// PollEvent waits for the next terminal event. termbox knows only keys
+// without modifiers, so the input is read raw and decoded by the library:
+// this way Ctrl, Shift and Alt combinations of xterm-like terminals are
+// reported
+func (s *TermboxScreen) PollEvent() Event {
+	for len(s.events) == 0 {
+		ev := term.PollRawEvent(s.buf[:])
+		if ev.Type != term.EventRaw {
+			return termboxEventToLocal(ev)
+		}
+
+		s.pending = append(s.pending, s.buf[:ev.N]...)
+		s.events, s.pending = parseInput(s.pending)
+	}
+
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev
+}
+
+// SetMouseHover asks the terminal to report all mouse moves or only moves
+// with a button pressed
+func (s *TermboxScreen) SetMouseHover(on bool) {
+	seq := hoverOff
+	if on {
+		seq = hoverOn
+	}
+	writeTerminal(seq)
+}
+
+// setBracketedPaste turns bracketed paste mode of the terminal on or off
+func setBracketedPaste(on bool) {
+	seq := pasteOff
+	if on {
+		seq = pasteOn
+	}
+	writeTerminal(seq)
+}
+
+// writeTerminal writes the escape sequence directly to the terminal that
+// termbox uses: termbox does not know modes like bracketed paste
+func writeTerminal(seq string) {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	defer tty.Close()
+
+	_, _ = tty.WriteString(seq)
+}
@@ -0,0 +1,21 @@
+package tv
+
+import (
+	term "github.com/nsf/termbox-go"
+)
+
+// PollEvent waits for the next termbox event and converts it to Event.
+// Windows console reports only Alt modifier
+func (s *TermboxScreen) PollEvent() Event {
+	return termboxEventToLocal(term.PollEvent())
+}
+
+// setBracketedPaste does nothing: Windows console does not support
+// bracketed paste
+func setBracketedPaste(on bool) {
+}
+
+// SetMouseHover does nothing: termbox reports mouse moves in Windows
+// console as is
+func (s *TermboxScreen) SetMouseHover(on bool) {
+}
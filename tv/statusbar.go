@@ -0,0 +1,258 @@
+package tv
+
+import (
+	"strings"
+	"sync"
+
+	xs "github.com/huandu/xstrings"
+	term "github.com/nsf/termbox-go"
+
+	"github.com/prospero78/goTV/tv/types"
+)
+
+// StatusItem is a hint shown in the status bar, e.g. "F1 Help". Keys are
+// drawn with the hotkey color. If Keys is empty, the keys bound to Command
+// in the keymap are shown. Clicking the item runs Command, or, if the item
+// does not have a command, sends Keys as if a user pressed them. The item
+// of a disabled command is grayed out
+type StatusItem struct {
+	Keys    string
+	Title   string
+	Command string
+}
+
+// keysLabel returns the keys displayed for the item
+func (si StatusItem) keysLabel() string {
+	if si.Keys == "" && si.Command != "" {
+		return keysLabel(comp.keymap.Keys(si.Command))
+	}
+	return si.Keys
+}
+
+// enabled returns false if the item command is disabled
+func (si StatusItem) enabled() bool {
+	return si.Command == "" || comp.CommandEnabled(si.Command)
+}
+
+// text returns the item text without padding
+func (si StatusItem) text() string {
+	keys := si.keysLabel()
+	switch {
+	case keys == "":
+		return si.Title
+	case si.Title == "":
+		return keys
+	}
+	return keys + " " + si.Title
+}
+
+/*
+StatusBar is the status line at the bottom of the screen. It is managed
+by Composer: create it with CreateStatusBar. Windows are maximized above
+the status bar.
+The status bar shows hotkey hints of the active window: items set for the
+active control or its parents with SetScopeItems replace the default items
+set with SetItems. The right part of the status bar is the message area.
+SetMessage can be called from any goroutine, e.g. to show the progress of
+a background task
+*/
+type StatusBar struct {
+	TBaseControl
+	items  []StatusItem
+	scopes map[IControl][]StatusItem
+	// index of the item under the pressed mouse button, -1 if none
+	pressed int
+
+	mtx     sync.Mutex
+	message string
+	// loop of the application the status bar belongs to: SetMessage can
+	// be called from any goroutine
+	loop *mainLoop
+}
+
+// CreateStatusBar creates an empty status bar and attaches it to the
+// bottom of the screen. The previous status bar is replaced
+func CreateStatusBar() *StatusBar {
+	sb := &StatusBar{
+		TBaseControl: NewBaseControl(),
+		scopes:       make(map[IControl][]StatusItem),
+		pressed:      -1,
+		loop:         loop,
+	}
+	sb.SetTabStop(false)
+	comp.SetStatusBar(sb)
+	return sb
+}
+
+// SetItems sets the default items that are shown when the active window
+// and its active control do not have own items
+func (sb *StatusBar) SetItems(items []StatusItem) {
+	sb.items = items
+	RefreshScreen()
+}
+
+// Items returns the default items
+func (sb *StatusBar) Items() []StatusItem {
+	return sb.items
+}
+
+// SetScopeItems sets the items that are shown while the window or the
+// control is active. A control items override its window items. nil
+// removes the scope items. The items are removed when the window is
+// destroyed
+func (sb *StatusBar) SetScopeItems(scope IControl, items []StatusItem) {
+	if items == nil {
+		delete(sb.scopes, scope)
+	} else {
+		sb.scopes[scope] = items
+	}
+	RefreshScreen()
+}
+
+// removeScope removes items of the window and all its children
+func (sb *StatusBar) removeScope(wnd IControl) {
+	for scope := range sb.scopes {
+		if ownedBy(scope, wnd) {
+			delete(sb.scopes, scope)
+		}
+	}
+}
+
+// CurrentItems returns the items for the active window and control
+func (sb *StatusBar) CurrentItems() []StatusItem {
+	for _, scope := range comp.keyScopes() {
+		if items, ok := sb.scopes[scope]; ok {
+			return items
+		}
+	}
+	return sb.items
+}
+
+// SetMessage shows the text in the message area. It is safe to call it
+// from any goroutine
+func (sb *StatusBar) SetMessage(msg string) {
+	sb.mtx.Lock()
+	sb.message = msg
+	sb.mtx.Unlock()
+	sb.loop.put(Event{Type: EventRedraw})
+}
+
+// Message returns the text of the message area
+func (sb *StatusBar) Message() string {
+	sb.mtx.Lock()
+	defer sb.mtx.Unlock()
+	return sb.message
+}
+
+// itemPos returns the screen column of the item idx of the list
+func (sb *StatusBar) itemPos(items []StatusItem, idx int) types.ACoordX {
+	x := sb.pos.GetX()
+	for i := 0; i < idx; i++ {
+		x += types.ACoordX(xs.Len(items[i].text()) + 2)
+	}
+	return x
+}
+
+// itemAt returns the index of the current item at column x or -1
+func (sb *StatusBar) itemAt(x types.ACoordX) int {
+	items := sb.CurrentItems()
+	for i, item := range items {
+		start := sb.itemPos(items, i)
+		if x >= start && x < start+types.ACoordX(xs.Len(item.text())+2) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Draw repaints the status bar
+func (sb *StatusBar) Draw() {
+	if sb.hidden {
+		return
+	}
+
+	PushAttributes()
+	defer PopAttributes()
+
+	x, y := sb.pos.Get()
+	w, _ := sb.Size()
+	fg, bg := RealColor(sb.fg, sb.Style(), ColorStatusText), RealColor(sb.bg, sb.Style(), ColorStatusBack)
+	hk := RealColor(ColorDefault, sb.Style(), ColorStatusHotkeyText)
+	disabled := RealColor(ColorDefault, sb.Style(), ColorStatusDisabledText)
+	SetTextColor(fg)
+	SetBackColor(bg)
+	FillRect(x, y, w, 1, ' ')
+
+	items := sb.CurrentItems()
+	end := x
+	for i, item := range items {
+		pos := sb.itemPos(items, i)
+		text := item.text()
+		switch keys := item.keysLabel(); {
+		case !item.enabled():
+			SetTextColor(disabled)
+		case keys != "":
+			text = "<t:" + ColorToString(hk) + ">" + keys + "<t:>" + xs.Slice(text, xs.Len(keys), -1)
+		}
+		DrawText(pos+1, y, text)
+		SetTextColor(fg)
+		end = pos + types.ACoordX(xs.Len(item.text())+2)
+	}
+
+	msg := sb.Message()
+	if msg == "" {
+		return
+	}
+	space := int(x) + w - int(end) - 1
+	if space <= 0 {
+		return
+	}
+	if xs.Len(msg) > space {
+		msg = xs.Slice(msg, 0, space)
+	}
+	DrawRawText(x+types.ACoordX(w-xs.Len(msg)-1), y, msg)
+}
+
+// ProcessEvent handles mouse clicks on the items. A click on an item runs
+// its command or sends its keys
+func (sb *StatusBar) ProcessEvent(ev Event) bool {
+	if ev.Type != EventMouse || sb.hidden || ev.Y != sb.pos.GetY() {
+		if ev.Type == EventMouse && ev.Key == term.MouseRelease {
+			sb.pressed = -1
+		}
+		return false
+	}
+
+	switch {
+	case ev.Mod&term.ModMotion != 0:
+	case ev.Key == term.MouseLeft:
+		sb.pressed = sb.itemAt(ev.X)
+	case ev.Key == term.MouseRelease:
+		idx := sb.itemAt(ev.X)
+		pressed := sb.pressed
+		sb.pressed = -1
+		if idx != -1 && idx == pressed {
+			sb.trigger(sb.CurrentItems()[idx])
+		}
+	}
+	return true
+}
+
+// trigger runs the item command or sends its keys to the active window
+func (sb *StatusBar) trigger(item StatusItem) {
+	if !item.enabled() {
+		return
+	}
+	if item.Command != "" {
+		comp.runCommand(item.Command, Event{Type: EventClick, Msg: item.Command})
+		return
+	}
+
+	keys, err := ParseKeys(strings.ToLower(item.Keys))
+	if err != nil {
+		return
+	}
+	for _, ks := range keys {
+		comp.processKey(ks.Event())
+	}
+}
@@ -0,0 +1,79 @@
+package tv
+
+import (
+	"strings"
+	"testing"
+
+	term "github.com/nsf/termbox-go"
+)
+
+func TestStatusBar(t *testing.T) {
+	scr := NewHeadlessScreen(60, 20)
+	InitLibrary(scr)
+	defer DeinitLibrary()
+
+	wnd := AddWindow(0, 0, 30, 10, "Main", false, false)
+	edit := CreateEditField(wnd, 10, "", 1)
+	btn := CreateButton(wnd, AutoSize, AutoSize, "OK", Fixed, true, true)
+	wnd.ResizeChildren()
+	wnd.PlaceChildren()
+	ActivateControl(wnd, btn)
+
+	var got []string
+	comp.HandleCommand("Help", func(ev Event) {
+		got = append(got, "help")
+	})
+	_ = comp.Keymap().Bind("f1", "Help")
+	_ = comp.Keymap().Bind("f2", "Save")
+	comp.HandleCommand("Save", func(ev Event) {
+		got = append(got, "save")
+	})
+
+	sb := CreateStatusBar()
+	sb.SetItems([]StatusItem{{Title: "Help", Command: "Help"}, {Keys: "Alt+X", Title: "Exit"}})
+	sb.SetScopeItems(edit, []StatusItem{{Keys: "F2", Title: "Save"}})
+	sb.SetMessage("Loading")
+
+	RefreshScreen()
+	if line := scr.Lines()[19]; !strings.HasPrefix(line, " F1 Help  Alt+X Exit ") || !strings.HasSuffix(line, "Loading ") {
+		t.Errorf("Status bar is not drawn: <%v>", line)
+	}
+
+	ProcessEvent(mouseAt(2, 19, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(2, 19, term.MouseRelease, 0))
+
+	// items of the active control replace the default ones, clicking an
+	// item without command sends its keys
+	ActivateControl(wnd, edit)
+	RefreshScreen()
+	if line := scr.Lines()[19]; !strings.HasPrefix(line, " F2 Save  ") {
+		t.Errorf("Control items are not drawn: <%v>", line)
+	}
+	ProcessEvent(mouseAt(3, 19, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(3, 19, term.MouseRelease, 0))
+
+	// release outside the pressed item does nothing
+	ProcessEvent(mouseAt(3, 19, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(30, 19, term.MouseRelease, 0))
+
+	if strings.Join(got, ",") != "help,save" {
+		t.Errorf("Wrong commands: %v", got)
+	}
+
+	wnd.SetMaximized(true)
+	if _, h := wnd.Size(); h != 19 {
+		t.Errorf("Maximized window must be above the status bar: %v", h)
+	}
+	ProcessEvent(Event{Type: EventResize, Width: 50, Height: 15})
+	if _, y := sb.Pos().Get(); y != 14 {
+		t.Errorf("Status bar must stay at the bottom: %v", y)
+	}
+	if w, h := wnd.Size(); w != 50 || h != 14 {
+		t.Errorf("Maximized window must fit the desktop: %vx%v", w, h)
+	}
+
+	comp.DestroyWindow(wnd)
+	if len(sb.scopes) != 0 {
+		t.Errorf("Items of the destroyed window must be removed")
+	}
+}
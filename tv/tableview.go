@@ -25,6 +25,7 @@ Predefined hotkeys:
   Insert - emits event TableActionNew
   Delete - emits event TableActionDelete
   F4 - Change sort mode
+  Shift+F10, right click - emits event TableActionMenu
 
 Events:
   OnDrawCell - called every time the table is going to draw a cell.
@@ -526,6 +527,20 @@ func (l *TableView) moveLeft(dx int) {
 	l.emitSelectionChange()
 }
 
+// resizeColumn changes the width of the selected column by dw
+func (l *TableView) resizeColumn(dw int) {
+	if l.selectedCol < 0 || l.selectedCol >= len(l.columns) {
+		return
+	}
+
+	col := &l.columns[l.selectedCol]
+	if col.Width+dw < 1 {
+		return
+	}
+	col.Width += dw
+	l.EnsureColVisible()
+}
+
 func (l *TableView) isColVisible(idx int) bool {
 	if idx < l.topCol {
 		return false
@@ -620,6 +635,20 @@ func (l *TableView) EnsureRowVisible() {
 	}
 }
 
+// scroll moves the visible rows by dy rows. The selected cell does not
+// change
+func (l *TableView) scroll(dy int) {
+	maxTop := l.rowCount - int(l.height.Get()-3)
+	top := l.topRow + dy
+	if top > maxTop {
+		top = maxTop
+	}
+	if top < 0 {
+		top = 0
+	}
+	l.topRow = top
+}
+
 func (l *TableView) mouseToCol(dx types.ACoordX) int {
 	shift := l.counterWidth()
 	if l.showVLines {
@@ -737,6 +766,75 @@ func (l *TableView) processMouseClick(ev Event) bool {
 	return true
 }
 
+// processDoubleClick emits TableActionEdit event if a user double clicks
+// a cell. The first click of double click has already selected the cell
+func (l *TableView) processDoubleClick(ev Event) bool {
+	dx := ev.X - l.pos.GetX()
+	dy := ev.Y - l.pos.GetY()
+
+	if dy < 2 || int(dy) == int(l.height.Get())-1 || int(dx) == int(l.width.Get())-1 {
+		return false
+	}
+	if l.topRow+int(dy)-2 >= l.rowCount {
+		return false
+	}
+
+	if l.selectedRow != -1 && l.selectedCol != -1 && l.onAction != nil {
+		ev := TableEvent{Action: TableActionEdit, Col: l.selectedCol, Row: l.selectedRow}
+		l.onAction(ev)
+	}
+	return true
+}
+
+// processRightClick selects the cell under the mouse and emits
+// TableActionMenu event
+func (l *TableView) processRightClick(ev Event) bool {
+	dx := ev.X - l.pos.GetX()
+	dy := ev.Y - l.pos.GetY()
+
+	if dy < 2 || int(dy) == int(l.height.Get())-1 || int(dx) == int(l.width.Get())-1 {
+		return false
+	}
+	if l.topRow+int(dy)-2 >= l.rowCount {
+		return false
+	}
+
+	ev.Key = term.MouseLeft
+	l.processMouseClick(ev)
+	l.emitContextMenu()
+	return true
+}
+
+func (l *TableView) emitContextMenu() {
+	if l.selectedRow != -1 && l.onAction != nil {
+		ev := TableEvent{Action: TableActionMenu, Col: l.selectedCol, Row: l.selectedRow}
+		l.onAction(ev)
+	}
+}
+
+// popupPos returns the screen position of the selected cell
+func (l *TableView) popupPos() (types.ACoordX, types.ACoordY) {
+	x, y := l.pos.Get()
+	if l.selectedRow >= l.topRow {
+		y += types.ACoordY(2 + l.selectedRow - l.topRow)
+	}
+
+	shift := l.counterWidth()
+	if l.showVLines {
+		shift++
+	}
+	for idx := l.topCol; idx < l.selectedCol && idx < len(l.columns); idx++ {
+		shift += l.columns[idx].Width
+		if l.showVLines {
+			shift++
+		}
+	}
+	if shift < int(l.width.Get()) {
+		x += types.ACoordX(shift)
+	}
+	return x, y
+}
+
 func (l *TableView) headerClicked(dx types.ACoordX) {
 	colID := l.mouseToCol(dx)
 	if colID == -1 {
@@ -775,6 +873,15 @@ that the control do not want or cannot process the event and the caller sends
 the event to the control parent
 */
 func (l *TableView) ProcessEvent(event Event) bool {
+	if event.Type == EventMouseWheel && l.Enabled() {
+		if event.Key == term.MouseWheelUp {
+			l.scroll(-mouseWheelLines)
+		} else {
+			l.scroll(mouseWheelLines)
+		}
+		return true
+	}
+
 	if !l.Active() || !l.Enabled() {
 		return false
 	}
@@ -788,9 +895,18 @@ func (l *TableView) ProcessEvent(event Event) bool {
 			}
 		}
 
+		switch {
+		case event.Is("ctrl+left"):
+			l.resizeColumn(-1)
+			return true
+		case event.Is("ctrl+right"):
+			l.resizeColumn(1)
+			return true
+		}
+
 		switch event.Key {
 		case term.KeyHome:
-			if event.Mod == term.ModAlt {
+			if event.Alt() {
 				l.selectedRow = 0
 				l.EnsureRowVisible()
 				l.emitSelectionChange()
@@ -799,7 +915,7 @@ func (l *TableView) ProcessEvent(event Event) bool {
 			}
 			return true
 		case term.KeyEnd:
-			if event.Mod == term.ModAlt {
+			if event.Alt() {
 				l.selectedRow = l.rowCount - 1
 				l.EnsureRowVisible()
 				l.emitSelectionChange()
@@ -835,6 +951,12 @@ func (l *TableView) ProcessEvent(event Event) bool {
 				ev := TableEvent{Action: TableActionDelete, Col: l.selectedCol, Row: l.selectedRow}
 				l.onAction(ev)
 			}
+		case term.KeyF10:
+			if !event.Shift() {
+				return false
+			}
+			l.emitContextMenu()
+			return true
 		case term.KeyInsert:
 			if l.onAction != nil {
 				ev := TableEvent{Action: TableActionNew, Col: l.selectedCol, Row: l.selectedRow}
@@ -867,6 +989,10 @@ func (l *TableView) ProcessEvent(event Event) bool {
 		}
 	case EventMouse:
 		return l.processMouseClick(event)
+	case EventDoubleClick:
+		return l.processDoubleClick(event)
+	case EventRightClick:
+		return l.processRightClick(event)
 	}
 
 	return false
@@ -0,0 +1,1017 @@
+package tv
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/atotto/clipboard"
+	"github.com/mattn/go-runewidth"
+	term "github.com/nsf/termbox-go"
+
+	"github.com/prospero78/goTV/tv/autoheight"
+	"github.com/prospero78/goTV/tv/autowidth"
+	"github.com/prospero78/goTV/tv/types"
+)
+
+// textPos is a position in the editor text: line number and rune index
+// inside the line
+type textPos struct {
+	line, col int
+}
+
+func (p textPos) less(o textPos) bool {
+	return p.line < o.line || p.line == o.line && p.col < o.col
+}
+
+// textRow is one screen row of the editor: runes from start to end of
+// the line. A line takes a few rows if word wrap is on
+type textRow struct {
+	line, start, end int
+}
+
+// textEdit is an undo step: the text removed at pos is replaced with the
+// inserted text. cursor is the cursor position before the change
+type textEdit struct {
+	pos      textPos
+	removed  string
+	inserted string
+	cursor   textPos
+}
+
+/*
+TextEditor is a multi-line text edit control. Keys:
+  - arrows, Home, End, PgUp, PgDn - move the cursor by character, line and
+    page; Ctrl+Left and Ctrl+Right move by word, Ctrl+Home and Ctrl+End move
+    to the start and to the end of the text
+  - Shift with any movement key selects text, Ctrl+A selects all
+  - Ctrl+C or Ctrl+Insert copies, Ctrl+X or Shift+Delete cuts, Ctrl+V or
+    Shift+Insert pastes the selection through the system clipboard
+  - Ctrl+Z undoes and Ctrl+Y redoes changes, the undo history is unlimited
+  - Insert toggles insert and overwrite modes
+  - Tab inserts spaces up to the next tab stop, Shift+Tab moves the focus to
+    the next control
+
+Mouse click moves the cursor, dragging selects text, the wheel scrolls the
+text. The vertical scrollbar is at the right side of the control.
+Wide runes (e.g. CJK) take two columns. Tabs in the text are replaced with
+spaces.
+TextEditor calls OnChange callback after every change of its text. Event
+type is EventChanged
+*/
+type TextEditor struct {
+	TBaseControl
+	lines [][]rune
+	// cursor position and the selection anchor. The text between them is
+	// selected, the selection is empty if they are the same
+	cursor textPos
+	anchor textPos
+	// display column the cursor keeps while moving up and down
+	wantX int
+	// the first displayed row and the first displayed column if word wrap
+	// is off
+	topRow  int
+	leftCol int
+	// screen rows of the text, they are recalculated after the text or
+	// the control width changes
+	rows      []textRow
+	rowsWidth int
+
+	wordWrap  bool
+	overwrite bool
+	readonly  bool
+	tabSize   int
+
+	undo []textEdit
+	redo []textEdit
+	// typing is true while a user types characters one by one: they are
+	// undone at once
+	typing bool
+
+	onChange func(Event)
+
+	autoWidth  types.IAutoWidth
+	autoHeight types.IAutoHeight
+}
+
+/*
+CreateTextEditor creates a new multi-line text editor.
+parent - is container that keeps the control.
+width and height - are minimal size of the control.
+scale - the way of scaling the control when the parent is resized. Use DoNotScale constant if the
+control should keep its original size.
+*/
+func CreateTextEditor(parent IControl, width, height int, scale int) *TextEditor {
+	e := &TextEditor{
+		TBaseControl: NewBaseControl(),
+		lines:        [][]rune{{}},
+		tabSize:      4,
+		autoWidth:    autowidth.New(),
+		autoHeight:   autoheight.New(),
+	}
+
+	if width == 0 {
+		width = 10
+		e.autoWidth.Set()
+	}
+	if height == 0 {
+		height = 3
+		e.autoHeight.Set()
+	}
+
+	e.SetSize(width, height)
+	e.SetConstraints(width, height)
+	e.parent = parent
+	e.SetTabStop(true)
+	e.SetScale(scale)
+
+	if parent != nil {
+		parent.AddChild(e)
+	}
+
+	return e
+}
+
+// runeWidth returns the number of screen columns the rune takes
+func runeWidth(r rune) int {
+	if w := runewidth.RuneWidth(r); w > 1 {
+		return w
+	}
+	return 1
+}
+
+// textWidth returns the number of columns for the text, the last column
+// is the scrollbar
+func (e *TextEditor) textWidth() int {
+	w := int(e.width.Get()) - 1
+	if w < 1 {
+		w = 1
+	}
+	return w
+}
+
+// layout returns the screen rows of the text
+func (e *TextEditor) layout() []textRow {
+	width := e.textWidth()
+	if e.rows != nil && e.rowsWidth == width {
+		return e.rows
+	}
+
+	e.rows = e.rows[:0]
+	e.rowsWidth = width
+	for i, line := range e.lines {
+		if !e.wordWrap || len(line) == 0 {
+			e.rows = append(e.rows, textRow{line: i, start: 0, end: len(line)})
+			continue
+		}
+
+		for start := 0; start < len(line); {
+			end, x := start, 0
+			for end < len(line) && (end == start || x+runeWidth(line[end]) <= width) {
+				x += runeWidth(line[end])
+				end++
+			}
+			// break the line after the last space of the row
+			if end < len(line) {
+				for sp := end - 1; sp > start; sp-- {
+					if line[sp] == ' ' {
+						end = sp + 1
+						break
+					}
+				}
+			}
+			e.rows = append(e.rows, textRow{line: i, start: start, end: end})
+			start = end
+		}
+	}
+	return e.rows
+}
+
+// invalidateRows makes the editor recalculate the screen rows
+func (e *TextEditor) invalidateRows() {
+	e.rows = nil
+}
+
+// lastRow returns true if the row is the last row of its line
+func (e *TextEditor) lastRow(idx int) bool {
+	rows := e.layout()
+	return idx == len(rows)-1 || rows[idx+1].line != rows[idx].line
+}
+
+// rowOf returns the index of the screen row that contains the position
+func (e *TextEditor) rowOf(p textPos) int {
+	rows := e.layout()
+	for i, r := range rows {
+		if r.line == p.line && (p.col < r.end || e.lastRow(i)) {
+			return i
+		}
+	}
+	return len(rows) - 1
+}
+
+// xOf returns the display column of the position inside its row
+func (e *TextEditor) xOf(p textPos) int {
+	r := e.layout()[e.rowOf(p)]
+	x := 0
+	for _, ch := range e.lines[p.line][r.start:p.col] {
+		x += runeWidth(ch)
+	}
+	return x
+}
+
+// posAt returns the position in the row that is the closest to the
+// display column x
+func (e *TextEditor) posAt(idx int, x int) textPos {
+	r := e.layout()[idx]
+	line := e.lines[r.line]
+	col, cx := r.start, 0
+	for col < r.end && cx+runeWidth(line[col]) <= x {
+		cx += runeWidth(line[col])
+		col++
+	}
+	// the end of a wrapped row is the start of the next row
+	if col == r.end && col > r.start && !e.lastRow(idx) {
+		col--
+	}
+	return textPos{line: r.line, col: col}
+}
+
+// scrollToCursor scrolls the text to make the cursor visible
+func (e *TextEditor) scrollToCursor() {
+	_, h := e.Size()
+	row := e.rowOf(e.cursor)
+	if row < e.topRow {
+		e.topRow = row
+	} else if row >= e.topRow+h {
+		e.topRow = row - h + 1
+	}
+
+	if e.wordWrap {
+		e.leftCol = 0
+		return
+	}
+	width := e.textWidth()
+	x := e.xOf(e.cursor)
+	if x < e.leftCol {
+		e.leftCol = x
+	} else if x >= e.leftCol+width {
+		e.leftCol = x - width + 1
+	}
+}
+
+// scroll moves the visible part of the text by dy rows without moving
+// the cursor
+func (e *TextEditor) scroll(dy int) {
+	_, h := e.Size()
+	e.topRow += dy
+	if max := len(e.layout()) - h; e.topRow > max {
+		e.topRow = max
+	}
+	if e.topRow < 0 {
+		e.topRow = 0
+	}
+}
+
+// moveTo moves the cursor. If selecting is true the selection is extended
+// to the new position, otherwise the selection is dropped
+func (e *TextEditor) moveTo(p textPos, selecting bool) {
+	e.cursor = p
+	if !selecting {
+		e.anchor = p
+	}
+	e.typing = false
+	e.scrollToCursor()
+}
+
+// moveToX moves the cursor and remembers its display column for the
+// following up and down moves
+func (e *TextEditor) moveToX(p textPos, selecting bool) {
+	e.moveTo(p, selecting)
+	e.wantX = e.xOf(p)
+}
+
+func (e *TextEditor) charLeft(selecting bool) {
+	p := e.cursor
+	if !selecting && e.HasSelection() {
+		p, _ = e.selection()
+	} else if p.col > 0 {
+		p.col--
+	} else if p.line > 0 {
+		p.line--
+		p.col = len(e.lines[p.line])
+	}
+	e.moveToX(p, selecting)
+}
+
+func (e *TextEditor) charRight(selecting bool) {
+	p := e.cursor
+	if !selecting && e.HasSelection() {
+		_, p = e.selection()
+	} else if p.col < len(e.lines[p.line]) {
+		p.col++
+	} else if p.line < len(e.lines)-1 {
+		p.line++
+		p.col = 0
+	}
+	e.moveToX(p, selecting)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (e *TextEditor) wordLeft(selecting bool) {
+	p := e.cursor
+	if p.col == 0 {
+		e.charLeft(selecting)
+		return
+	}
+
+	line := e.lines[p.line]
+	for p.col > 0 && !isWordRune(line[p.col-1]) {
+		p.col--
+	}
+	for p.col > 0 && isWordRune(line[p.col-1]) {
+		p.col--
+	}
+	e.moveToX(p, selecting)
+}
+
+func (e *TextEditor) wordRight(selecting bool) {
+	p := e.cursor
+	line := e.lines[p.line]
+	if p.col == len(line) {
+		e.charRight(selecting)
+		return
+	}
+
+	for p.col < len(line) && isWordRune(line[p.col]) {
+		p.col++
+	}
+	for p.col < len(line) && !isWordRune(line[p.col]) {
+		p.col++
+	}
+	e.moveToX(p, selecting)
+}
+
+// moveRows moves the cursor up (dy < 0) or down by dy screen rows
+func (e *TextEditor) moveRows(dy int, selecting bool) {
+	rows := e.layout()
+	row := e.rowOf(e.cursor) + dy
+	if row < 0 {
+		e.moveToX(textPos{}, selecting)
+		return
+	}
+	if row >= len(rows) {
+		last := len(e.lines) - 1
+		e.moveToX(textPos{line: last, col: len(e.lines[last])}, selecting)
+		return
+	}
+	e.moveTo(e.posAt(row, e.wantX), selecting)
+}
+
+// page moves the cursor and the visible text by a page up (dir=-1) or
+// down (dir=1)
+func (e *TextEditor) page(dir int, selecting bool) {
+	_, h := e.Size()
+	e.scroll(dir * h)
+	e.moveRows(dir*h, selecting)
+}
+
+func (e *TextEditor) home(selecting bool) {
+	e.moveToX(textPos{line: e.cursor.line}, selecting)
+}
+
+func (e *TextEditor) end(selecting bool) {
+	e.moveToX(textPos{line: e.cursor.line, col: len(e.lines[e.cursor.line])}, selecting)
+}
+
+// selection returns the start and the end of the selected text
+func (e *TextEditor) selection() (from, to textPos) {
+	if e.anchor.less(e.cursor) {
+		return e.anchor, e.cursor
+	}
+	return e.cursor, e.anchor
+}
+
+// textRange returns the text between two positions
+func (e *TextEditor) textRange(from, to textPos) string {
+	if from.line == to.line {
+		return string(e.lines[from.line][from.col:to.col])
+	}
+
+	var buf strings.Builder
+	buf.WriteString(string(e.lines[from.line][from.col:]))
+	for i := from.line + 1; i < to.line; i++ {
+		buf.WriteByte('\n')
+		buf.WriteString(string(e.lines[i]))
+	}
+	buf.WriteByte('\n')
+	buf.WriteString(string(e.lines[to.line][:to.col]))
+	return buf.String()
+}
+
+// textEnd returns the position after the text inserted at pos
+func textEnd(pos textPos, text string) textPos {
+	parts := strings.Split(text, "\n")
+	last := len([]rune(parts[len(parts)-1]))
+	if len(parts) == 1 {
+		return textPos{line: pos.line, col: pos.col + last}
+	}
+	return textPos{line: pos.line + len(parts) - 1, col: last}
+}
+
+// replace replaces the text between from and to with the text and
+// returns the position after the inserted text
+func (e *TextEditor) replace(from, to textPos, text string) textPos {
+	head := e.lines[from.line][:from.col]
+	tail := e.lines[to.line][to.col:]
+
+	parts := strings.Split(text, "\n")
+	changed := make([][]rune, len(parts))
+	for i, part := range parts {
+		changed[i] = []rune(part)
+	}
+	last := len(changed) - 1
+	end := textPos{line: from.line + last, col: len(changed[last])}
+	if last == 0 {
+		end.col += len(head)
+	}
+	changed[0] = append(append([]rune{}, head...), changed[0]...)
+	changed[last] = append(changed[last], tail...)
+
+	lines := make([][]rune, 0, len(e.lines)-(to.line-from.line)+last)
+	lines = append(lines, e.lines[:from.line]...)
+	lines = append(lines, changed...)
+	lines = append(lines, e.lines[to.line+1:]...)
+	e.lines = lines
+
+	e.invalidateRows()
+	return end
+}
+
+// edit replaces the text between from and to with the text as one undo
+// step and moves the cursor after the inserted text. typed text is merged
+// with the previous typed text into one undo step
+func (e *TextEditor) edit(from, to textPos, text string, typed bool) {
+	removed := e.textRange(from, to)
+	if removed == "" && text == "" {
+		return
+	}
+
+	n := len(e.undo)
+	if typed && e.typing && n > 0 && !strings.Contains(text, "\n") &&
+		textEnd(e.undo[n-1].pos, e.undo[n-1].inserted) == from {
+		e.undo[n-1].inserted += text
+		e.undo[n-1].removed += removed
+	} else {
+		e.undo = append(e.undo, textEdit{pos: from, removed: removed, inserted: text, cursor: e.cursor})
+	}
+	e.redo = nil
+
+	end := e.replace(from, to, text)
+	e.moveToX(end, false)
+	e.typing = typed
+	e.changed()
+}
+
+func (e *TextEditor) changed() {
+	if e.onChange != nil {
+		e.onChange(Event{Type: EventChanged, Target: e})
+	}
+}
+
+// normalizeText removes carriage returns and control characters and
+// replaces tabs with spaces. x is the display column of the first rune
+func (e *TextEditor) normalizeText(text string, x int) string {
+	var buf strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\n':
+			buf.WriteRune(r)
+			x = 0
+		case r == '\t':
+			n := e.tabSize - x%e.tabSize
+			buf.WriteString(strings.Repeat(" ", n))
+			x += n
+		case !unicode.IsControl(r):
+			buf.WriteRune(r)
+			x += runeWidth(r)
+		}
+	}
+	return buf.String()
+}
+
+// lineX returns the display column of the position from the line start
+func (e *TextEditor) lineX(p textPos) int {
+	x := 0
+	for _, r := range e.lines[p.line][:p.col] {
+		x += runeWidth(r)
+	}
+	return x
+}
+
+// InsertText replaces the selection with the text or inserts the text at
+// the cursor position. It is one undo step
+func (e *TextEditor) InsertText(text string) {
+	if e.readonly {
+		return
+	}
+
+	from, to := e.selection()
+	e.edit(from, to, e.normalizeText(text, e.lineX(from)), false)
+}
+
+// insertRune inserts or overwrites one typed rune
+func (e *TextEditor) insertRune(r rune) {
+	if e.readonly {
+		return
+	}
+
+	from, to := e.selection()
+	if e.overwrite && from == to && to.col < len(e.lines[to.line]) {
+		to.col++
+	}
+	e.edit(from, to, string(r), true)
+}
+
+// tab inserts spaces up to the next tab stop
+func (e *TextEditor) tab() {
+	if e.readonly {
+		return
+	}
+
+	from, to := e.selection()
+	x := e.lineX(from)
+	e.edit(from, to, strings.Repeat(" ", e.tabSize-x%e.tabSize), true)
+}
+
+func (e *TextEditor) newLine() {
+	if e.readonly {
+		return
+	}
+
+	from, to := e.selection()
+	e.edit(from, to, "\n", false)
+}
+
+// deleteSelection removes the selected text. Returns false if nothing is
+// selected
+func (e *TextEditor) deleteSelection() bool {
+	if !e.HasSelection() {
+		return false
+	}
+	from, to := e.selection()
+	e.edit(from, to, "", false)
+	return true
+}
+
+func (e *TextEditor) backspace() {
+	if e.readonly || e.deleteSelection() {
+		return
+	}
+
+	to := e.cursor
+	e.charLeft(false)
+	e.edit(e.cursor, to, "", false)
+}
+
+func (e *TextEditor) del() {
+	if e.readonly || e.deleteSelection() {
+		return
+	}
+
+	from := e.cursor
+	e.charRight(false)
+	to := e.cursor
+	e.cursor, e.anchor = from, from
+	e.edit(from, to, "", false)
+}
+
+// Text returns the editor text. Lines are separated with "\n"
+func (e *TextEditor) Text() string {
+	last := len(e.lines) - 1
+	return e.textRange(textPos{}, textPos{line: last, col: len(e.lines[last])})
+}
+
+// SetText replaces the editor text, moves the cursor to the start of the
+// text and clears the undo history
+func (e *TextEditor) SetText(text string) {
+	e.lines = e.lines[:0]
+	for _, line := range strings.Split(e.normalizeText(text, 0), "\n") {
+		e.lines = append(e.lines, []rune(line))
+	}
+	e.undo, e.redo = nil, nil
+	e.invalidateRows()
+	e.topRow, e.leftCol = 0, 0
+	e.moveToX(textPos{}, false)
+	e.changed()
+}
+
+// LineCount returns the number of lines in the text
+func (e *TextEditor) LineCount() int {
+	return len(e.lines)
+}
+
+// Line returns the line of the text by its number
+func (e *TextEditor) Line(idx int) string {
+	if idx < 0 || idx >= len(e.lines) {
+		return ""
+	}
+	return string(e.lines[idx])
+}
+
+// CursorPos returns the line number and the rune index inside the line
+// of the cursor
+func (e *TextEditor) CursorPos() (line, col int) {
+	return e.cursor.line, e.cursor.col
+}
+
+// SetCursorPos moves the cursor and drops the selection. The position is
+// adjusted to fit the text
+func (e *TextEditor) SetCursorPos(line, col int) {
+	if line >= len(e.lines) {
+		line = len(e.lines) - 1
+	}
+	if line < 0 {
+		line = 0
+	}
+	if col > len(e.lines[line]) {
+		col = len(e.lines[line])
+	}
+	if col < 0 {
+		col = 0
+	}
+	e.moveToX(textPos{line: line, col: col}, false)
+}
+
+// HasSelection returns true if some text is selected
+func (e *TextEditor) HasSelection() bool {
+	return e.cursor != e.anchor
+}
+
+// SelectedText returns the selected text
+func (e *TextEditor) SelectedText() string {
+	from, to := e.selection()
+	return e.textRange(from, to)
+}
+
+// SelectAll selects the whole text
+func (e *TextEditor) SelectAll() {
+	last := len(e.lines) - 1
+	e.anchor = textPos{}
+	e.moveToX(textPos{line: last, col: len(e.lines[last])}, true)
+}
+
+// Copy copies the selected text to the clipboard
+func (e *TextEditor) Copy() {
+	if e.HasSelection() {
+		_ = clipboard.WriteAll(e.SelectedText())
+	}
+}
+
+// Cut moves the selected text to the clipboard
+func (e *TextEditor) Cut() {
+	if e.readonly || !e.HasSelection() {
+		return
+	}
+	e.Copy()
+	e.deleteSelection()
+}
+
+// Paste replaces the selection with the clipboard text
+func (e *TextEditor) Paste() {
+	if e.readonly {
+		return
+	}
+	if s, err := clipboard.ReadAll(); err == nil && s != "" {
+		e.InsertText(s)
+	}
+}
+
+// CanUndo returns true if there are changes to undo
+func (e *TextEditor) CanUndo() bool {
+	return len(e.undo) > 0
+}
+
+// CanRedo returns true if there are undone changes to redo
+func (e *TextEditor) CanRedo() bool {
+	return len(e.redo) > 0
+}
+
+// Undo reverts the last change. Returns false if there is nothing to undo
+func (e *TextEditor) Undo() bool {
+	if e.readonly || len(e.undo) == 0 {
+		return false
+	}
+
+	step := e.undo[len(e.undo)-1]
+	e.undo = e.undo[:len(e.undo)-1]
+	e.redo = append(e.redo, step)
+
+	e.replace(step.pos, textEnd(step.pos, step.inserted), step.removed)
+	e.moveToX(step.cursor, false)
+	e.changed()
+	return true
+}
+
+// Redo repeats the last undone change. Returns false if there is nothing
+// to redo
+func (e *TextEditor) Redo() bool {
+	if e.readonly || len(e.redo) == 0 {
+		return false
+	}
+
+	step := e.redo[len(e.redo)-1]
+	e.redo = e.redo[:len(e.redo)-1]
+	e.undo = append(e.undo, step)
+
+	end := e.replace(step.pos, textEnd(step.pos, step.removed), step.inserted)
+	e.moveToX(end, false)
+	e.changed()
+	return true
+}
+
+// Overwrite returns true if typed characters replace the text under the
+// cursor
+func (e *TextEditor) Overwrite() bool {
+	return e.overwrite
+}
+
+// SetOverwrite switches between insert and overwrite modes
+func (e *TextEditor) SetOverwrite(overwrite bool) {
+	e.overwrite = overwrite
+}
+
+// ReadOnly returns true if a user cannot change the text
+func (e *TextEditor) ReadOnly() bool {
+	return e.readonly
+}
+
+// SetReadOnly enables or disables changing the text. A user still can
+// move the cursor, select and copy the text of a read-only editor
+func (e *TextEditor) SetReadOnly(readonly bool) {
+	e.readonly = readonly
+}
+
+// WordWrap returns true if long lines are wrapped at word boundaries
+func (e *TextEditor) WordWrap() bool {
+	return e.wordWrap
+}
+
+// SetWordWrap enables or disables word wrap. If it is off the text is
+// scrolled horizontally to follow the cursor
+func (e *TextEditor) SetWordWrap(wrap bool) {
+	if wrap == e.wordWrap {
+		return
+	}
+	e.wordWrap = wrap
+	e.invalidateRows()
+	e.scrollToCursor()
+}
+
+// TabSize returns the distance between tab stops
+func (e *TextEditor) TabSize() int {
+	return e.tabSize
+}
+
+// SetTabSize changes the distance between tab stops. It affects only the
+// text that is inserted after the change
+func (e *TextEditor) SetTabSize(size int) {
+	if size > 0 {
+		e.tabSize = size
+	}
+}
+
+// OnChange sets the callback that is called after the text is changed
+func (e *TextEditor) OnChange(fn func(Event)) {
+	e.onChange = fn
+}
+
+// Draw repaints the control
+func (e *TextEditor) Draw() {
+	if e.hidden {
+		return
+	}
+
+	PushAttributes()
+	defer PopAttributes()
+
+	x, y := e.pos.Get()
+	w, h := e.Size()
+
+	fg, bg := RealColor(e.fg, e.Style(), ColorEditText), RealColor(e.bg, e.Style(), ColorEditBack)
+	if !e.Enabled() {
+		fg, bg = RealColor(e.fg, e.Style(), ColorDisabledText), RealColor(e.bg, e.Style(), ColorDisabledBack)
+	} else if e.Active() {
+		fg, bg = RealColor(e.fg, e.Style(), ColorEditActiveText), RealColor(e.bg, e.Style(), ColorEditActiveBack)
+	}
+	fgSel, bgSel := RealColor(ColorDefault, e.Style(), ColorSelectionText), RealColor(ColorDefault, e.Style(), ColorSelectionBack)
+
+	SetTextColor(fg)
+	SetBackColor(bg)
+	FillRect(x, y, w, h, ' ')
+
+	rows := e.layout()
+	width := e.textWidth()
+	from, to := e.selection()
+	for dy := 0; dy < h && e.topRow+dy < len(rows); dy++ {
+		r := rows[e.topRow+dy]
+		line := e.lines[r.line]
+		cx := -e.leftCol
+		for col := r.start; col < r.end && cx < width; col++ {
+			rw := runeWidth(line[col])
+			if cx >= 0 && cx+rw <= width {
+				p := textPos{line: r.line, col: col}
+				if !p.less(from) && p.less(to) {
+					SetTextColor(fgSel)
+					SetBackColor(bgSel)
+				} else {
+					SetTextColor(fg)
+					SetBackColor(bg)
+				}
+				PutChar(x+types.ACoordX(cx), y+types.ACoordY(dy), line[col])
+			}
+			cx += rw
+		}
+	}
+
+	row := e.rowOf(e.cursor)
+	DrawScrollBar(x+types.ACoordX(w-1), y, 1, h, ThumbPosition(row, len(rows), h))
+
+	if e.Active() {
+		cx := e.xOf(e.cursor) - e.leftCol
+		if row >= e.topRow && row < e.topRow+h && cx >= 0 && cx < width {
+			SetCursorPos(x+types.ACoordX(cx), y+types.ACoordY(row-e.topRow))
+		} else {
+			HideCursor()
+		}
+	}
+}
+
+// processMouse moves the cursor to the clicked position or scrolls the
+// text if the scrollbar is clicked
+func (e *TextEditor) processMouse(ev Event, selecting bool) {
+	w, h := e.Size()
+	dx := int(ev.X - e.pos.GetX())
+	dy := int(ev.Y - e.pos.GetY())
+	rows := e.layout()
+
+	if dx == w-1 && !selecting {
+		switch {
+		case dy == 0:
+			e.moveRows(-1, false)
+		case dy == h-1:
+			e.moveRows(1, false)
+		default:
+			if row := ItemByThumbPosition(dy, len(rows), h); row >= 0 {
+				e.moveRows(row-e.rowOf(e.cursor), false)
+			}
+		}
+		return
+	}
+
+	row := e.topRow + dy
+	if dy < 0 {
+		row = e.topRow - 1
+	}
+	switch {
+	case row < 0:
+		e.moveToX(textPos{}, selecting)
+	case row >= len(rows):
+		last := len(e.lines) - 1
+		e.moveToX(textPos{line: last, col: len(e.lines[last])}, selecting)
+	default:
+		if dx < 0 {
+			dx = 0
+		}
+		e.moveToX(e.posAt(row, dx+e.leftCol), selecting)
+	}
+}
+
+/*
+ProcessEvent processes all events come from the control parent. If a control
+processes an event it should return true. If the method returns false it means
+that the control do not want or cannot process the event and the caller sends
+the event to the control parent
+*/
+func (e *TextEditor) ProcessEvent(ev Event) bool {
+	if ev.Type == EventMouseWheel && e.Enabled() {
+		if ev.Key == term.MouseWheelUp {
+			e.scroll(-mouseWheelLines)
+		} else {
+			e.scroll(mouseWheelLines)
+		}
+		return true
+	}
+
+	if !e.Active() || !e.Enabled() {
+		return false
+	}
+
+	switch ev.Type {
+	case EventActivate:
+		if ev.X == 0 {
+			HideCursor()
+		}
+	case EventPaste:
+		e.InsertText(ev.Msg)
+		return true
+	case EventMouse:
+		if ev.Key == term.MouseLeft && ev.Mod&term.ModMotion == 0 {
+			e.processMouse(ev, false)
+			return true
+		}
+	case EventMouseDrag:
+		if ev.Key == term.MouseLeft {
+			e.processMouse(ev, true)
+			return true
+		}
+	case EventKey:
+		return e.processKey(ev)
+	}
+
+	return false
+}
+
+func (e *TextEditor) processKey(ev Event) bool {
+	shift := ev.Shift()
+	switch ev.Key {
+	case term.KeyArrowLeft:
+		if ev.Ctrl() {
+			e.wordLeft(shift)
+		} else {
+			e.charLeft(shift)
+		}
+	case term.KeyArrowRight:
+		if ev.Ctrl() {
+			e.wordRight(shift)
+		} else {
+			e.charRight(shift)
+		}
+	case term.KeyArrowUp:
+		e.moveRows(-1, shift)
+	case term.KeyArrowDown:
+		e.moveRows(1, shift)
+	case term.KeyPgup:
+		e.page(-1, shift)
+	case term.KeyPgdn:
+		e.page(1, shift)
+	case term.KeyHome:
+		if ev.Ctrl() {
+			e.moveToX(textPos{}, shift)
+		} else {
+			e.home(shift)
+		}
+	case term.KeyEnd:
+		if ev.Ctrl() {
+			last := len(e.lines) - 1
+			e.moveToX(textPos{line: last, col: len(e.lines[last])}, shift)
+		} else {
+			e.end(shift)
+		}
+	case term.KeyCtrlA:
+		e.SelectAll()
+	case term.KeyCtrlC:
+		e.Copy()
+	case term.KeyCtrlX:
+		e.Cut()
+	case term.KeyCtrlV:
+		e.Paste()
+	case term.KeyCtrlZ:
+		e.Undo()
+	case term.KeyCtrlY:
+		e.Redo()
+	case term.KeyInsert:
+		switch {
+		case shift:
+			e.Paste()
+		case ev.Ctrl():
+			e.Copy()
+		default:
+			e.overwrite = !e.overwrite
+		}
+	case term.KeyDelete:
+		if shift {
+			e.Cut()
+		} else {
+			e.del()
+		}
+	case term.KeyBackspace, term.KeyBackspace2:
+		e.backspace()
+	case term.KeyEnter:
+		e.newLine()
+	case term.KeyTab:
+		if shift {
+			return false
+		}
+		e.tab()
+	case term.KeySpace:
+		e.insertRune(' ')
+	default:
+		if ev.Ch == 0 || ev.Alt() {
+			return false
+		}
+		e.insertRune(ev.Ch)
+	}
+	return true
+}
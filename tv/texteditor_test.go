@@ -0,0 +1,171 @@
+package tv
+
+import (
+	"strings"
+	"testing"
+
+	term "github.com/nsf/termbox-go"
+)
+
+func typeText(text string) {
+	for _, r := range text {
+		switch r {
+		case '\n':
+			ProcessEvent(keyEvent(term.KeyEnter))
+		case ' ':
+			ProcessEvent(keyEvent(term.KeySpace))
+		default:
+			ProcessEvent(Event{Type: EventKey, Ch: r})
+		}
+	}
+}
+
+func newTestEditor(w, h int) (*TextEditor, *HeadlessScreen) {
+	scr := NewHeadlessScreen(60, 20)
+	InitLibrary(scr)
+
+	wnd := AddWindow(0, 0, w+2, h+2, "Editor", false, false)
+	wnd.SetPack(Vertical)
+	ed := CreateTextEditor(wnd, w, h, 1)
+	wnd.ResizeChildren()
+	wnd.PlaceChildren()
+	ActivateControl(wnd, ed)
+	return ed, scr
+}
+
+func TestTextEditorEditing(t *testing.T) {
+	ed, _ := newTestEditor(20, 5)
+	defer DeinitLibrary()
+
+	changes := 0
+	ed.OnChange(func(ev Event) {
+		changes++
+	})
+
+	typeText("ab c\nxyz")
+	if ed.Text() != "ab c\nxyz" || ed.LineCount() != 2 || changes != 8 {
+		t.Fatalf("Wrong text: %q, %v changes", ed.Text(), changes)
+	}
+
+	// typed characters are undone at once
+	for _, want := range []string{"ab c\n", "ab c", ""} {
+		ed.Undo()
+		if ed.Text() != want {
+			t.Errorf("Wrong undo: %q instead of %q", ed.Text(), want)
+		}
+	}
+	if ed.Undo() {
+		t.Errorf("Nothing to undo")
+	}
+	ProcessEvent(keyEvent(term.KeyCtrlY))
+	ProcessEvent(keyEvent(term.KeyCtrlY))
+	ProcessEvent(keyEvent(term.KeyCtrlY))
+	if ed.Text() != "ab c\nxyz" || ed.CanRedo() {
+		t.Errorf("Wrong redo: %q", ed.Text())
+	}
+
+	// word jumps and selection
+	ProcessEvent(Event{Type: EventKey, Key: term.KeyHome, Mod: ModCtrl})
+	ProcessEvent(Event{Type: EventKey, Key: term.KeyArrowRight, Mod: ModCtrl})
+	if line, col := ed.CursorPos(); line != 0 || col != 3 {
+		t.Errorf("Ctrl+Right must jump to the next word: %v:%v", line, col)
+	}
+	ProcessEvent(Event{Type: EventKey, Key: term.KeyArrowDown, Mod: ModShift})
+	if ed.SelectedText() != "c\nxyz" {
+		t.Errorf("Wrong selection: %q", ed.SelectedText())
+	}
+	typeText("d")
+	if ed.Text() != "ab d" || ed.HasSelection() {
+		t.Errorf("Typing must replace the selection: %q", ed.Text())
+	}
+	ProcessEvent(Event{Type: EventKey, Key: term.KeyArrowLeft, Mod: ModCtrl})
+	ProcessEvent(Event{Type: EventKey, Key: term.KeyArrowLeft, Mod: ModCtrl})
+	if _, col := ed.CursorPos(); col != 0 {
+		t.Errorf("Ctrl+Left must jump to the word start: %v", col)
+	}
+
+	// overwrite mode, tab and deletion
+	ProcessEvent(keyEvent(term.KeyInsert))
+	typeText("XY")
+	ProcessEvent(keyEvent(term.KeyInsert))
+	ProcessEvent(keyEvent(term.KeyTab))
+	if ed.Text() != "XY   d" || !ed.CanUndo() {
+		t.Errorf("Wrong overwrite or tab: %q", ed.Text())
+	}
+	ProcessEvent(keyEvent(term.KeyBackspace2))
+	ProcessEvent(keyEvent(term.KeyDelete))
+	if ed.Text() != "XY d" {
+		t.Errorf("Wrong deletion: %q", ed.Text())
+	}
+	ed.Undo()
+	ed.Undo()
+	if ed.Text() != "XY   d" {
+		t.Errorf("Deletion must be undone: %q", ed.Text())
+	}
+	ed.Undo()
+	if ed.Text() != "ab d" {
+		t.Errorf("Typed text must be undone at once: %q", ed.Text())
+	}
+
+	// pasted text is inserted at once, tabs are expanded
+	ed.SetText("")
+	ProcessEvent(Event{Type: EventPaste, Msg: "a\tb\r\n\tc"})
+	if ed.Text() != "a   b\n    c" {
+		t.Errorf("Wrong pasted text: %q", ed.Text())
+	}
+	ProcessEvent(keyEvent(term.KeyCtrlA))
+	ed.SetReadOnly(true)
+	typeText("z")
+	if ed.SelectedText() != ed.Text() || ed.Text() != "a   b\n    c" {
+		t.Errorf("Read-only editor must not change: %q", ed.Text())
+	}
+}
+
+func TestTextEditorView(t *testing.T) {
+	ed, scr := newTestEditor(11, 3)
+	defer DeinitLibrary()
+
+	ed.SetText("日本語 text\nhello world again\nthree\nfour")
+	ed.SetCursorPos(0, 2)
+	RefreshScreen()
+	if x, y, _ := scr.Cursor(); x != 5 || y != 1 {
+		t.Errorf("Wide runes must take two columns: %v:%v", x, y)
+	}
+
+	ProcessEvent(keyEvent(term.KeyArrowDown))
+	if line, col := ed.CursorPos(); line != 1 || col != 4 {
+		t.Errorf("Down must keep the column: %v:%v", line, col)
+	}
+
+	ProcessEvent(keyEvent(term.KeyEnd))
+	RefreshScreen()
+	if ed.leftCol != 8 || !strings.HasPrefix(scr.Lines()[2], "║rld again") {
+		t.Errorf("Long line must be scrolled: %v <%v>", ed.leftCol, scr.Lines()[2])
+	}
+
+	ed.SetWordWrap(true)
+	if rows := ed.layout(); len(rows) != 7 || rows[1].start != 4 || rows[3].start != 6 || rows[4].start != 12 {
+		t.Errorf("Wrong wrapped rows: %v", rows)
+	}
+	ed.SetCursorPos(1, 3)
+	ProcessEvent(keyEvent(term.KeyArrowDown))
+	ProcessEvent(keyEvent(term.KeyArrowDown))
+	if line, col := ed.CursorPos(); line != 1 || col != 15 || ed.topRow != 2 {
+		t.Errorf("Down must move by screen rows: %v:%v, top %v", line, col, ed.topRow)
+	}
+
+	ProcessEvent(keyEvent(term.KeyPgdn))
+	if line, _ := ed.CursorPos(); line != 3 {
+		t.Errorf("PgDn must move by page: %v", line)
+	}
+
+	// click moves the cursor, drag selects
+	ed.SetWordWrap(false)
+	ed.SetCursorPos(0, 0)
+	ProcessEvent(mouseAt(3, 1, term.MouseLeft, 0))
+	ProcessEvent(mouseAt(5, 2, term.MouseLeft, term.ModMotion))
+	ProcessEvent(mouseAt(5, 2, term.MouseRelease, 0))
+	if ed.SelectedText() != "本語 text\nhell" {
+		t.Errorf("Wrong mouse selection: %q", ed.SelectedText())
+	}
+}
@@ -333,6 +333,15 @@ that the control do not want or cannot process the event and the caller sends
 the event to the control parent
 */
 func (l *TextView) ProcessEvent(event Event) bool {
+	if event.Type == EventMouseWheel && l.Enabled() {
+		if event.Key == term.MouseWheelUp {
+			l.moveUp(mouseWheelLines)
+		} else {
+			l.moveDown(mouseWheelLines)
+		}
+		return true
+	}
+
 	if !l.Active() || !l.Enabled() {
 		return false
 	}
@@ -116,20 +116,25 @@ type theme struct {
 	objects map[string]string
 }
 
-// NewThemeManager creates a new theme manager
-func initThemeManager() {
-	themeManager = new(ThemeManager)
-	ThemeReset()
+// newThemeManager creates a new theme manager
+func newThemeManager() *ThemeManager {
+	s := new(ThemeManager)
+	s.reset()
+	return s
 }
 
 // ThemeReset removes all loaded themes from cache and reinitialize
 // the default theme
 func ThemeReset() {
+	themeManager.reset()
+}
+
+func (s *ThemeManager) reset() {
 	thememtx.Lock()
 	defer thememtx.Unlock()
 
-	themeManager.current = defaultTheme
-	themeManager.themes = make(map[string]theme)
+	s.current = defaultTheme
+	s.themes = make(map[string]theme)
 
 	defTheme := theme{parent: "", title: "Default Theme", author: "Vladimir V. Markelov", version: "1.0"}
 	defTheme.colors = make(map[string]term.Attribute)
@@ -147,6 +152,7 @@ func ThemeReset() {
 	defTheme.objects[ObjSparkChart] = "█"
 	defTheme.objects[ObjTableView] = "─│┼▼▲"
 	defTheme.objects[ObjButton] = "▀█"
+	defTheme.objects[ObjMenu] = "─│┌┐└┘├┤√►"
 
 	defTheme.colors[ColorDisabledText] = ColorBlackBold
 	defTheme.colors[ColorDisabledBack] = ColorWhite
@@ -154,6 +160,7 @@ func ThemeReset() {
 	defTheme.colors[ColorBack] = ColorBlack
 	defTheme.colors[ColorViewBack] = ColorBlack
 	defTheme.colors[ColorViewText] = ColorWhite
+	defTheme.colors[ColorHotkeyText] = ColorYellowBold
 
 	defTheme.colors[ColorControlText] = ColorWhite
 	defTheme.colors[ColorControlBack] = ColorBlack
@@ -162,6 +169,7 @@ func ThemeReset() {
 	defTheme.colors[ColorControlShadow] = ColorBlue
 	defTheme.colors[ColorControlDisabledText] = ColorWhite
 	defTheme.colors[ColorControlDisabledBack] = ColorBlack
+	defTheme.colors[ColorControlHotkeyText] = ColorYellowBold
 
 	defTheme.colors[ColorButtonText] = ColorWhite
 	defTheme.colors[ColorButtonBack] = ColorGreen
@@ -170,11 +178,14 @@ func ThemeReset() {
 	defTheme.colors[ColorButtonShadow] = ColorBlue
 	defTheme.colors[ColorButtonDisabledText] = ColorWhite
 	defTheme.colors[ColorButtonDisabledBack] = ColorBlack
+	defTheme.colors[ColorButtonHotkeyText] = ColorYellowBold
 
 	defTheme.colors[ColorEditText] = ColorBlack
 	defTheme.colors[ColorEditBack] = ColorWhite
 	defTheme.colors[ColorEditActiveText] = ColorBlack
 	defTheme.colors[ColorEditActiveBack] = ColorYellow
+	defTheme.colors[ColorEditInvalidText] = ColorWhiteBold
+	defTheme.colors[ColorEditInvalidBack] = ColorRed
 	defTheme.colors[ColorSelectionText] = ColorYellow
 	defTheme.colors[ColorSelectionBack] = ColorBlue
 
@@ -209,7 +220,20 @@ func ThemeReset() {
 	defTheme.colors[ColorTableHeaderText] = ColorWhite
 	defTheme.colors[ColorTableHeaderBack] = ColorBlack
 
-	themeManager.themes[defaultTheme] = defTheme
+	defTheme.colors[ColorMenuBack] = ColorWhite
+	defTheme.colors[ColorMenuText] = ColorBlack
+	defTheme.colors[ColorMenuActiveBack] = ColorGreen
+	defTheme.colors[ColorMenuActiveText] = ColorBlack
+	defTheme.colors[ColorMenuDisabledText] = ColorBlackBold
+	defTheme.colors[ColorMenuHotkeyText] = ColorRed
+	defTheme.colors[ColorMenuActiveHotkeyText] = ColorRed
+
+	defTheme.colors[ColorStatusBack] = ColorWhite
+	defTheme.colors[ColorStatusText] = ColorBlack
+	defTheme.colors[ColorStatusHotkeyText] = ColorRed
+	defTheme.colors[ColorStatusDisabledText] = ColorBlackBold
+
+	s.themes[defaultTheme] = defTheme
 }
 
 // SysColor returns attribute by its id for the current theme.
@@ -0,0 +1,174 @@
+package tv
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+Timer is a handle of a function scheduled with AfterFunc or Every. The
+function always runs in the main loop like functions passed to Post do,
+so it can change controls directly and the screen is repainted after the
+function finishes.
+
+A timer can be bound to a window with SetOwner. The timer is stopped
+automatically when the window is closed with Composer.DestroyWindow, so
+callbacks never touch controls of a destroyed window:
+
+	lbl := tv.CreateLabel(wnd, tv.AutoSize, tv.AutoSize, "", tv.Fixed)
+	tv.Every(time.Second, func() {
+		lbl.SetTitle(time.Now().Format("15:04:05"))
+	}).SetOwner(wnd)
+*/
+type Timer struct {
+	mtx     sync.Mutex
+	loop    *mainLoop
+	fn      func()
+	period  time.Duration
+	timer   *time.Timer
+	owner   IControl
+	stopped bool
+}
+
+// AfterFunc calls fn in the main loop of the default application once
+// after d passes. Inside event handlers it works with the active
+// application, use Application.AfterFunc for other applications
+func AfterFunc(d time.Duration, fn func()) *Timer {
+	return callLoop().startTimer(d, 0, fn)
+}
+
+// Every calls fn in the main loop of the default application every d
+// until the timer is stopped. The next period starts after fn finishes, so
+// slow callbacks never pile up in the event queue. Inside event handlers
+// Every works with the active application, use Application.Every for other
+// applications
+func Every(d time.Duration, fn func()) *Timer {
+	return callLoop().startTimer(d, d, fn)
+}
+
+// startTimer creates and starts a timer. Without a loop the returned timer
+// is already stopped
+func (l *mainLoop) startTimer(d, period time.Duration, fn func()) *Timer {
+	t := &Timer{loop: l, fn: fn, period: period}
+	if l == nil {
+		t.stopped = true
+		return t
+	}
+
+	l.mtx.Lock()
+	if l.timers == nil {
+		l.timers = make(map[*Timer]struct{})
+	}
+	l.timers[t] = struct{}{}
+	l.mtx.Unlock()
+
+	t.mtx.Lock()
+	t.timer = time.AfterFunc(d, t.post)
+	t.mtx.Unlock()
+
+	return t
+}
+
+// post sends the timer callback to the main loop
+func (t *Timer) post() {
+	t.loop.post(t.fire)
+}
+
+// fire runs the callback inside the main loop and restarts the timer if
+// it is periodic
+func (t *Timer) fire() {
+	t.mtx.Lock()
+	stopped := t.stopped
+	t.mtx.Unlock()
+	if stopped {
+		return
+	}
+
+	if t.period == 0 {
+		t.Stop()
+	}
+
+	t.fn()
+
+	t.mtx.Lock()
+	if !t.stopped {
+		t.timer.Reset(t.period)
+	}
+	t.mtx.Unlock()
+}
+
+// Stop cancels the timer. Returns false if the timer has been already
+// stopped or a one-shot timer has already fired
+func (t *Timer) Stop() bool {
+	t.mtx.Lock()
+	if t.stopped {
+		t.mtx.Unlock()
+		return false
+	}
+	t.stopped = true
+	t.timer.Stop()
+	t.mtx.Unlock()
+
+	t.loop.mtx.Lock()
+	delete(t.loop.timers, t)
+	t.loop.mtx.Unlock()
+
+	return true
+}
+
+// Stopped returns true if the timer is cancelled or a one-shot timer has
+// already fired
+func (t *Timer) Stopped() bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	return t.stopped
+}
+
+// SetOwner binds the timer to a window or to any control inside a window.
+// The timer is stopped when the window is destroyed. Returns the timer
+// itself to make chaining possible
+func (t *Timer) SetOwner(owner IControl) *Timer {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.owner = owner
+	return t
+}
+
+// Owner returns the control the timer is bound to
+func (t *Timer) Owner() IControl {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	return t.owner
+}
+
+// stopTimers cancels all timers that are bound to the window or to its
+// children
+func (l *mainLoop) stopTimers(wnd IControl) {
+	var owned []*Timer
+
+	l.mtx.Lock()
+	for t := range l.timers {
+		if ownedBy(t.Owner(), wnd) {
+			owned = append(owned, t)
+		}
+	}
+	l.mtx.Unlock()
+
+	for _, t := range owned {
+		t.Stop()
+	}
+}
+
+// ownedBy returns true if ctrl is wnd or wnd is one of ctrl parents
+func ownedBy(ctrl, wnd IControl) bool {
+	for ctrl != nil {
+		if ctrl == wnd {
+			return true
+		}
+		ctrl = ctrl.Parent()
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package tv
+
+import (
+	"testing"
+	"time"
+)
+
+func startLoop(t *testing.T) func() {
+	return startLoopOn(t, NewHeadlessScreen(30, 5))
+}
+
+func TestAfterFunc(t *testing.T) {
+	stop := startLoop(t)
+	defer stop()
+
+	fired := make(chan struct{}, 2)
+	tm := AfterFunc(10*time.Millisecond, func() {
+		fired <- struct{}{}
+	})
+
+	select {
+	case <-fired:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timer did not fire")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if len(fired) != 0 {
+		t.Errorf("One-shot timer must fire only once")
+	}
+	if !tm.Stopped() || tm.Stop() {
+		t.Errorf("Fired one-shot timer must be stopped")
+	}
+
+	cancelled := AfterFunc(20*time.Millisecond, func() {
+		t.Errorf("Cancelled timer must not fire")
+	})
+	if !cancelled.Stop() {
+		t.Errorf("Active timer must be stopped successfully")
+	}
+	time.Sleep(40 * time.Millisecond)
+}
+
+func TestEvery(t *testing.T) {
+	stop := startLoop(t)
+	defer stop()
+
+	count := 0
+	done := make(chan struct{})
+	var tm *Timer
+	Invoke(func() {
+		tm = Every(5*time.Millisecond, func() {
+			count++
+			if count == 3 {
+				tm.Stop()
+				close(done)
+			}
+		})
+	})
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Ticker did not fire 3 times")
+	}
+	time.Sleep(20 * time.Millisecond)
+	Invoke(func() {
+		if count != 3 {
+			t.Errorf("Stopped ticker must not fire, count: %v", count)
+		}
+	})
+}
+
+func TestTimerOwner(t *testing.T) {
+	stop := startLoop(t)
+	defer stop()
+
+	var owned, free *Timer
+	Invoke(func() {
+		AddWindow(0, 0, 10, 3, "Keep", false, false)
+		wnd := AddWindow(0, 0, 10, 3, "Close", false, false)
+		lbl := CreateLabel(wnd, AutoSize, AutoSize, "", Fixed)
+		owned = Every(time.Hour, func() {}).SetOwner(lbl)
+		free = Every(time.Hour, func() {})
+		WindowManager().DestroyWindow(wnd)
+	})
+
+	if !owned.Stopped() {
+		t.Errorf("Timer must be stopped when its window is destroyed")
+	}
+	if free.Stopped() {
+		t.Errorf("Timer without owner must not be stopped")
+	}
+	free.Stop()
+}
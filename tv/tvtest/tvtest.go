@@ -0,0 +1,252 @@
+/*
+Package tvtest helps to test goTV windows with golden snapshots.
+
+A test creates a Harness that initializes the library with a headless
+screen, builds a widget tree as usual, renders it and compares the screen
+content with a golden file from testdata directory:
+
+	func TestDialog(t *testing.T) {
+		h := tvtest.New(t, 40, 12)
+		wnd := tv.AddWindow(0, 0, 30, 8, "Dialog", false, false)
+		tv.CreateButton(wnd, 0, 0, "OK", tv.Fixed, true, true)
+		h.AssertText("dialog")
+	}
+
+The content can be dumped as plain text (golden file name.txt) or as text
+with color annotations (golden file name.colors.txt). Run tests with
+flag -update to rewrite golden files with the current output:
+
+	go test ./... -update
+*/
+package tvtest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	term "github.com/nsf/termbox-go"
+
+	"github.com/prospero78/goTV/tv"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata directory")
+
+// GoldenDir is a directory with golden files relative to the package
+// under test
+var GoldenDir = "testdata"
+
+// Harness is a headless goTV instance bound to a test
+type Harness struct {
+	t      testing.TB
+	Screen *tv.HeadlessScreen
+}
+
+// New initializes the library with a new headless screen of the given
+// size. The library is finalized automatically after the test finishes
+func New(t testing.TB, width, height int) *Harness {
+	t.Helper()
+
+	scr := tv.NewHeadlessScreen(width, height)
+	if !tv.InitLibrary(scr) {
+		t.Fatalf("Failed to initialize library with headless screen")
+	}
+	t.Cleanup(tv.DeinitLibrary)
+
+	return &Harness{t: t, Screen: scr}
+}
+
+// Render repaints all windows
+func (h *Harness) Render() {
+	tv.RefreshScreen()
+}
+
+// Text renders all windows and returns the screen as plain text
+func (h *Harness) Text() string {
+	h.Render()
+	return Dump(h.Screen)
+}
+
+// Colors renders all windows and returns the screen as text with color
+// annotations. See DumpColors for format description
+func (h *Harness) Colors() string {
+	h.Render()
+	return DumpColors(h.Screen)
+}
+
+// AssertText renders all windows and compares the screen text with
+// golden file name.txt
+func (h *Harness) AssertText(name string) {
+	h.t.Helper()
+	CompareGolden(h.t, name+".txt", h.Text())
+}
+
+// AssertColors renders all windows and compares the screen text with
+// color annotations with golden file name.colors.txt
+func (h *Harness) AssertColors(name string) {
+	h.t.Helper()
+	CompareGolden(h.t, name+".colors.txt", h.Colors())
+}
+
+// Dump returns visible screen content as text: one line per screen row.
+// Trailing spaces are removed to make golden files easier to edit
+func Dump(scr *tv.HeadlessScreen) string {
+	var buf bytes.Buffer
+	for _, line := range scr.Lines() {
+		buf.WriteString(strings.TrimRight(line, " "))
+		buf.WriteByte('\n')
+	}
+
+	return buf.String()
+}
+
+// attrKeys are the symbols used to mark color pairs in DumpColors output
+const attrKeys = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+var colorNames = []string{"default", "black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// ColorName returns a stable text description of a color attribute,
+// e.g. "yellow bold"
+func ColorName(attr term.Attribute) string {
+	var parts []string
+
+	clr := int(attr & 0x1FF)
+	if clr < len(colorNames) {
+		parts = append(parts, colorNames[clr])
+	} else {
+		parts = append(parts, fmt.Sprintf("color%d", clr))
+	}
+	if attr&term.AttrBold != 0 {
+		parts = append(parts, "bold")
+	}
+	if attr&term.AttrUnderline != 0 {
+		parts = append(parts, "underline")
+	}
+	if attr&term.AttrReverse != 0 {
+		parts = append(parts, "reverse")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+/*
+DumpColors returns visible screen content with color annotations. The
+output has three sections: screen text, color map and color legend.
+The color map has the same size as the screen and every cell holds a
+symbol of the cell text and background color pair. The legend explains
+the symbols in order of their first appearance:
+
+	 ╔═Demo═╗
+	 ║      ║
+	--
+	abbbbbbb
+	abccccbb
+	--
+	a: white on black
+	b: white bold on blue
+	c: black on white
+*/
+func DumpColors(scr *tv.HeadlessScreen) string {
+	var text, colors, legend bytes.Buffer
+
+	width, height := scr.Size()
+	keys := make(map[[2]term.Attribute]byte)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			cell := scr.CellAt(x, y)
+			ch := cell.Ch
+			if ch == 0 {
+				ch = ' '
+			}
+			text.WriteRune(ch)
+
+			pair := [2]term.Attribute{cell.Fg, cell.Bg}
+			key, ok := keys[pair]
+			if !ok {
+				key = '?'
+				if len(keys) < len(attrKeys) {
+					key = attrKeys[len(keys)]
+				}
+				keys[pair] = key
+				fmt.Fprintf(&legend, "%c: %s on %s\n", key, ColorName(cell.Fg), ColorName(cell.Bg))
+			}
+			colors.WriteByte(key)
+		}
+		text.WriteByte('\n')
+		colors.WriteByte('\n')
+	}
+
+	return text.String() + "--\n" + colors.String() + "--\n" + legend.String()
+}
+
+// CompareGolden compares got with the content of golden file name in
+// GoldenDir. If the test runs with flag -update the golden file is
+// rewritten with got instead
+func CompareGolden(t testing.TB, name, got string) {
+	t.Helper()
+
+	path := filepath.Join(GoldenDir, name)
+	if *update {
+		if err := os.MkdirAll(GoldenDir, 0755); err != nil {
+			t.Fatalf("Failed to create golden directory: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("Failed to update golden file %v: %v", path, err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file %v (run test with -update to create it): %v", path, err)
+	}
+
+	if diff := Diff(string(want), got); diff != "" {
+		t.Errorf("Screen does not match golden file %v:\n%v", path, diff)
+	}
+}
+
+// Diff returns line-by-line difference between want and got or empty
+// string if they are equal. Lines that differ are prefixed with '-' for
+// want and '+' for got
+func Diff(want, got string) string {
+	if want == got {
+		return ""
+	}
+
+	wl := strings.Split(want, "\n")
+	gl := strings.Split(got, "\n")
+	n := len(wl)
+	if len(gl) > n {
+		n = len(gl)
+	}
+
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		var w, g string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+
+		if w == g {
+			fmt.Fprintf(&buf, " %3d |%s\n", i+1, w)
+			continue
+		}
+		if i < len(wl) {
+			fmt.Fprintf(&buf, "-%3d |%s\n", i+1, w)
+		}
+		if i < len(gl) {
+			fmt.Fprintf(&buf, "+%3d |%s\n", i+1, g)
+		}
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,57 @@
+package tvtest
+
+import (
+	"testing"
+
+	"github.com/prospero78/goTV/tv"
+)
+
+func TestLayoutHorizontal(t *testing.T) {
+	h := New(t, 40, 10)
+
+	wnd := tv.AddWindow(0, 0, 30, 8, "Layout", false, false)
+	wnd.SetPack(tv.Horizontal)
+	tv.CreateLabel(wnd, 0, 0, "Name", tv.Fixed)
+	tv.CreateEditField(wnd, 10, "value", 1)
+	frm := tv.CreateFrame(wnd, 8, 3, tv.BorderThin, 1)
+	frm.SetTitle("Box")
+
+	h.AssertText("layout_horizontal")
+}
+
+func TestLayoutVertical(t *testing.T) {
+	h := New(t, 40, 14)
+
+	wnd := tv.AddWindow(2, 1, 30, 10, "Vertical", false, false)
+	wnd.SetPack(tv.Vertical)
+	tv.CreateCheckBox(wnd, 0, "Check me", tv.Fixed, true)
+	tv.CreateRadio(wnd, 0, "Radio", tv.Fixed)
+	tv.CreateButton(wnd, 0, 0, "OK", tv.Fixed, true, true)
+	wnd.SetSize(34, 12)
+	wnd.ResizeChildren()
+	wnd.PlaceChildren()
+
+	h.AssertText("layout_vertical")
+}
+
+func TestColors(t *testing.T) {
+	h := New(t, 24, 6)
+
+	wnd := tv.AddWindow(0, 0, 20, 5, "Colors", false, false)
+	edit := tv.CreateEditField(wnd, 10, "text", 1)
+	tv.ActivateControl(wnd, edit)
+
+	h.AssertColors("colors")
+}
+
+func TestDiff(t *testing.T) {
+	if d := Diff("a\nb\n", "a\nb\n"); d != "" {
+		t.Errorf("Equal texts must have empty diff, got <%v>", d)
+	}
+
+	d := Diff("a\nb", "a\nc")
+	want := "   1 |a\n-  2 |b\n+  2 |c\n"
+	if d != want {
+		t.Errorf("Diff must be <%v> instead of <%v>", want, d)
+	}
+}
@@ -0,0 +1,286 @@
+package tv
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/*
+Validator checks the text of TEditField, like Turbo Vision TValidator.
+IsValidInput is called while a user types: it gets the text after the
+change and returns false to reject the change. It may complete the text,
+e.g. the picture validator inserts literal characters. fill is false when
+text is deleted, so the validator must not complete the text. Validate
+checks the complete text when the field loses focus or the dialog is
+closed, the error text is shown to a user.
+
+	edit.SetValidator(tv.NewPictureValidator("###-####"))
+	edit.SetValidator(tv.NewRangeValidator(1, 65535))
+*/
+type Validator interface {
+	IsValidInput(text string, fill bool) (string, bool)
+	Validate(text string) error
+}
+
+// validatedControl is a control that can check its content
+type validatedControl interface {
+	IControl
+	Validate() error
+}
+
+// ValidateControls validates all children of the parent that check their
+// content, e.g. edit fields with a validator. The first invalid control is
+// activated. Returns false if any control is invalid. Call it before a
+// dialog is closed with OK button
+func ValidateControls(parent IControl) bool {
+	first := validateChildren(parent)
+	if first == nil {
+		return true
+	}
+
+	ActivateControl(parent, first)
+	// show the error of the activated control
+	_ = first.Validate()
+	return false
+}
+
+// validateChildren validates all children of the parent and returns the
+// first invalid one
+func validateChildren(parent IControl) validatedControl {
+	var first validatedControl
+	for _, ctrl := range parent.Children() {
+		if v, ok := ctrl.(validatedControl); ok && v.Validate() != nil && first == nil {
+			first = v
+		}
+		if invalid := validateChildren(ctrl); invalid != nil && first == nil {
+			first = invalid
+		}
+	}
+	return first
+}
+
+// RangeValidator accepts integer numbers from Min to Max. A user can type
+// only digits, and minus sign if Min is negative. Message replaces the
+// default error text
+type RangeValidator struct {
+	Min     int64
+	Max     int64
+	Message string
+}
+
+// NewRangeValidator creates a validator of integer numbers from min to max
+func NewRangeValidator(min, max int64) *RangeValidator {
+	return &RangeValidator{Min: min, Max: max}
+}
+
+// IsValidInput rejects non-digit characters and numbers that cannot get
+// into the range after typing more digits
+func (v *RangeValidator) IsValidInput(text string, fill bool) (string, bool) {
+	digits := text
+	if strings.HasPrefix(text, "-") {
+		if v.Min >= 0 {
+			return text, false
+		}
+		digits = text[1:]
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return text, false
+		}
+	}
+	if digits == "" {
+		return text, true
+	}
+
+	n, err := strconv.ParseInt(text, 10, 64)
+	switch {
+	case err != nil:
+		return text, false
+	case n > 0 && n > v.Max:
+		return text, false
+	case v.Max < 0 && !strings.HasPrefix(text, "-"):
+		// only negative numbers fit the range
+		return text, false
+	case n < 0 && n < v.Min:
+		return text, false
+	}
+	return text, true
+}
+
+// Validate returns an error if the text is not a number in the range
+func (v *RangeValidator) Validate(text string) error {
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil || n < v.Min || n > v.Max {
+		return validationError(v.Message, fmt.Sprintf("Value must be a number from %v to %v", v.Min, v.Max))
+	}
+	return nil
+}
+
+// RegexpValidator accepts text that matches the regular expression. The
+// text is checked only by Validate. If Chars is not empty a user can type
+// only characters from Chars, e.g. "0123456789." for IP address. Message
+// replaces the default error text
+type RegexpValidator struct {
+	Regexp  *regexp.Regexp
+	Chars   string
+	Message string
+}
+
+// NewRegexpValidator creates a validator of text that matches the pattern.
+// The pattern is anchored: the whole text must match it. Returns error if
+// the pattern cannot be compiled
+func NewRegexpValidator(pattern string) (*RegexpValidator, error) {
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+	return &RegexpValidator{Regexp: re}, nil
+}
+
+// IsValidInput rejects characters that are not in Chars
+func (v *RegexpValidator) IsValidInput(text string, fill bool) (string, bool) {
+	if v.Chars == "" {
+		return text, true
+	}
+	for _, r := range text {
+		if !strings.ContainsRune(v.Chars, r) {
+			return text, false
+		}
+	}
+	return text, true
+}
+
+// Validate returns an error if the text does not match the expression
+func (v *RegexpValidator) Validate(text string) error {
+	if !v.Regexp.MatchString(text) {
+		return validationError(v.Message, "Invalid value")
+	}
+	return nil
+}
+
+/*
+PictureValidator accepts text that matches the picture mask like Turbo
+Vision TPXPictureValidator. Supported picture characters:
+
+	#	a digit
+	?	a letter
+	&	a letter, it is converted to uppercase
+	@	any character
+	!	any character, it is converted to uppercase
+	;	the next character is a literal
+
+All other characters are literals. Literals are inserted automatically
+while a user types, e.g. for "###-####" typing "5551" gives "555-1".
+Empty text is valid as in Turbo Vision. Message replaces the default error
+text
+*/
+type PictureValidator struct {
+	Message string
+
+	picture string
+	mask    []pictureChar
+}
+
+// pictureChar is a parsed picture character: kind is a picture character
+// or 0 for a literal ch
+type pictureChar struct {
+	kind rune
+	ch   rune
+}
+
+// match checks the rune against the picture character and returns the
+// rune to put into the text
+func (p pictureChar) match(r rune) (rune, bool) {
+	switch p.kind {
+	case '#':
+		return r, unicode.IsDigit(r)
+	case '?':
+		return r, unicode.IsLetter(r)
+	case '&':
+		return unicode.ToUpper(r), unicode.IsLetter(r)
+	case '@':
+		return r, true
+	case '!':
+		return unicode.ToUpper(r), true
+	}
+	return p.ch, unicode.ToUpper(r) == unicode.ToUpper(p.ch)
+}
+
+// NewPictureValidator creates a validator of text that matches the picture
+func NewPictureValidator(picture string) *PictureValidator {
+	v := &PictureValidator{picture: picture}
+	escaped := false
+	for _, r := range picture {
+		switch {
+		case escaped:
+			v.mask = append(v.mask, pictureChar{ch: r})
+			escaped = false
+		case r == ';':
+			escaped = true
+		case strings.ContainsRune("#?&@!", r):
+			v.mask = append(v.mask, pictureChar{kind: r})
+		default:
+			v.mask = append(v.mask, pictureChar{ch: r})
+		}
+	}
+	return v
+}
+
+// Picture returns the picture mask
+func (v *PictureValidator) Picture() string {
+	return v.picture
+}
+
+// IsValidInput checks the text against the mask. Skipped literals are
+// inserted, and if fill is true the literals after the text are appended
+func (v *PictureValidator) IsValidInput(text string, fill bool) (string, bool) {
+	var out []rune
+	i := 0
+	for _, r := range text {
+		for i < len(v.mask) && v.mask[i].kind == 0 && unicode.ToUpper(r) != unicode.ToUpper(v.mask[i].ch) {
+			out = append(out, v.mask[i].ch)
+			i++
+		}
+		if i >= len(v.mask) {
+			return text, false
+		}
+		ch, ok := v.mask[i].match(r)
+		if !ok {
+			return text, false
+		}
+		out = append(out, ch)
+		i++
+	}
+
+	if fill && len(out) > 0 {
+		for i < len(v.mask) && v.mask[i].kind == 0 {
+			out = append(out, v.mask[i].ch)
+			i++
+		}
+	}
+	return string(out), true
+}
+
+// Validate returns an error if the text does not fill the whole mask
+func (v *PictureValidator) Validate(text string) error {
+	if text == "" {
+		return nil
+	}
+	if fixed, ok := v.IsValidInput(text, false); !ok || fixed != text || len([]rune(text)) != len(v.mask) {
+		return validationError(v.Message, fmt.Sprintf("Value must match %v", v.picture))
+	}
+	return nil
+}
+
+// validationError returns the error with the custom message if it is set
+// or with the default one
+func validationError(message, def string) error {
+	if message == "" {
+		message = def
+	}
+	return errors.New(message)
+}
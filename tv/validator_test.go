@@ -0,0 +1,107 @@
+package tv
+
+import (
+	"testing"
+
+	term "github.com/nsf/termbox-go"
+)
+
+func TestValidators(t *testing.T) {
+	pic := NewPictureValidator("###-&&;#")
+	cases := []struct {
+		text  string
+		fill  bool
+		fixed string
+		ok    bool
+	}{
+		{"555", true, "555-", true},
+		{"555", false, "555", true},
+		{"555a", false, "555-A", true},
+		{"555-ab", true, "555-AB#", true},
+		{"55a", true, "55a", false},
+		{"555-AB#1", true, "555-AB#1", false},
+	}
+	for _, c := range cases {
+		fixed, ok := pic.IsValidInput(c.text, c.fill)
+		if fixed != c.fixed || ok != c.ok {
+			t.Errorf("Wrong picture input %q: %q %v", c.text, fixed, ok)
+		}
+	}
+	if pic.Validate("555-AB#") != nil || pic.Validate("") != nil || pic.Validate("555-A") == nil {
+		t.Errorf("Wrong picture validation")
+	}
+
+	rng := NewRangeValidator(-10, 200)
+	for text, ok := range map[string]bool{"": true, "-": true, "-1": true, "-11": false, "20": true, "201": false, "1a": false} {
+		if _, res := rng.IsValidInput(text, true); res != ok {
+			t.Errorf("Wrong range input %q: %v", text, res)
+		}
+	}
+	neg := NewRangeValidator(-100, -5)
+	for text, ok := range map[string]bool{"-": true, "-1": true, "-500": false, "5": false, "0": false} {
+		if _, res := neg.IsValidInput(text, true); res != ok {
+			t.Errorf("Wrong negative range input %q: %v", text, res)
+		}
+	}
+	rng.Message = "Out of range"
+	if rng.Validate("150") != nil || rng.Validate("-") == nil || rng.Validate("300").Error() != "Out of range" {
+		t.Errorf("Wrong range validation")
+	}
+
+	if _, err := NewRegexpValidator("(a"); err == nil {
+		t.Errorf("Invalid pattern must fail")
+	}
+	re, _ := NewRegexpValidator(`\d+(\.\d+){3}`)
+	re.Chars = "0123456789."
+	if _, ok := re.IsValidInput("10.0.x", true); ok {
+		t.Errorf("Characters out of Chars must be rejected")
+	}
+	if re.Validate("10.0.0.1") != nil || re.Validate("10.0.0") == nil || re.Validate("x10.0.0.1") == nil {
+		t.Errorf("Wrong regexp validation")
+	}
+}
+
+func TestEditFieldValidator(t *testing.T) {
+	InitLibrary(NewHeadlessScreen(40, 10))
+	defer DeinitLibrary()
+	sb := CreateStatusBar()
+
+	wnd := AddWindow(0, 0, 30, 6, "Phone", false, false)
+	wnd.SetPack(Vertical)
+	phone := CreateEditField(wnd, 10, "", Fixed)
+	phone.SetValidator(NewPictureValidator("###-####"))
+	other := CreateEditField(wnd, 10, "", Fixed)
+	wnd.ResizeChildren()
+	wnd.PlaceChildren()
+	ActivateControl(wnd, phone)
+
+	typeText("555x1")
+	if phone.Title() != "555-1" || phone.CursorPos() != 5 {
+		t.Errorf("Wrong typed text: %q, cursor %v", phone.Title(), phone.CursorPos())
+	}
+	ProcessEvent(keyEvent(term.KeyBackspace2))
+	ProcessEvent(keyEvent(term.KeyBackspace2))
+	if phone.Title() != "555" {
+		t.Errorf("Deletion must not fill literals: %q", phone.Title())
+	}
+	phone.Undo()
+	if phone.Title() != "555-" {
+		t.Errorf("Wrong undo: %q", phone.Title())
+	}
+
+	ProcessEvent(keyEvent(term.KeyTab))
+	if !other.Active() || phone.ValidationError() == nil || sb.Message() != "Value must match ###-####" {
+		t.Errorf("Field must be validated on focus loss: %v <%v>", phone.ValidationError(), sb.Message())
+	}
+	if ValidateControls(wnd) || !phone.Active() {
+		t.Errorf("Invalid field must be activated")
+	}
+
+	typeText("1234")
+	if phone.Title() != "555-1234" || phone.ValidationError() != nil || sb.Message() != "" {
+		t.Errorf("Fixed field must be valid: %q %v <%v>", phone.Title(), phone.ValidationError(), sb.Message())
+	}
+	if !ValidateControls(wnd) {
+		t.Errorf("Valid fields must pass")
+	}
+}
@@ -285,6 +285,9 @@ func (sf *TWindow) ProcessEvent(ev Event) bool {
 		}
 		return true
 	case EventKey:
+		if processHotkey(sf, ev) {
+			return true
+		}
 		if ev.Key == term.KeyTab || ev.Key == term.KeyArrowUp || ev.Key == term.KeyArrowDown {
 			if SendEventToChild(sf, ev) {
 				return true
@@ -382,8 +385,8 @@ func (sf *TWindow) SetMaximized(maximize bool) {
 		sf.posOrig.Y().Set(y)
 		sf.origWidth, sf.origHeight = sf.Size()
 		sf.maximized = true
-		sf.SetPos(0, 0)
-		width, height := ScreenSize()
+		x, y, width, height := comp.Desktop()
+		sf.SetPos(x, y)
 		sf.SetSize(width, height)
 	} else {
 		sf.maximized = false